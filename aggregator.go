@@ -0,0 +1,199 @@
+package larkslim
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Aggregator buffers messages per target and sends them as one combined
+	// message when Interval has passed since the first buffered message or
+	// when MaxMessages messages are buffered, whichever comes first.
+	// Messages are grouped by key in the combined message so that a storm
+	// of similar alerts reads as one line with a count.
+	Aggregator struct {
+		API *API
+
+		// defaults to 5 minutes, measured with the Clock of API
+		Interval time.Duration
+
+		// 0 means no limit
+		MaxMessages int
+
+		// title of the combined message, defaults to "Digest"
+		Title string
+
+		// "post" (default) or "card"
+		Format string
+
+		// header color of cards
+		Template string
+
+		// called with errors of sending in background
+		OnError func(target string, err error)
+
+		mutex   sync.Mutex
+		buffers map[string]*aggregatorBuffer
+	}
+
+	aggregatorBuffer struct {
+		keys   []string
+		groups map[string][]string
+		count  int
+		timer  Timer
+	}
+)
+
+// Add buffers text for target, grouped under key. Empty key groups the text
+// with other messages without a key.
+func (a *Aggregator) Add(target, key, text string) {
+	a.mutex.Lock()
+	if a.buffers == nil {
+		a.buffers = map[string]*aggregatorBuffer{}
+	}
+	buf := a.buffers[target]
+	if buf == nil {
+		buf = &aggregatorBuffer{
+			groups: map[string][]string{},
+		}
+		interval := a.Interval
+		if interval <= 0 {
+			interval = 5 * time.Minute
+		}
+		current := buf
+		buf.timer = a.API.clock().AfterFunc(interval, func() {
+			if err := a.flush(target, current); err != nil && a.OnError != nil {
+				a.OnError(target, err)
+			}
+		})
+		a.buffers[target] = buf
+	}
+	if _, ok := buf.groups[key]; !ok {
+		buf.keys = append(buf.keys, key)
+	}
+	buf.groups[key] = append(buf.groups[key], text)
+	buf.count++
+	full := a.MaxMessages > 0 && buf.count >= a.MaxMessages
+	a.mutex.Unlock()
+	if full {
+		if err := a.Flush(target); err != nil && a.OnError != nil {
+			a.OnError(target, err)
+		}
+	}
+}
+
+// Flush sends buffered messages of target now.
+func (a *Aggregator) Flush(target string) error {
+	return a.flush(target, nil)
+}
+
+// flush sends buffered messages of target. If only is not nil, messages are
+// sent only if the buffer of target is still only, so that the timer of a
+// buffer that has been flushed does not flush the next buffer early.
+func (a *Aggregator) flush(target string, only *aggregatorBuffer) error {
+	a.mutex.Lock()
+	buf := a.buffers[target]
+	if buf == nil || (only != nil && buf != only) {
+		a.mutex.Unlock()
+		return nil
+	}
+	delete(a.buffers, target)
+	a.mutex.Unlock()
+	buf.timer.Stop()
+	return a.send(target, buf)
+}
+
+// FlushAll sends buffered messages of all targets now, for example before
+// the program exits.
+func (a *Aggregator) FlushAll() (err error) {
+	a.mutex.Lock()
+	targets := make([]string, 0, len(a.buffers))
+	for target := range a.buffers {
+		targets = append(targets, target)
+	}
+	a.mutex.Unlock()
+	for _, target := range targets {
+		if e := a.Flush(target); e != nil {
+			err = e
+		}
+	}
+	return
+}
+
+func (a *Aggregator) send(target string, buf *aggregatorBuffer) error {
+	title := a.Title
+	if title == "" {
+		title = "Digest"
+	}
+	title = fmt.Sprintf("%s (%d)", title, buf.count)
+	if a.Format == "card" {
+		var b strings.Builder
+		for _, key := range buf.keys {
+			texts := buf.groups[key]
+			if key != "" {
+				fmt.Fprintf(&b, "**%s** (%d)\n", key, len(texts))
+			}
+			for _, text := range uniqueCounted(texts) {
+				b.WriteString(text)
+				b.WriteString("\n")
+			}
+		}
+		_, err := a.API.SendCard(target, Card{
+			Config: CardConfig{
+				WideScreenMode: true,
+				EnableForward:  true,
+			},
+			Header: CardHeader{
+				Title: CardHeaderTitle{
+					Tag:     "plain_text",
+					Content: title,
+				},
+				Template: a.Template,
+			},
+			Elements: []interface{}{
+				map[string]interface{}{
+					"tag":     "markdown",
+					"content": strings.TrimSpace(b.String()),
+				},
+			},
+		})
+		return err
+	}
+	var lines PostLines
+	for _, key := range buf.keys {
+		texts := buf.groups[key]
+		if key != "" {
+			lines = append(lines, PostLine{{Tag: "text", Text: fmt.Sprintf("[%s] (%d)", key, len(texts))}})
+		}
+		for _, text := range uniqueCounted(texts) {
+			lines = append(lines, PostLine{{Tag: "text", Text: text}})
+		}
+	}
+	_, err := a.API.SendPost(target, Post{
+		"zh_cn": PostOfLocale{
+			Title:   title,
+			Content: lines,
+		},
+	})
+	return err
+}
+
+// uniqueCounted removes duplicates in texts and appends the number of
+// occurrences to duplicated texts.
+func uniqueCounted(texts []string) (out []string) {
+	count := map[string]int{}
+	for _, text := range texts {
+		if count[text] == 0 {
+			out = append(out, text)
+		}
+		count[text]++
+	}
+	for i, text := range out {
+		if n := count[text]; n > 1 {
+			out[i] = fmt.Sprintf("%s (x%d)", text, n)
+		}
+	}
+	return
+}
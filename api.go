@@ -2,22 +2,30 @@ package larkslim
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"os"
+	"regexp"
 	"strings"
-	"sync"
 	"time"
 )
 
 const (
 	Prefix = "https://open.feishu.cn/open-apis"
 
-	getAccessToken = "/auth/v3/tenant_access_token/internal"
+	getAccessToken    = "/auth/v3/tenant_access_token/internal"
+	getAppAccessToken = "/auth/v3/app_access_token/internal"
+
+	// DefaultTokenExpiryMargin is the default of API.TokenExpiryMargin.
+	DefaultTokenExpiryMargin = 30 * time.Second
 )
 
 type (
@@ -27,11 +35,139 @@ type (
 
 		Timeout time.Duration
 
+		// defaults to Prefix, change it to use a proxy or a fake server
+		BaseURL string
+
+		// if set, used instead of a client with DefaultTransport and Timeout
+		HTTPClient *http.Client
+
+		// defaults to SystemClock
+		Clock Clock
+
+		// if set, called before each request is sent with the complete
+		// request and its body, to add headers required by gateways
+		Signer func(req *http.Request, body []byte) error
+
 		Debugger func(args ...interface{})
 
-		accessToken          string
-		accessTokenExpiredAt time.Time
-		mutex                sync.Mutex
+		// called once for each deprecated endpoint in use, defaults to
+		// Debugger
+		Warn func(args ...interface{})
+
+		// if set, all sent messages are appended to it
+		Transcript TranscriptStore
+
+		// if set, called after every attempt to send a message by any of
+		// the Send* methods, for example for compliance logging; messageId
+		// is empty if err is not nil; also called for UpdateMessage with
+		// empty target
+		OnMessageSent func(target, msgType, messageId string, err error)
+
+		// applied in order to the content of every message before it is
+		// sent, see SendFilter
+		SendFilters []SendFilter
+
+		// if set, files are scanned before they are uploaded
+		Scanner Scanner
+
+		// maximum number of retries of failed requests, zero to disable;
+		// POST requests like v4 sends are not retried after timeouts,
+		// im/v1 sends are retried with a uuid so they are sent only once
+		MaxRetries int
+
+		// decides whether to retry a request after the attempt (starting
+		// from zero) failed with err and how long to wait, defaults to
+		// DefaultRetryPolicy
+		RetryPolicy func(attempt int, err error) (wait time.Duration, retry bool)
+
+		// if set, requests wait for the limiter before they are sent
+		RateLimiter *RateLimiter
+
+		// limiters of specific endpoints, keyed by the full path of the
+		// request URL, e.g. "/open-apis/message/v4/send/"
+		EndpointRateLimiters map[string]*RateLimiter
+
+		// version of endpoints (V4 or V1) used by methods available on both
+		// legacy v4 and im/v1 APIs, defaults to V4
+		Version string
+
+		// versions of specific endpoints overriding Version, keyed by
+		// endpoint name, e.g. "message.send", "chat.get" or "chat.list"
+		EndpointVersions map[string]string
+
+		// if true, responses containing fields unknown to the response
+		// structs are treated as errors, to detect API changes in tests
+		Strict bool
+
+		// translations used by SendLocalized
+		Catalog Catalog
+
+		// locale of SendLocalized if UserLocale is not set or the catalog
+		// doesn't have the locale of the user
+		DefaultLocale string
+
+		// returns the locale of the user for SendLocalized, for example
+		// from a custom attribute; the contact API has no language setting
+		// of users, so DefaultLocale is used if it is not set
+		UserLocale func(user UserInfo) string
+
+		// messages sent to targets during their quiet hours are held and
+		// sent when the quiet hours end, unless sent with the Critical
+		// option; use empty string as key to apply to all other targets
+		QuietHours map[string]QuietHours
+
+		// if greater than zero, sending the same content to the same target
+		// again within this duration is skipped without error
+		DedupWindow time.Duration
+
+		// if set, the access token, keys of uploaded images and the dedup
+		// window are stored in it, for example to share them between
+		// processes with Redis; defaults to a cache in memory
+		Cache Cache
+
+		// if set, the access token is stored in it instead of Cache, for
+		// example to share it between processes with a FileTokenStore
+		TokenStore TokenStore
+
+		// the access token is refreshed this long before it expires, to
+		// allow for slow requests and clocks of other hosts sharing the
+		// Cache or TokenStore; defaults to DefaultTokenExpiryMargin
+		TokenExpiryMargin time.Duration
+
+		// if greater than zero, read-only requests (for example GetUserInfo
+		// and GetChatInfo) without response after this duration are sent
+		// again, and the first response is used, to reduce tail latency;
+		// downloads like GetImage and GetFile are never hedged
+		HedgeDelay time.Duration
+
+		// MIME types of images that can be uploaded, images of other types
+		// are rejected before uploading; defaults to DefaultImageTypes
+		AllowedImageTypes []string
+
+		// if greater than zero, results of GetUserInfo, GetChatInfo and
+		// ListAllChats are kept in Cache for this duration; use
+		// InvalidateOnEvent to drop results changed by events
+		ReadCacheTTL time.Duration
+
+		// if set, called after every request with its result and the
+		// metadata of the context given to WithContext, for example to
+		// collect metrics
+		OnRequest func(RequestInfo)
+
+		// set by WithContext
+		ctx context.Context
+
+		// set by WithUserAccessToken, used instead of the tenant access
+		// token
+		accessToken string
+
+		// internal state shared by copies of the API
+		shared *apiState
+	}
+
+	cachedToken struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
 	}
 
 	Protected struct {
@@ -76,10 +212,51 @@ type (
 
 	Groups []Group
 
+	ChatInfoResponse struct {
+		APIResponse
+		Data ChatInfo `json:"data"`
+	}
+
+	// https://open.feishu.cn/document/server-docs/group/chat/get-2
+	ChatInfo struct {
+		Avatar                 string    `json:"avatar"`
+		Name                   string    `json:"name"`
+		Description            string    `json:"description"`
+		I18nNames              I18nNames `json:"i18n_names"`
+		AddMemberPermission    string    `json:"add_member_permission"`
+		ShareCardPermission    string    `json:"share_card_permission"`
+		AtAllPermission        string    `json:"at_all_permission"`
+		EditPermission         string    `json:"edit_permission"`
+		OwnerIdType            string    `json:"owner_id_type"`
+		OwnerId                string    `json:"owner_id"`
+		ChatMode               string    `json:"chat_mode"`
+		ChatType               string    `json:"chat_type"`
+		ChatTag                string    `json:"chat_tag"`
+		JoinMessageVisibility  string    `json:"join_message_visibility"`
+		LeaveMessageVisibility string    `json:"leave_message_visibility"`
+		MembershipApproval     string    `json:"membership_approval"`
+		ModerationPermission   string    `json:"moderation_permission"`
+		External               bool      `json:"external"`
+		TenantKey              string    `json:"tenant_key"`
+		UserCount              int       `json:"user_count,string"`
+		BotCount               int       `json:"bot_count,string"`
+
+		// "chat", or "thread" for topic groups
+		GroupMessageType string `json:"group_message_type"`
+	}
+
+	I18nNames struct {
+		ZhCn string `json:"zh_cn,omitempty"`
+		EnUs string `json:"en_us,omitempty"`
+		JaJp string `json:"ja_jp,omitempty"`
+	}
+
 	GroupsResponse struct {
 		APIResponse
 		Data struct {
-			Groups Groups `json:"groups"`
+			Groups    Groups `json:"groups"`
+			HasMore   bool   `json:"has_more"`
+			PageToken string `json:"page_token"`
 		} `json:"data"`
 	}
 
@@ -87,12 +264,40 @@ type (
 		APIResponse
 		Data struct {
 			MessageId string `json:"message_id"`
+			// im/v1 only
+			ThreadId string `json:"thread_id"`
+			ChatId   string `json:"chat_id"`
 		} `json:"data"`
 	}
 
+	// https://open.feishu.cn/document/server-docs/contact-v3/user/get
 	UserInfo struct {
-		Name   string `json:"name"`
-		OpenId string `json:"open_id"`
+		Name            string `json:"name"`
+		EnName          string `json:"en_name"`
+		OpenId          string `json:"open_id"`
+		UnionId         string `json:"union_id"`
+		UserId          string `json:"user_id"`
+		EmployeeNo      string `json:"employee_no"`
+		Email           string `json:"email"`
+		EnterpriseEmail string `json:"enterprise_email"`
+		Mobile          string `json:"mobile"`
+		JobTitle        string `json:"job_title"`
+		Avatar          struct {
+			Avatar72     string `json:"avatar_72"`
+			Avatar240    string `json:"avatar_240"`
+			Avatar640    string `json:"avatar_640"`
+			AvatarOrigin string `json:"avatar_origin"`
+		} `json:"avatar"`
+		Status struct {
+			IsFrozen    bool `json:"is_frozen"`
+			IsResigned  bool `json:"is_resigned"`
+			IsActivated bool `json:"is_activated"`
+			IsExited    bool `json:"is_exited"`
+			IsUnjoin    bool `json:"is_unjoin"`
+		} `json:"status"`
+
+		// open department ids
+		DepartmentIds []string `json:"department_ids"`
 	}
 
 	UserInfoResponse struct {
@@ -102,25 +307,76 @@ type (
 		} `json:"data"`
 	}
 
+	// EventResponse is an event callback in schema 1.0. Use ParseEvent to
+	// parse events of both schemas.
 	EventResponse struct {
+		Uuid  string `json:"uuid"`
+		Ts    string `json:"ts"`
 		Type  string `json:"type"`
 		Token string `json:"token"`
 
+		// "2.0" if the event is converted from schema 2.0
+		Schema string `json:"schema,omitempty"`
+
+		// schema 2.0 only
+		Header EventHeader `json:"header"`
+
 		// type == "url_verification"
 		Challenge string `json:"challenge"`
 
 		// type == "event_callback"
 		Event struct {
 			ChatId           string `json:"open_chat_id"`
+			ChatType         string `json:"chat_type"`
 			Type             string `json:"type"`
 			MsgType          string `json:"msg_type"`
+			MessageId        string `json:"open_message_id"`
+			RootId           string `json:"root_id"`
+			ParentId         string `json:"parent_id"`
+			IsMention        bool   `json:"is_mention"`
 			Text             string `json:"text"`
 			TextWithoutAtBot string `json:"text_without_at_bot"`
 			OpenId           string `json:"open_id"`
 			UserOpenId       string `json:"user_open_id"`
+			EmployeeId       string `json:"employee_id"`
+			UnionId          string `json:"union_id"`
+			AppId            string `json:"app_id"`
+			TenantKey        string `json:"tenant_key"`
+
+			// "user" or "app", schema 2.0 only
+			SenderType string `json:"sender_type,omitempty"`
+
+			// id of the topic or thread of the message, schema 2.0 only
+			ThreadId string `json:"thread_id,omitempty"`
+
+			// mentioned users and bots, schema 2.0 only
+			Mentions []Mention `json:"mentions,omitempty"`
+
+			// JSON content of the message, schema 2.0 only, see
+			// ParseEventContent
+			Content string `json:"content,omitempty"`
+
+			// msg_type == "post", "image" or "file", schema 1.0 only
+			Title       string `json:"title,omitempty"`
+			ImageKey    string `json:"image_key,omitempty"`
+			ImageUrl    string `json:"image_url,omitempty"`
+			ImageWidth  int    `json:"image_width,omitempty"`
+			ImageHeight int    `json:"image_height,omitempty"`
+			FileKey     string `json:"file_key,omitempty"`
+			FileName    string `json:"file_name,omitempty"`
 		} `json:"event"`
 	}
 
+	// EventHeader is the header of events in schema 2.0.
+	EventHeader struct {
+		EventId    string `json:"event_id"`
+		EventType  string `json:"event_type"`
+		CreateTime string `json:"create_time"`
+		Token      string `json:"token"`
+		AppId      string `json:"app_id"`
+		TenantKey  string `json:"tenant_key"`
+	}
+
 	UploadResponse struct {
 		APIResponse
 		Data struct {
@@ -137,6 +393,7 @@ type (
 		ImageKey string `json:"image_key,omitempty"`
 		Width    int    `json:"width,omitempty"`
 		Height   int    `json:"height,omitempty"`
+		Language string `json:"language,omitempty"`
 	}
 
 	PostLine []PostTag
@@ -150,6 +407,36 @@ type (
 
 	Post map[string]PostOfLocale
 
+	// SendFilter inspects the content of a message before it is sent and
+	// returns the content to send, for example with secrets redacted, or an
+	// error to block the message. Content is TextContent, PostContent,
+	// ImageContent, FileContent or Card.
+	SendFilter func(target, msgType string, content interface{}) (interface{}, error)
+
+	// contents of messages passed to SendFilters
+	TextContent struct {
+		Text string `json:"text"`
+	}
+
+	ImageContent struct {
+		ImageKey string `json:"image_key"`
+	}
+
+	PostContent struct {
+		Post Post `json:"post"`
+	}
+
+	FileContent struct {
+		FileKey string `json:"file_key"`
+	}
+
+	AudioContent struct {
+		FileKey string `json:"file_key"`
+
+		// in milliseconds
+		Duration int `json:"duration"`
+	}
+
 	Card struct {
 		Config   CardConfig    `json:"config"`
 		Header   CardHeader    `json:"header"`
@@ -159,6 +446,10 @@ type (
 	CardConfig struct {
 		WideScreenMode bool `json:"wide_screen_mode"`
 		EnableForward  bool `json:"enable_forward"`
+
+		// if true, updates of the card are shown to all members of the
+		// chat instead of only the user who clicked it
+		UpdateMulti bool `json:"update_multi,omitempty"`
 	}
 
 	// https://open.feishu.cn/document/ukTMukTMukTM/ukTNwUjL5UDM14SO1ATN
@@ -183,6 +474,7 @@ func NewAPI(appId, appSecret string) *API {
 	return &API{
 		AppId:     appId,
 		AppSecret: appSecret,
+		shared:    &apiState{},
 	}
 }
 
@@ -215,32 +507,42 @@ func (api *API) newRequest(method, path string, reqBody interface{}) (req *http.
 	if api.Debugger != nil && debug != nil {
 		debug()
 	}
-	req, err = http.NewRequest(method, Prefix+path, body)
+	req, err = http.NewRequestWithContext(api.context(), method, api.baseURL()+path, body)
 	if err != nil {
 		return
 	}
-	if path != getAccessToken {
-		err = api.getAccessToken()
+	token := api.accessToken
+	if token == "" && path != getAccessToken && path != getAppAccessToken {
+		token, err = api.getAccessToken()
 		if err != nil {
 			return
 		}
 	}
-	req.Header.Set("Authorization", "Bearer "+api.accessToken)
+	req.Header.Set("Authorization", "Bearer "+token)
 	return
 }
 
-func (api *API) do(req *http.Request, respData interface{}) (err error) {
-	var resp *http.Response
-	client := http.Client{
-		Timeout: api.Timeout,
+func (api *API) doOnce(req *http.Request, respData interface{}) (err error) {
+	api.wait(req)
+	if api.Signer != nil {
+		err = api.sign(req)
+		if err != nil {
+			return
+		}
 	}
-	resp, err = client.Do(req)
+	var resp *http.Response
+	start := api.clock().Now()
+	defer func() {
+		api.onRequest(req, start, resp, err)
+	}()
+	resp, err = api.client().Do(req)
 	if err != nil {
 		return
 	}
 	if api.Debugger != nil {
 		api.Debugger(req.URL.String(), "->", resp.Status)
 	}
+	api.checkDeprecation(req, resp)
 	defer resp.Body.Close()
 	var res []byte
 	res, err = ioutil.ReadAll(resp.Body)
@@ -248,23 +550,49 @@ func (api *API) do(req *http.Request, respData interface{}) (err error) {
 		return
 	}
 	if api.Debugger != nil {
-		api.Debugger("response body:", string(res))
+		api.Debugger("response body:", filterTokens(res))
 	}
 	var apiResp APIResponse
 	err = json.Unmarshal(res, &apiResp)
 	if err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			err = &Error{Status: resp.StatusCode, RetryAfter: retryAfter(resp)}
+		}
 		return
 	}
 	if apiResp.Msg != "ok" && apiResp.Msg != "success" {
-		err = fmt.Errorf("not ok or success returned: %s", apiResp.Msg)
+		e := api.newError(resp.StatusCode, apiResp.Code, apiResp.Msg)
+		e.RetryAfter = retryAfter(resp)
+		err = e
 		return
 	}
 	if respData != nil {
-		err = json.Unmarshal(res, respData)
+		if api.Strict {
+			err = UnmarshalStrict(res, respData)
+		} else {
+			err = json.Unmarshal(res, respData)
+		}
 	}
 	return
 }
 
+func (api *API) sign(req *http.Request) (err error) {
+	var body []byte
+	if req.GetBody != nil {
+		var rc io.ReadCloser
+		rc, err = req.GetBody()
+		if err != nil {
+			return
+		}
+		body, err = ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return
+		}
+	}
+	return api.Signer(req, body)
+}
+
 func (api *API) NewRequest(method, path string, reqBody interface{}, respData interface{}) (err error) {
 	var req *http.Request
 	req, err = api.newRequest(method, path, reqBody)
@@ -274,14 +602,87 @@ func (api *API) NewRequest(method, path string, reqBody interface{}, respData in
 	return api.do(req, respData)
 }
 
-func (api *API) getAccessToken() (err error) {
-	api.mutex.Lock()
-	defer api.mutex.Unlock()
-	if !api.expired() {
-		return nil
+// AccessToken returns the tenant access token and its expiry time, fetching
+// a new token if the cached one has expired, so other components (for
+// example raw HTTP calls) can reuse the token managed by the API.
+func (api *API) AccessToken() (token string, expiresAt time.Time, err error) {
+	token, err = api.getAccessToken()
+	if err != nil {
+		return
+	}
+	state := api.state()
+	state.mutex.Lock()
+	if state.accessToken == token {
+		expiresAt = state.accessTokenExpiredAt
+	}
+	state.mutex.Unlock()
+	return
+}
+
+// getAccessToken returns the cached access token or fetches a new one if it
+// has expired. Concurrent callers wait for a single in-flight refresh, the
+// lock is not held during the request.
+func (api *API) getAccessToken() (token string, err error) {
+	state := api.state()
+	state.mutex.Lock()
+	if !api.expired(state) {
+		token = state.accessToken
+		state.mutex.Unlock()
+		return
+	}
+	if f := state.refresh; f != nil {
+		state.mutex.Unlock()
+		<-f.done
+		return f.token, f.err
+	}
+	f := &tokenRefresh{done: make(chan struct{})}
+	state.refresh = f
+	state.mutex.Unlock()
+
+	// the token is shared by callers waiting for it, so it is not fetched
+	// with the context of this caller, which may be canceled before theirs
+	var expiresAt time.Time
+	f.token, expiresAt, f.err = api.detached().fetchAccessToken()
+
+	state.mutex.Lock()
+	if f.err == nil {
+		state.accessToken = f.token
+		state.accessTokenExpiredAt = expiresAt
+	}
+	state.refresh = nil
+	state.mutex.Unlock()
+	close(f.done)
+	return f.token, f.err
+}
+
+// fetchAccessToken returns the token in TokenStore (or Cache) if it is set
+// and the token has not expired, otherwise requests a new token.
+func (api *API) fetchAccessToken() (token string, expiresAt time.Time, err error) {
+	key := api.cacheKey("token", "tenant")
+	store := api.tokenStore()
+	if store != nil {
+		var ok bool
+		token, expiresAt, ok, err = store.Get(key)
+		if err == nil && ok {
+			// the stored time may have lost its monotonic clock reading,
+			// convert it to a duration from now
+			now := api.clock().Now()
+			if remaining := expiresAt.Sub(now); remaining > 0 {
+				return token, now.Add(remaining), nil
+			}
+		}
+		if err != nil && api.Debugger != nil {
+			api.Debugger("token store error:", err)
+		}
+		token, expiresAt, err = "", time.Time{}, nil
 	}
+	// expiry is counted from before the request is sent; times returned by
+	// SystemClock have monotonic clock readings, so changes of the wall
+	// clock don't affect the expiry
+	start := api.clock().Now()
 	var data AccessTokenResponse
-	err = api.NewRequest(
+	// fetching a token has no side effects, it is safe to retry
+	err = api.idempotent().NewRequest(
 		// method
 		"POST",
 
@@ -306,16 +707,71 @@ func (api *API) getAccessToken() (err error) {
 	if err != nil {
 		return
 	}
-	api.accessToken = data.Token
-	api.accessTokenExpiredAt = time.Now().Add(time.Duration(data.Expire-30) * time.Second)
+	token = data.Token
+	expiresAt = start.Add(api.tokenLifetime(data.Expire))
+	if store != nil {
+		if err := store.Set(key, token, expiresAt); err != nil && api.Debugger != nil {
+			api.Debugger("token store error:", err)
+		}
+	}
 	return
 }
 
-func (api *API) expired() bool {
-	return api.accessTokenExpiredAt.Before(time.Now())
+// tokenPattern matches access tokens and refresh tokens in response bodies.
+var tokenPattern = regexp.MustCompile(`("[a-z_]*(?:access|refresh)_token"\s*:\s*)"[^"]*"`)
+
+// filterTokens returns the response body with tokens replaced, so they are
+// not written to debug logs.
+func filterTokens(body []byte) string {
+	return tokenPattern.ReplaceAllString(string(body), `$1"[filtered]"`)
+}
+
+// tokenLifetime returns how long a token that expires in the given seconds
+// can be used.
+func (api *API) tokenLifetime(expire int) time.Duration {
+	margin := api.TokenExpiryMargin
+	if margin <= 0 {
+		margin = DefaultTokenExpiryMargin
+	}
+	lifetime := time.Duration(expire) * time.Second
+	if margin > lifetime/2 {
+		margin = lifetime / 2
+	}
+	return lifetime - margin
+}
+
+func (api *API) expired(state *apiState) bool {
+	return state.accessTokenExpiredAt.Before(api.clock().Now())
 }
 
+// ListAllChats returns all chats the bot is in, requesting all pages.
 func (api *API) ListAllChats() (groups Groups, err error) {
+	err = api.cachedRead("chats", &groups, func() (err error) {
+		groups, err = api.listAllChats()
+		return
+	})
+	return
+}
+
+func (api *API) listAllChats() (groups Groups, err error) {
+	var pageToken string
+	for {
+		var page Groups
+		page, pageToken, err = api.ListChatsPage(pageToken)
+		groups = append(groups, page...)
+		if err != nil || pageToken == "" {
+			return
+		}
+	}
+}
+
+// ListChatsPage returns a page of chats the bot is in. Pass empty pageToken
+// for the first page and the returned nextPageToken for the next page, which
+// is empty if this is the last page.
+func (api *API) ListChatsPage(pageToken string) (groups Groups, nextPageToken string, err error) {
+	if endpoint := api.endpoint(endpointListChats); endpoint.Version == V1 {
+		return api.listChatsPageV1(endpoint, pageToken)
+	}
 	var data GroupsResponse
 	err = api.NewRequest(
 		// method
@@ -326,17 +782,35 @@ func (api *API) ListAllChats() (groups Groups, err error) {
 
 		// request body
 		struct {
-			PageSize string `json:"page_size"`
-		}{"200"},
+			PageSize  string `json:"page_size"`
+			PageToken string `json:"page_token,omitempty"`
+		}{"200", pageToken},
 
 		// response
 		&data,
 	)
+	if err != nil {
+		return
+	}
 	groups = data.Data.Groups
+	if data.Data.HasMore {
+		nextPageToken = data.Data.PageToken
+	}
 	return
 }
 
 func (api *API) GetChatInfo(chatId string) (group Group, err error) {
+	err = api.cachedRead("chat:"+chatId, &group, func() (err error) {
+		group, err = api.getChatInfo(chatId)
+		return
+	})
+	return
+}
+
+func (api *API) getChatInfo(chatId string) (group Group, err error) {
+	if endpoint := api.endpoint(endpointGetChat); endpoint.Version == V1 {
+		return api.getChatInfoV1(endpoint, chatId)
+	}
 	var data GroupInfoResponse
 	err = api.NewRequest(
 		// method
@@ -360,14 +834,69 @@ func (api *API) GetChatInfo(chatId string) (group Group, err error) {
 	return
 }
 
+// ListChatsWithUser returns the chats that both the bot and the user with
+// the given open_id are members of.
+func (api *API) ListChatsWithUser(openId string) (groups Groups, err error) {
+	var all Groups
+	all, err = api.ListAllChats()
+	if err != nil {
+		return
+	}
+	for _, g := range all {
+		var group Group
+		group, err = api.GetChatInfo(g.ChatId)
+		if err != nil {
+			return
+		}
+		if group.HasMember(openId) {
+			groups = append(groups, group)
+		}
+	}
+	return
+}
+
+// GetChat returns the full chat information from the im/v1 API. Owner id is
+// returned as open_id.
+func (api *API) GetChat(chatId string) (chat ChatInfo, err error) {
+	var data ChatInfoResponse
+	err = api.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/im/v1/chats/"+chatId+"?user_id_type=open_id",
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	chat = data.Data
+	return
+}
+
+// GetUserInfo returns the user of the open id (ou_...), union id (on_...) or
+// user id. Email and mobile require their field permissions.
 func (api *API) GetUserInfo(userId string) (userInfo UserInfo, err error) {
+	err = api.cachedRead("user:"+userId, &userInfo, func() (err error) {
+		userInfo, err = api.getUserInfo(userId)
+		return
+	})
+	return
+}
+
+func (api *API) getUserInfo(userId string) (userInfo UserInfo, err error) {
 	var data UserInfoResponse
 	err = api.NewRequest(
 		// method
 		"GET",
 
 		// path
-		"/contact/v3/users/"+userId,
+		"/contact/v3/users/"+userId+"?user_id_type="+userIdType(userId)+"&department_id_type=open_department_id",
 
 		// request body
 		nil,
@@ -404,6 +933,36 @@ func (api *API) CreateChat(name, userOpenId string) (chatId string, err error) {
 		return
 	}
 	chatId = data.Data.ChatId
+	api.invalidate("chats")
+	return
+}
+
+// GetP2PChatId returns the chat_id of the one-on-one chat between the bot and
+// the user, creating the chat if it does not exist yet. Target can be open_id
+// or user_id.
+func (api *API) GetP2PChatId(target string) (chatId string, err error) {
+	a, _, _, d := parseTarget(target)
+	var data GroupResponse
+	err = api.NewRequest(
+		// method
+		"POST",
+
+		// path
+		"/chat/v4/p2p/id",
+
+		// request body
+		struct {
+			OpenId *string `json:"open_id,omitempty"`
+			UserId *string `json:"user_id,omitempty"`
+		}{a, d},
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	chatId = data.Data.ChatId
 	return
 }
 
@@ -456,6 +1015,9 @@ func (api *API) UpdateChat(chatId string, update map[string]interface{}) (err er
 		// response
 		nil,
 	)
+	if err == nil {
+		api.InvalidateChat(chatId)
+	}
 	return
 }
 
@@ -475,6 +1037,9 @@ func (api *API) DestroyChat(chatId string) (err error) {
 		// response
 		nil,
 	)
+	if err == nil {
+		api.InvalidateChat(chatId)
+	}
 	return
 }
 
@@ -499,6 +1064,7 @@ func (api *API) AddUsersToChat(chatId string, userIds []string) (err error) {
 	if err != nil {
 		return
 	}
+	api.InvalidateChat(chatId)
 	return
 }
 
@@ -519,116 +1085,107 @@ func (api *API) RemoveUsersFromChat(chatId string, userIds []string) (err error)
 		// response
 		nil,
 	)
+	if err == nil {
+		api.InvalidateChat(chatId)
+	}
 	return
 }
 
-func (api *API) SendCard(target string, card Card) (err error) {
-	a, b, c, d := parseTarget(target)
-	var data MessageResponse
-	err = api.NewRequest(
-		// method
-		"POST",
-
-		// path
-		"/message/v4/send/",
-
-		// request body
-		struct {
-			OpenId  *string     `json:"open_id,omitempty"`
-			ChatId  *string     `json:"chat_id,omitempty"`
-			Email   *string     `json:"email,omitempty"`
-			UserId  *string     `json:"user_id,omitempty"`
-			MsgType string      `json:"msg_type"`
-			Card    interface{} `json:"card"`
-		}{a, b, c, d, "interactive", card},
-
-		// response
-		&data,
-	)
-	return
+func (api *API) SendCard(target string, card Card, opts ...SendOption) (messageId string, err error) {
+	return api.send(target, "interactive", card, opts...)
 }
 
-func (api *API) SendMessage(target, content string) (err error) {
-	a, b, c, d := parseTarget(target)
-	var data MessageResponse
-	err = api.NewRequest(
-		// method
-		"POST",
-
-		// path
-		"/message/v4/send/",
-
-		// request body
-		struct {
-			OpenId  *string     `json:"open_id,omitempty"`
-			ChatId  *string     `json:"chat_id,omitempty"`
-			Email   *string     `json:"email,omitempty"`
-			UserId  *string     `json:"user_id,omitempty"`
-			MsgType string      `json:"msg_type"`
-			Content interface{} `json:"content"`
-		}{a, b, c, d, "text", struct {
-			Text string `json:"text"`
-		}{content}},
-
-		// response
-		&data,
-	)
-	return
+// SendMessage sends a text message and returns its id, which is empty if the
+// message is skipped by DedupWindow or held during QuietHours.
+func (api *API) SendMessage(target, content string, opts ...SendOption) (messageId string, err error) {
+	return api.send(target, "text", TextContent{content}, opts...)
 }
 
-func (api *API) SendImageMessage(target, imageKey string) (err error) {
-	a, b, c, d := parseTarget(target)
-	var data MessageResponse
-	err = api.NewRequest(
-		// method
-		"POST",
+func (api *API) SendImageMessage(target, imageKey string, opts ...SendOption) (messageId string, err error) {
+	return api.send(target, "image", ImageContent{imageKey}, opts...)
+}
 
-		// path
-		"/message/v4/send/",
+func (api *API) SendPost(target string, post Post, opts ...SendOption) (messageId string, err error) {
+	return api.send(target, "post", PostContent{post}, opts...)
+}
 
-		// request body
-		struct {
-			OpenId  *string     `json:"open_id,omitempty"`
-			ChatId  *string     `json:"chat_id,omitempty"`
-			Email   *string     `json:"email,omitempty"`
-			UserId  *string     `json:"user_id,omitempty"`
-			MsgType string      `json:"msg_type"`
-			Content interface{} `json:"content"`
-		}{a, b, c, d, "image", struct {
-			ImageKey string `json:"image_key"`
-		}{imageKey}},
+// SendFileMessage sends the file uploaded by UploadFile. File messages are
+// always sent with the im/v1 API.
+func (api *API) SendFileMessage(target, fileKey string, opts ...SendOption) (messageId string, err error) {
+	return api.send(target, "file", FileContent{fileKey}, opts...)
+}
 
-		// response
-		&data,
-	)
+// send is used by all Send* methods.
+func (api *API) send(target, msgType string, content interface{}, opts ...SendOption) (messageId string, err error) {
+	options := newSendOptions(opts)
+	if !options.critical && api.hold(target, msgType, content, options) {
+		if api.Debugger != nil {
+			api.Debugger("held", msgType, "message to", target, "during quiet hours")
+		}
+		return
+	}
+	content, err = api.filter(target, msgType, content)
+	if err != nil {
+		return
+	}
+	if options.fallback != "" && oversized(msgType, content) {
+		return api.sendFileFallback(target, msgType, content, options.fallback, opts)
+	}
+	key := api.dedupKey(target+"\x00"+options.rootId, msgType, content)
+	if api.isDuplicate(key) {
+		if api.Debugger != nil {
+			api.Debugger("skipped duplicate", msgType, "message to", target)
+		}
+		return
+	}
+	// chat of the sent message, known only from im/v1 responses
+	var chatId string
+	endpoint := api.endpoint(endpointSendMessage)
+	if options.version != "" {
+		endpoint = endpoints[endpointSendMessage][options.version]
+	}
+	if msgType == "file" {
+		// file messages are only available in im/v1
+		endpoint = endpoints[endpointSendMessage][V1]
+	}
+	if endpoint.Version == V1 {
+		var threadId string
+		messageId, threadId, chatId, err = api.sendV1(endpoint, target, options.rootId, options.inThread, msgType, content)
+		if options.threadId != nil {
+			*options.threadId = threadId
+		}
+	} else {
+		messageId, err = api.sendV4(endpoint, target, options.rootId, msgType, content)
+	}
+	if err == nil {
+		api.markSent(key)
+	}
+	if api.Transcript != nil {
+		chat := target
+		if chatId != "" {
+			chat = chatId
+		}
+		api.Transcript.Append(newOutboundTranscriptEntry(api.clock().Now(), chat, msgType, messageId, content, err))
+	}
+	if api.OnMessageSent != nil {
+		api.OnMessageSent(target, msgType, messageId, err)
+	}
 	return
 }
 
-func (api *API) SendPost(target string, post Post) (err error) {
-	a, b, c, d := parseTarget(target)
-	var data MessageResponse
-	err = api.NewRequest(
-		// method
-		"POST",
-
-		// path
-		"/message/v4/send/",
-
-		// request body
-		struct {
-			OpenId  *string     `json:"open_id,omitempty"`
-			ChatId  *string     `json:"chat_id,omitempty"`
-			Email   *string     `json:"email,omitempty"`
-			UserId  *string     `json:"user_id,omitempty"`
-			MsgType string      `json:"msg_type"`
-			Content interface{} `json:"content"`
-		}{a, b, c, d, "post", struct {
-			Post Post `json:"post"`
-		}{post}},
-
-		// response
-		&data,
-	)
+// filter applies SendFilters to the content, OnMessageSent is called if a
+// filter blocks the message.
+func (api *API) filter(target, msgType string, content interface{}) (filtered interface{}, err error) {
+	filtered = content
+	for _, f := range api.SendFilters {
+		filtered, err = f(target, msgType, filtered)
+		if err != nil {
+			if api.OnMessageSent != nil {
+				api.OnMessageSent(target, msgType, "", err)
+			}
+			return
+		}
+	}
 	return
 }
 
@@ -641,10 +1198,37 @@ func (api *API) UploadMessageImage(file io.Reader) (key string, err error) {
 }
 
 func (api *API) uploadImage(imageType string, file io.Reader) (key string, err error) {
+	name := "image"
+	if named, ok := file.(NamedReader); ok {
+		name = named.Name
+	}
+	file, err = api.scan(name, file)
+	if err != nil {
+		return
+	}
+	var mimeType string
+	file, mimeType, err = api.sniffImage(file)
+	if err != nil {
+		return
+	}
+	var image []byte
+	image, err = ioutil.ReadAll(file)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(image)
+	cacheKey := api.cacheKey("image", imageType+":"+hex.EncodeToString(sum[:]))
+	if value, ok, err := api.cache().Get(cacheKey); err == nil && ok {
+		return string(value), nil
+	}
+	file = bytes.NewReader(image)
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="image"; filename="`+quoteEscaper.Replace(imageFileName(name, mimeType))+`"`)
+	header.Set("Content-Type", mimeType)
 	var part io.Writer
-	part, err = writer.CreateFormFile("image", "image")
+	part, err = writer.CreatePart(header)
 	if err != nil {
 		return
 	}
@@ -674,12 +1258,25 @@ func (api *API) uploadImage(imageType string, file io.Reader) (key string, err e
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	var data UploadResponse
 	err = api.do(req, &data)
-	if err == nil {
-		key = data.Data.ImageKey
+	if err != nil {
+		return
+	}
+	key = data.Data.ImageKey
+	if err := api.cache().Set(cacheKey, []byte(key), 0); err != nil && api.Debugger != nil {
+		api.Debugger("image cache error:", err)
 	}
 	return
 }
 
+func (group Group) HasMember(openId string) bool {
+	for _, member := range group.Members {
+		if member.OpenId == openId {
+			return true
+		}
+	}
+	return false
+}
+
 func (groups *Groups) String() string {
 	if len(*groups) == 0 {
 		return "no groups"
@@ -704,8 +1301,14 @@ func parseTarget(target string) (*string, *string, *string, *string) {
 	if strings.HasPrefix(target, "oc_") {
 		return nil, &target, nil, nil
 	}
-	if strings.HasPrefix(target, "@") {
+	if isEmail(target) {
 		return nil, nil, &target, nil
 	}
 	return nil, nil, nil, &target
 }
+
+// isEmail reports whether the target is an email, used by both message APIs
+// to detect the type of the target.
+func isEmail(target string) bool {
+	return strings.Contains(target, "@")
+}
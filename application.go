@@ -0,0 +1,189 @@
+package larkslim
+
+import (
+	"strings"
+)
+
+type (
+	// MissingScopesError is returned by CheckScopes.
+	MissingScopesError struct {
+		Scopes []string
+	}
+
+	ApplicationResponse struct {
+		APIResponse
+		Data struct {
+			App Application `json:"app"`
+		} `json:"data"`
+	}
+
+	// https://open.feishu.cn/document/server-docs/application-v6/application/get
+	Application struct {
+		AppId            string     `json:"app_id"`
+		CreatorId        string     `json:"creator_id"`
+		Status           int        `json:"status"`
+		SceneType        int        `json:"scene_type"`
+		RedirectUrls     []string   `json:"redirect_urls"`
+		OnlineVersionId  string     `json:"online_version_id"`
+		UnauditVersionId string     `json:"unaudit_version_id"`
+		AppName          string     `json:"app_name"`
+		AvatarUrl        string     `json:"avatar_url"`
+		Description      string     `json:"description"`
+		Scopes           []AppScope `json:"scopes"`
+		BackHomeUrl      string     `json:"back_home_url"`
+		PrimaryLanguage  string     `json:"primary_language"`
+	}
+
+	AppScope struct {
+		Scope       string `json:"scope"`
+		Description string `json:"description"`
+		Level       int    `json:"level"`
+	}
+
+	AppVersionResponse struct {
+		APIResponse
+		Data struct {
+			AppVersion AppVersion `json:"app_version"`
+		} `json:"data"`
+	}
+
+	// https://open.feishu.cn/document/server-docs/application-v6/application/get-2
+	AppVersion struct {
+		AppId     string     `json:"app_id"`
+		AppName   string     `json:"app_name"`
+		Version   string     `json:"version"`
+		VersionId string     `json:"version_id"`
+		Status    int        `json:"status"`
+		Scopes    []AppScope `json:"scopes"`
+		Events    []AppEvent `json:"events"`
+		Ability   struct {
+			Bot struct {
+				CardRequestUrl string `json:"card_request_url"`
+			} `json:"bot"`
+		} `json:"ability"`
+	}
+
+	AppEvent struct {
+		EventType        string `json:"event_type"`
+		EventName        string `json:"event_name"`
+		EventDescription string `json:"event_description"`
+	}
+)
+
+// GetApplication returns information of the application. Empty appId means
+// the current application. Requires the application:application:self_manage
+// scope.
+func (api *API) GetApplication(appId string) (app Application, err error) {
+	if appId == "" {
+		appId = api.AppId
+	}
+	var data ApplicationResponse
+	err = api.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/application/v6/applications/"+appId+"?lang=zh_cn",
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	app = data.Data.App
+	return
+}
+
+// GetAppVersion returns the scopes, subscribed events and the card request
+// URL of a version of the application.
+func (api *API) GetAppVersion(appId, versionId string) (version AppVersion, err error) {
+	if appId == "" {
+		appId = api.AppId
+	}
+	var data AppVersionResponse
+	err = api.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/application/v6/applications/"+appId+"/app_versions/"+versionId+"?lang=zh_cn",
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	version = data.Data.AppVersion
+	return
+}
+
+// GetOnlineAppVersion returns the currently published version of the current
+// application.
+func (api *API) GetOnlineAppVersion() (version AppVersion, err error) {
+	var app Application
+	app, err = api.GetApplication("")
+	if err != nil {
+		return
+	}
+	return api.GetAppVersion(app.AppId, app.OnlineVersionId)
+}
+
+// CheckEventSubscriptions returns event types in required that the published
+// version of the application has not subscribed to. The open platform does
+// not allow changing subscriptions or the event callback URL through the API,
+// these must be configured in the developer console.
+func (api *API) CheckEventSubscriptions(required ...string) (missing []string, err error) {
+	var version AppVersion
+	version, err = api.GetOnlineAppVersion()
+	if err != nil {
+		return
+	}
+	subscribed := map[string]bool{}
+	for _, event := range version.Events {
+		subscribed[event.EventType] = true
+	}
+	for _, eventType := range required {
+		if !subscribed[eventType] {
+			missing = append(missing, eventType)
+		}
+	}
+	return
+}
+
+// CheckScopes returns MissingScopesError if the published version of the
+// application has not been granted any of the required scopes (for example
+// "im:message"), so that missing permissions are reported at startup rather
+// than as errors of later API calls.
+func (api *API) CheckScopes(required ...string) (err error) {
+	var version AppVersion
+	version, err = api.GetOnlineAppVersion()
+	if err != nil {
+		return
+	}
+	granted := map[string]bool{}
+	for _, scope := range version.Scopes {
+		granted[scope.Scope] = true
+	}
+	var missing []string
+	for _, scope := range required {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		err = &MissingScopesError{missing}
+	}
+	return
+}
+
+func (e *MissingScopesError) Error() string {
+	return "missing scopes: " + strings.Join(e.Scopes, ", ")
+}
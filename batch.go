@@ -0,0 +1,66 @@
+package larkslim
+
+type (
+	// BatchResult is the result of SendBatchMessage.
+	BatchResult struct {
+		// id of the batch, which can be used to recall the messages
+		MessageId string `json:"message_id"`
+
+		// ids the message was not sent to, for example users who have
+		// left or are out of the availability of the app
+		InvalidDepartmentIds []string `json:"invalid_department_ids"`
+		InvalidOpenIds       []string `json:"invalid_open_ids"`
+		InvalidUserIds       []string `json:"invalid_user_ids"`
+	}
+
+	BatchMessageResponse struct {
+		APIResponse
+		Data BatchResult `json:"data"`
+	}
+)
+
+// SendBatchMessage sends a message to up to 200 users and all users of up
+// to 200 departments in one request. Content is TextContent, ImageContent,
+// PostContent or Card (with msgType "interactive"). Messages are sent
+// asynchronously, so ids in the result's invalid lists are the only users
+// known not to receive the message. SendFilters, Transcript and
+// OnMessageSent see the batch as one message with an empty target.
+func (api *API) SendBatchMessage(openIds, departmentIds []string, msgType string, content interface{}) (result BatchResult, err error) {
+	content, err = api.filter("", msgType, content)
+	if err != nil {
+		return
+	}
+	original := content
+	var card interface{}
+	if msgType == "interactive" {
+		card, content = content, nil
+	}
+	var data BatchMessageResponse
+	err = api.NewRequest(
+		// method
+		"POST",
+
+		// path
+		"/message/v4/batch_send/",
+
+		// request body
+		struct {
+			OpenIds       []string    `json:"open_ids,omitempty"`
+			DepartmentIds []string    `json:"department_ids,omitempty"`
+			MsgType       string      `json:"msg_type"`
+			Content       interface{} `json:"content,omitempty"`
+			Card          interface{} `json:"card,omitempty"`
+		}{openIds, departmentIds, msgType, content, card},
+
+		// response
+		&data,
+	)
+	result = data.Data
+	if api.Transcript != nil {
+		api.Transcript.Append(newOutboundTranscriptEntry(api.clock().Now(), "", msgType, result.MessageId, original, err))
+	}
+	if api.OnMessageSent != nil {
+		api.OnMessageSent("", msgType, result.MessageId, err)
+	}
+	return
+}
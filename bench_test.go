@@ -0,0 +1,111 @@
+package larkslim_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+// BenchmarkTransport compares http.DefaultTransport, which keeps only 2 idle
+// connections per host, with larkslim.DefaultTransport when sending
+// messages concurrently.
+func BenchmarkTransport(b *testing.B) {
+	server := larktest.NewServer()
+	defer server.Close()
+	for _, test := range []struct {
+		name      string
+		transport http.RoundTripper
+	}{
+		{"http.DefaultTransport", http.DefaultTransport.(*http.Transport).Clone()},
+		{"larkslim.DefaultTransport", larkslim.NewTransport(32, 90*time.Second)},
+	} {
+		b.Run(test.name, func(b *testing.B) {
+			api := server.API()
+			api.HTTPClient = &http.Client{Transport: test.transport}
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := api.SendMessage("oc_test", "hello"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSendMessageHTTP2(b *testing.B) {
+	server := larktest.NewTLSServer()
+	defer server.Close()
+	api := server.API()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := api.SendMessage("oc_test", "hello"); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkSendMessage(b *testing.B) {
+	server := larktest.NewServer()
+	defer server.Close()
+	api := server.API()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := api.SendMessage("oc_test", "hello"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSendCard(b *testing.B) {
+	server := larktest.NewServer()
+	defer server.Close()
+	api := server.API()
+	card := larkslim.Card{
+		Header: larkslim.CardHeader{
+			Title: larkslim.CardHeaderTitle{Tag: "plain_text", Content: "title"},
+		},
+		Elements: []interface{}{
+			map[string]interface{}{"tag": "markdown", "content": "**hello**"},
+			map[string]interface{}{"tag": "hr"},
+		},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := api.SendCard("oc_test", card); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSendPost(b *testing.B) {
+	server := larktest.NewServer()
+	defer server.Close()
+	api := server.API()
+	post := larkslim.Post{
+		"zh_cn": larkslim.PostOfLocale{
+			Title: "title",
+			Content: larkslim.PostLines{
+				{{Tag: "text", Text: "hello "}, {Tag: "a", Text: "link", Href: "https://example.com"}},
+			},
+		},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := api.SendPost("oc_test", post); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
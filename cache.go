@@ -0,0 +1,111 @@
+package larkslim
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Cache stores values shared by copies of the API and, if the cache is
+	// shared (for example Redis), by multiple processes. It is used for the
+	// access token, keys of uploaded images and the dedup window.
+	// Implementations must be safe for concurrent use.
+	Cache interface {
+		// Get returns the value of the key, ok is false if the key does
+		// not exist or has expired.
+		Get(key string) (value []byte, ok bool, err error)
+
+		// Set sets the value of the key, which expires after ttl, or
+		// never if ttl is zero.
+		Set(key string, value []byte, ttl time.Duration) error
+
+		Delete(key string) error
+	}
+
+	// MemoryCache is a Cache in memory.
+	MemoryCache struct {
+		// defaults to SystemClock
+		Clock Clock
+
+		mutex sync.Mutex
+		items map[string]memoryCacheItem
+	}
+
+	memoryCacheItem struct {
+		value     []byte
+		expiresAt time.Time
+	}
+)
+
+func (c *MemoryCache) Get(key string) (value []byte, ok bool, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	item, ok := c.items[key]
+	if !ok {
+		return
+	}
+	if !item.expiresAt.IsZero() && !c.clock().Now().Before(item.expiresAt) {
+		delete(c.items, key)
+		ok = false
+		return
+	}
+	value = item.value
+	return
+}
+
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	now := c.clock().Now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.items == nil {
+		c.items = map[string]memoryCacheItem{}
+	}
+	// remove expired items from time to time
+	if len(c.items) >= 1024 && len(c.items)&(len(c.items)-1) == 0 {
+		for k, item := range c.items {
+			if !item.expiresAt.IsZero() && !now.Before(item.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+	}
+	item := memoryCacheItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = now.Add(ttl)
+	}
+	c.items[key] = item
+	return nil
+}
+
+func (c *MemoryCache) Delete(key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+func (c *MemoryCache) clock() Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+	return SystemClock
+}
+
+// cache returns Cache, or a MemoryCache shared by copies of the API if it
+// is not set.
+func (api *API) cache() Cache {
+	if api.Cache != nil {
+		return api.Cache
+	}
+	state := api.state()
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	if state.cache == nil {
+		state.cache = &MemoryCache{Clock: api.clock()}
+	}
+	return state.cache
+}
+
+// cacheKey returns the key in the cache of the kind of values of the app.
+func (api *API) cacheKey(kind, key string) string {
+	return "larkslim:" + api.AppId + ":" + kind + ":" + key
+}
@@ -0,0 +1,273 @@
+package larkslim
+
+import (
+	"encoding/json"
+	"html"
+	"regexp"
+	"strings"
+)
+
+type (
+	// cardLine is a rendered line of a card, with an optional link.
+	cardLine struct {
+		Text string
+		Href string
+	}
+)
+
+// Text renders the card as plain text, as a fallback for targets that can't
+// display interactive cards or as a readable log representation.
+func (card Card) Text() string {
+	var b strings.Builder
+	if title := card.Header.Title.Content; title != "" {
+		b.WriteString(title)
+		b.WriteString("\n")
+	}
+	for _, line := range card.lines() {
+		b.WriteString(line.Text)
+		if line.Href != "" && line.Href != line.Text {
+			b.WriteString(" (" + line.Href + ")")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Post renders the card as a post message of the locale (for example
+// "zh_cn"). Buttons with URLs are rendered as links.
+func (card Card) Post(locale string) Post {
+	var lines PostLines
+	for _, line := range card.lines() {
+		if line.Href != "" {
+			lines = append(lines, PostLine{{Tag: "a", Text: line.Text, Href: line.Href}})
+		} else {
+			lines = append(lines, PostLine{{Tag: "text", Text: line.Text}})
+		}
+	}
+	return Post{
+		locale: PostOfLocale{
+			Title:   card.Header.Title.Content,
+			Content: lines,
+		},
+	}
+}
+
+func (card Card) lines() (lines []cardLine) {
+	for _, element := range card.Elements {
+		lines = append(lines, renderCardElement(toMap(element))...)
+	}
+	return
+}
+
+func renderCardElement(element map[string]interface{}) (lines []cardLine) {
+	addText := func(text string) {
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, cardLine{Text: line})
+		}
+	}
+	switch element["tag"] {
+	case "div":
+		if text := textContent(element["text"]); text != "" {
+			addText(text)
+		}
+		fields, _ := element["fields"].([]interface{})
+		for _, field := range fields {
+			if text := textContent(toMap(field)["text"]); text != "" {
+				addText(text)
+			}
+		}
+		if extra, ok := element["extra"]; ok {
+			lines = append(lines, renderCardElement(toMap(extra))...)
+		}
+	case "markdown":
+		if text, ok := element["content"].(string); ok {
+			addText(text)
+		}
+	case "plain_text", "lark_md":
+		addText(textContent(element))
+	case "hr":
+		lines = append(lines, cardLine{Text: "---"})
+	case "img":
+		alt := textContent(element["alt"])
+		if alt == "" {
+			alt = "image"
+		}
+		lines = append(lines, cardLine{Text: "[" + alt + "]"})
+	case "button":
+		text := textContent(element["text"])
+		url, _ := element["url"].(string)
+		if url == "" {
+			text = "[" + text + "]"
+		}
+		lines = append(lines, cardLine{Text: text, Href: url})
+	case "note", "action", "column_set", "column", "form":
+		for _, key := range []string{"elements", "actions", "columns"} {
+			children, _ := element[key].([]interface{})
+			for _, child := range children {
+				lines = append(lines, renderCardElement(toMap(child))...)
+			}
+		}
+	}
+	return
+}
+
+// textContent returns content of a text object like {"tag": "plain_text",
+// "content": "..."}.
+func textContent(v interface{}) string {
+	content, _ := toMap(v)["content"].(string)
+	return content
+}
+
+// toMap converts a card element of any type to a map.
+func toMap(v interface{}) map[string]interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+	var m map[string]interface{}
+	if data, err := json.Marshal(v); err == nil {
+		json.Unmarshal(data, &m)
+	}
+	return m
+}
+
+var (
+	cardMarkdownBold = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	cardMarkdownLink = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+
+	// https://open.feishu.cn/document/ukTMukTMukTM/ukTNwUjL5UDM14SO1ATN
+	cardTemplateColors = map[string]string{
+		"blue":      "#3370ff",
+		"wathet":    "#54b4ff",
+		"turquoise": "#2dbeab",
+		"green":     "#34c724",
+		"yellow":    "#ffc60a",
+		"orange":    "#ff8800",
+		"red":       "#f54a45",
+		"carmine":   "#e8338a",
+		"violet":    "#a35ee8",
+		"purple":    "#7f3bf5",
+		"indigo":    "#4954e6",
+		"grey":      "#8f959e",
+	}
+)
+
+const cardHTMLStyle = `body{font-family:sans-serif;background:#f5f6f7}
+.card{max-width:560px;margin:20px auto;background:#fff;border-radius:8px;overflow:hidden;box-shadow:0 1px 4px rgba(0,0,0,.15)}
+.header{padding:12px 16px;color:#fff;font-weight:bold;background:#1f2329}
+.body{padding:12px 16px;line-height:1.5}
+.fields{display:flex;flex-wrap:wrap}.field{width:50%}
+.note{color:#8f959e;font-size:12px}
+.img{background:#eee;color:#888;text-align:center;padding:40px 0;margin:4px 0}
+.actions{margin:8px 0}.button{display:inline-block;border:1px solid #d0d3d6;border-radius:4px;padding:4px 12px;margin-right:8px;color:#1f2329;text-decoration:none}
+.columns{display:flex}.column{flex:1}
+hr{border:none;border-top:1px solid #dee0e3}`
+
+// HTML renders the card into a standalone HTML page that approximates how
+// Lark clients display it, so layouts can be checked during development
+// without sending test messages. Only common elements are supported.
+func (card Card) HTML() string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><style>")
+	b.WriteString(cardHTMLStyle)
+	b.WriteString("</style></head><body><div class=\"card\">")
+	if title := card.Header.Title.Content; title != "" {
+		style := ""
+		if color, ok := cardTemplateColors[card.Header.Template]; ok {
+			style = " style=\"background:" + color + "\""
+		}
+		b.WriteString("<div class=\"header\"" + style + ">" + html.EscapeString(title) + "</div>")
+	}
+	b.WriteString("<div class=\"body\">")
+	for _, element := range card.Elements {
+		renderCardElementHTML(&b, toMap(element))
+	}
+	b.WriteString("</div></div></body></html>\n")
+	return b.String()
+}
+
+func renderCardElementHTML(b *strings.Builder, element map[string]interface{}) {
+	children := func(key string) {
+		items, _ := element[key].([]interface{})
+		for _, item := range items {
+			renderCardElementHTML(b, toMap(item))
+		}
+	}
+	switch element["tag"] {
+	case "div":
+		b.WriteString("<div>")
+		if text, ok := element["text"]; ok {
+			b.WriteString(cardTextHTML(toMap(text)))
+		}
+		if fields, ok := element["fields"].([]interface{}); ok {
+			b.WriteString("<div class=\"fields\">")
+			for _, field := range fields {
+				f := toMap(field)
+				class := "field"
+				if short, _ := f["is_short"].(bool); !short {
+					class += "\" style=\"width:100%"
+				}
+				b.WriteString("<div class=\"" + class + "\">" + cardTextHTML(toMap(f["text"])) + "</div>")
+			}
+			b.WriteString("</div>")
+		}
+		if extra, ok := element["extra"]; ok {
+			renderCardElementHTML(b, toMap(extra))
+		}
+		b.WriteString("</div>")
+	case "markdown", "lark_md":
+		content, _ := element["content"].(string)
+		b.WriteString("<div>" + cardMarkdownHTML(content) + "</div>")
+	case "plain_text":
+		b.WriteString("<div>" + cardTextHTML(element) + "</div>")
+	case "hr":
+		b.WriteString("<hr>")
+	case "img":
+		alt := textContent(element["alt"])
+		if alt == "" {
+			alt = "image"
+		}
+		b.WriteString("<div class=\"img\">" + html.EscapeString(alt) + "</div>")
+	case "note":
+		b.WriteString("<div class=\"note\">")
+		children("elements")
+		b.WriteString("</div>")
+	case "action":
+		b.WriteString("<div class=\"actions\">")
+		children("actions")
+		b.WriteString("</div>")
+	case "button":
+		url, _ := element["url"].(string)
+		if url == "" {
+			url = "#"
+		}
+		b.WriteString("<a class=\"button\" href=\"" + html.EscapeString(url) + "\">" +
+			cardTextHTML(toMap(element["text"])) + "</a>")
+	case "column_set":
+		b.WriteString("<div class=\"columns\">")
+		children("columns")
+		b.WriteString("</div>")
+	case "column", "form":
+		b.WriteString("<div class=\"" + element["tag"].(string) + "\">")
+		children("elements")
+		b.WriteString("</div>")
+	default:
+		tag, _ := element["tag"].(string)
+		b.WriteString("<div class=\"note\">[" + html.EscapeString(tag) + "]</div>")
+	}
+}
+
+func cardTextHTML(text map[string]interface{}) string {
+	content, _ := text["content"].(string)
+	if text["tag"] == "lark_md" {
+		return cardMarkdownHTML(content)
+	}
+	return strings.Replace(html.EscapeString(content), "\n", "<br>", -1)
+}
+
+// cardMarkdownHTML converts bold text, links and line breaks of markdown.
+func cardMarkdownHTML(content string) string {
+	s := html.EscapeString(content)
+	s = cardMarkdownBold.ReplaceAllString(s, "<b>$1</b>")
+	s = cardMarkdownLink.ReplaceAllString(s, "<a href=\"$2\">$1</a>")
+	return strings.Replace(s, "\n", "<br>", -1)
+}
@@ -0,0 +1,358 @@
+package larkslim
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+type (
+	// CardElement is a typed element of Card.Elements. Elements of other
+	// types, like maps, can still be added to Card.Elements directly.
+	CardElement interface {
+		cardElement()
+	}
+
+	// CardActionElement is an interactive element of CardAction.
+	CardActionElement interface {
+		cardActionElement()
+	}
+
+	// CardText is a text object with tag "plain_text" or "lark_md".
+	CardText struct {
+		Tag     string `json:"tag"`
+		Content string `json:"content"`
+		// maximum lines to display, 0 for no limit
+		Lines int `json:"lines,omitempty"`
+	}
+
+	// CardField is a field of CardDiv.
+	CardField struct {
+		// display two short fields in a row
+		IsShort bool     `json:"is_short"`
+		Text    CardText `json:"text"`
+	}
+
+	// CardDiv is a "div" element of text and fields.
+	CardDiv struct {
+		Text   *CardText   `json:"text,omitempty"`
+		Fields []CardField `json:"fields,omitempty"`
+		// CardButton, CardImage, CardSelectMenu or CardDatePicker on the
+		// right of the text
+		Extra interface{} `json:"extra,omitempty"`
+	}
+
+	// CardMarkdown is a "markdown" element.
+	CardMarkdown struct {
+		Content string `json:"content"`
+		// "left", "center" or "right"
+		TextAlign string `json:"text_align,omitempty"`
+	}
+
+	// CardImage is an "img" element of an image uploaded with
+	// UploadMessageImage.
+	CardImage struct {
+		ImgKey string    `json:"img_key"`
+		Alt    CardText  `json:"alt"`
+		Title  *CardText `json:"title,omitempty"`
+		// "crop_center", "fit_horizontal" or "stretch"
+		Mode string `json:"mode,omitempty"`
+	}
+
+	// CardAction is an "action" element of buttons, select menus and date
+	// pickers.
+	CardAction struct {
+		Actions []CardActionElement `json:"actions"`
+		// "bisected", "trisection" or "flow"
+		Layout string `json:"layout,omitempty"`
+	}
+
+	// CardButton is a "button" that opens URL or sends Value to the card
+	// callback.
+	CardButton struct {
+		Text CardText `json:"text"`
+		URL  string   `json:"url,omitempty"`
+		// "default", "primary" or "danger"
+		Type    string                 `json:"type,omitempty"`
+		Value   map[string]interface{} `json:"value,omitempty"`
+		Confirm *CardConfirm           `json:"confirm,omitempty"`
+	}
+
+	// CardConfirm is the confirmation dialog of an interactive element.
+	CardConfirm struct {
+		Title CardText `json:"title"`
+		Text  CardText `json:"text"`
+	}
+
+	// CardSelectMenu is a select menu of Options, or of chat members if
+	// Tag is "select_person".
+	CardSelectMenu struct {
+		// defaults to "select_static"
+		Tag           string                 `json:"tag"`
+		Placeholder   *CardText              `json:"placeholder,omitempty"`
+		InitialOption string                 `json:"initial_option,omitempty"`
+		Options       []CardOption           `json:"options,omitempty"`
+		Value         map[string]interface{} `json:"value,omitempty"`
+		Confirm       *CardConfirm           `json:"confirm,omitempty"`
+	}
+
+	// CardOption is an option of CardSelectMenu.
+	CardOption struct {
+		Text  CardText `json:"text"`
+		Value string   `json:"value"`
+	}
+
+	// CardDatePicker is a date, time or date and time picker.
+	CardDatePicker struct {
+		// defaults to "date_picker", or "picker_time" or
+		// "picker_datetime"
+		Tag string `json:"tag"`
+		// "2006-01-02" for date_picker
+		InitialDate string `json:"initial_date,omitempty"`
+		// "15:04" for picker_time
+		InitialTime string `json:"initial_time,omitempty"`
+		// "2006-01-02 15:04" for picker_datetime
+		InitialDatetime string                 `json:"initial_datetime,omitempty"`
+		Placeholder     *CardText              `json:"placeholder,omitempty"`
+		Value           map[string]interface{} `json:"value,omitempty"`
+		Confirm         *CardConfirm           `json:"confirm,omitempty"`
+	}
+
+	// CardNote is a "note" element of small texts (CardText) and images
+	// (CardImage).
+	CardNote struct {
+		Elements []interface{} `json:"elements"`
+	}
+
+	// CardHr is a horizontal rule.
+	CardHr struct{}
+
+	// CardColumnSet is a "column_set" element of columns side by side.
+	CardColumnSet struct {
+		// "none", "stretch", "flow", "bisect" or "trisect"
+		FlexMode string `json:"flex_mode,omitempty"`
+		// "default" or "grey"
+		BackgroundStyle string       `json:"background_style,omitempty"`
+		Columns         []CardColumn `json:"columns"`
+	}
+
+	// CardColumn is a column of CardColumnSet.
+	CardColumn struct {
+		// "auto" or "weighted"
+		Width  string `json:"width,omitempty"`
+		Weight int    `json:"weight,omitempty"`
+		// "top", "center" or "bottom"
+		VerticalAlign string        `json:"vertical_align,omitempty"`
+		Elements      []CardElement `json:"elements"`
+	}
+
+	// CardBuilder composes a Card with typed elements:
+	//
+	//	card := larkslim.NewCardBuilder("Deploy").
+	//		Template("blue").
+	//		Markdown("**web** is ready to deploy").
+	//		Actions(larkslim.CardButton{
+	//			Text:  larkslim.PlainText("Deploy"),
+	//			Type:  "primary",
+	//			Value: map[string]interface{}{"deploy": "web"},
+	//		}).
+	//		Build()
+	CardBuilder struct {
+		card Card
+	}
+)
+
+func (CardDiv) cardElement()       {}
+func (CardMarkdown) cardElement()  {}
+func (CardImage) cardElement()     {}
+func (CardAction) cardElement()    {}
+func (CardNote) cardElement()      {}
+func (CardHr) cardElement()        {}
+func (CardColumnSet) cardElement() {}
+
+func (CardButton) cardActionElement()     {}
+func (CardSelectMenu) cardActionElement() {}
+func (CardDatePicker) cardActionElement() {}
+
+// PlainText returns a "plain_text" text object.
+func PlainText(content string) CardText {
+	return CardText{Tag: "plain_text", Content: content}
+}
+
+// LarkMd returns a "lark_md" text object, which supports bold text, links
+// and mentions.
+func LarkMd(content string) CardText {
+	return CardText{Tag: "lark_md", Content: content}
+}
+
+func (e CardDiv) MarshalJSON() ([]byte, error) {
+	type div CardDiv
+	return marshalCardElement("div", div(e))
+}
+
+func (e CardMarkdown) MarshalJSON() ([]byte, error) {
+	type markdown CardMarkdown
+	return marshalCardElement("markdown", markdown(e))
+}
+
+func (e CardImage) MarshalJSON() ([]byte, error) {
+	type img CardImage
+	return marshalCardElement("img", img(e))
+}
+
+func (e CardAction) MarshalJSON() ([]byte, error) {
+	type action CardAction
+	return marshalCardElement("action", action(e))
+}
+
+func (e CardButton) MarshalJSON() ([]byte, error) {
+	type button CardButton
+	return marshalCardElement("button", button(e))
+}
+
+func (e CardSelectMenu) MarshalJSON() ([]byte, error) {
+	type selectMenu CardSelectMenu
+	if e.Tag == "" {
+		e.Tag = "select_static"
+	}
+	return json.Marshal(selectMenu(e))
+}
+
+func (e CardDatePicker) MarshalJSON() ([]byte, error) {
+	type datePicker CardDatePicker
+	if e.Tag == "" {
+		e.Tag = "date_picker"
+	}
+	return json.Marshal(datePicker(e))
+}
+
+func (e CardNote) MarshalJSON() ([]byte, error) {
+	type note CardNote
+	return marshalCardElement("note", note(e))
+}
+
+func (e CardHr) MarshalJSON() ([]byte, error) {
+	return marshalCardElement("hr", struct{}{})
+}
+
+func (e CardColumnSet) MarshalJSON() ([]byte, error) {
+	type columnSet CardColumnSet
+	return marshalCardElement("column_set", columnSet(e))
+}
+
+func (e CardColumn) MarshalJSON() ([]byte, error) {
+	type column CardColumn
+	return marshalCardElement("column", column(e))
+}
+
+// marshalCardElement marshals v, which must be marshaled as an object, with
+// "tag" as the first key.
+func marshalCardElement(tag string, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteString(`{"tag":`)
+	tagJSON, _ := json.Marshal(tag)
+	b.Write(tagJSON)
+	if len(data) > 2 {
+		b.WriteByte(',')
+	}
+	b.Write(data[1:])
+	return b.Bytes(), nil
+}
+
+// NewCardBuilder returns a CardBuilder of a wide screen card with the plain
+// text title.
+func NewCardBuilder(title string) *CardBuilder {
+	return &CardBuilder{
+		card: Card{
+			Config: CardConfig{
+				WideScreenMode: true,
+			},
+			Header: CardHeader{
+				Title: CardHeaderTitle{
+					Tag:     "plain_text",
+					Content: title,
+				},
+			},
+		},
+	}
+}
+
+// Template sets the color of the header, for example "blue" or "red".
+func (b *CardBuilder) Template(template string) *CardBuilder {
+	b.card.Header.Template = template
+	return b
+}
+
+// EnableForward allows the card to be forwarded.
+func (b *CardBuilder) EnableForward() *CardBuilder {
+	b.card.Config.EnableForward = true
+	return b
+}
+
+// UpdateMulti shares updates of the card with all members of the chat, see
+// CardConfig.
+func (b *CardBuilder) UpdateMulti() *CardBuilder {
+	b.card.Config.UpdateMulti = true
+	return b
+}
+
+// Add adds elements to the card.
+func (b *CardBuilder) Add(elements ...CardElement) *CardBuilder {
+	for _, element := range elements {
+		b.card.Elements = append(b.card.Elements, element)
+	}
+	return b
+}
+
+// Text adds a div of plain text.
+func (b *CardBuilder) Text(content string) *CardBuilder {
+	text := PlainText(content)
+	return b.Add(CardDiv{Text: &text})
+}
+
+// Markdown adds a markdown element.
+func (b *CardBuilder) Markdown(content string) *CardBuilder {
+	return b.Add(CardMarkdown{Content: content})
+}
+
+// Fields adds a div of fields.
+func (b *CardBuilder) Fields(fields ...CardField) *CardBuilder {
+	return b.Add(CardDiv{Fields: fields})
+}
+
+// Image adds an image.
+func (b *CardBuilder) Image(imgKey, alt string) *CardBuilder {
+	return b.Add(CardImage{ImgKey: imgKey, Alt: PlainText(alt)})
+}
+
+// Hr adds a horizontal rule.
+func (b *CardBuilder) Hr() *CardBuilder {
+	return b.Add(CardHr{})
+}
+
+// Note adds a note of plain texts.
+func (b *CardBuilder) Note(texts ...string) *CardBuilder {
+	var note CardNote
+	for _, text := range texts {
+		note.Elements = append(note.Elements, PlainText(text))
+	}
+	return b.Add(note)
+}
+
+// Actions adds an action element of buttons, select menus and date pickers.
+func (b *CardBuilder) Actions(actions ...CardActionElement) *CardBuilder {
+	return b.Add(CardAction{Actions: actions})
+}
+
+// Columns adds a column set of the columns.
+func (b *CardBuilder) Columns(columns ...CardColumn) *CardBuilder {
+	return b.Add(CardColumnSet{FlexMode: "none", Columns: columns})
+}
+
+// Build returns the card.
+func (b *CardBuilder) Build() Card {
+	return b.card
+}
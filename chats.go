@@ -0,0 +1,51 @@
+package larkslim
+
+type (
+	// ChatIterator iterates over chats the bot is in, requesting pages
+	// when needed:
+	//
+	//	it := api.Chats()
+	//	for it.Next() {
+	//		fmt.Println(it.Chat().Name)
+	//	}
+	//	if err := it.Err(); err != nil {
+	//		...
+	//	}
+	ChatIterator struct {
+		api       *API
+		page      Groups
+		pageToken string
+		started   bool
+		chat      Group
+		err       error
+	}
+)
+
+// Chats returns an iterator over chats the bot is in.
+func (api *API) Chats() *ChatIterator {
+	return &ChatIterator{api: api}
+}
+
+// Next advances to the next chat, it returns false when there are no more
+// chats or an error occurs.
+func (it *ChatIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || (it.started && it.pageToken == "") {
+			return false
+		}
+		it.started = true
+		it.page, it.pageToken, it.err = it.api.ListChatsPage(it.pageToken)
+	}
+	it.chat, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Chat returns the current chat.
+func (it *ChatIterator) Chat() Group {
+	return it.chat
+}
+
+// Err returns the error occurred during iteration.
+func (it *ChatIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,48 @@
+package larkslim
+
+import (
+	"time"
+)
+
+type (
+	// Clock provides the current time, sleeping and timers, so tests can
+	// replace real time with a fake clock.
+	Clock interface {
+		Now() time.Time
+		Sleep(d time.Duration)
+
+		// AfterFunc calls f after d, like time.AfterFunc.
+		AfterFunc(d time.Duration, f func()) Timer
+	}
+
+	// Timer is returned by AfterFunc of Clock, *time.Timer implements it.
+	Timer interface {
+		// Stop prevents the func from being called, it returns false if
+		// the func has been called or the timer has been stopped.
+		Stop() bool
+	}
+
+	systemClock struct{}
+)
+
+// SystemClock is the Clock using the time package.
+var SystemClock Clock = systemClock{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func (api *API) clock() Clock {
+	if api.Clock != nil {
+		return api.Clock
+	}
+	return SystemClock
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+func die(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(1)
+}
+
+func main() {
+	var address string
+	flag.StringVar(&address, "serve", "", "serve preview on this address, re-reading the file on every request")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"Usage: %s [file] - %s\n", os.Args[0],
+			"render card JSON from file (or stdin) to HTML",
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	file := flag.Arg(0)
+
+	if address == "" {
+		html, err := render(file)
+		if err != nil {
+			die(err)
+		}
+		fmt.Print(html)
+		return
+	}
+
+	if file == "" {
+		die("error: file is required to serve preview")
+	}
+	fmt.Fprintln(os.Stderr, "listening", address)
+	die(http.ListenAndServe(address, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		html, err := render(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, html)
+	})))
+}
+
+func render(file string) (string, error) {
+	var data []byte
+	var err error
+	if file == "" {
+		fmt.Fprintln(os.Stderr, "Reading from stdin...")
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return "", err
+	}
+	var card larkslim.Card
+	if err := json.Unmarshal(data, &card); err != nil {
+		return "", err
+	}
+	return card.HTML(), nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/caiguanhao/larkslim"
+	"github.com/caiguanhao/larkslim/larkbot"
+)
+
+func main() {
+	var appId, appSecret, eventURL, encryptKey, token string
+	flag.StringVar(&appId, "app-id", "", "lark app id (you can also use env LARK_APP_ID)")
+	flag.StringVar(&appSecret, "app-secret", "", "lark app secret (you can also use env LARK_APP_SECRET)")
+	flag.StringVar(&eventURL, "event-url", "", "also test the deployed events endpoint at this url")
+	flag.StringVar(&encryptKey, "encrypt-key", "", "encrypt key of events (you can also use env LARK_ENCRYPT_KEY)")
+	flag.StringVar(&token, "verification-token", "", "verification token of events (you can also use env LARK_VERIFICATION_TOKEN)")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"Usage: %s - %s\n", os.Args[0],
+			"check configuration of a lark app",
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if encryptKey == "" {
+		encryptKey = os.Getenv("LARK_ENCRYPT_KEY")
+	}
+	if token == "" {
+		token = os.Getenv("LARK_VERIFICATION_TOKEN")
+	}
+
+	l := larkslim.NewAPI(appId, appSecret)
+	diagnosis := l.Diagnose()
+	if eventURL != "" {
+		diagnosis = append(diagnosis, larkslim.DiagnosticCheck{
+			Name:   "event url",
+			Detail: eventURL,
+			Err:    larkbot.SelfTest(eventURL, encryptKey, token),
+		})
+	}
+	fmt.Print(diagnosis)
+	if !diagnosis.OK() {
+		os.Exit(1)
+	}
+}
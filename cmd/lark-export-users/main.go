@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+func die(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(1)
+}
+
+func main() {
+	var appId, appSecret, format, output, root string
+	flag.StringVar(&appId, "app-id", "", "lark app id (you can also use env LARK_APP_ID)")
+	flag.StringVar(&appSecret, "app-secret", "", "lark app secret (you can also use env LARK_APP_SECRET)")
+	flag.StringVar(&format, "format", "csv", "output format (csv or json)")
+	flag.StringVar(&output, "output", "", "write to file instead of stdout")
+	flag.StringVar(&root, "department", "0", "open_department_id of the department to export, 0 for the whole tenant")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"Usage: %s - %s\n", os.Args[0],
+			"export users of all departments of lark tenant",
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if format != "csv" && format != "json" {
+		die("error: unknown format:", format)
+	}
+
+	l := larkslim.NewAPI(appId, appSecret)
+
+	departments := []string{root}
+	children, err := l.ListAllDepartments(root)
+	if err != nil {
+		die(err)
+	}
+	for _, child := range children {
+		departments = append(departments, child.OpenDepartmentId)
+	}
+
+	var users []larkslim.ContactUser
+	seen := map[string]bool{}
+	for _, department := range departments {
+		items, err := l.ListAllUsersInDepartment(department)
+		if err != nil {
+			die(err)
+		}
+		for _, u := range items {
+			if seen[u.OpenId] {
+				continue
+			}
+			seen[u.OpenId] = true
+			users = append(users, u)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Exported", len(users), "users of", len(departments), "departments")
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			die(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(users)
+	} else {
+		err = writeCSV(w, users)
+	}
+	if err != nil {
+		die(err)
+	}
+}
+
+func writeCSV(w io.Writer, users []larkslim.ContactUser) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"open_id", "user_id", "union_id", "name", "en_name", "email",
+		"enterprise_email", "mobile", "job_title", "employee_no", "department_ids",
+	})
+	for _, u := range users {
+		cw.Write([]string{
+			u.OpenId, u.UserId, u.UnionId, u.Name, u.EnName, u.Email,
+			u.EnterpriseEmail, u.Mobile, u.JobTitle, u.EmployeeNo, strings.Join(u.DepartmentIds, ";"),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func die(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(1)
+}
+
+func main() {
+	var appId, appSecret, sendTarget, pprofAddress string
+	var total, concurrency int
+	var real bool
+	flag.StringVar(&appId, "app-id", "", "lark app id (you can also use env LARK_APP_ID)")
+	flag.StringVar(&appSecret, "app-secret", "", "lark app secret (you can also use env LARK_APP_SECRET)")
+	flag.StringVar(&sendTarget, "target", "oc_test", "send messages to open_id, user_id, email or chat_id")
+	flag.StringVar(&pprofAddress, "pprof", "", "serve net/http/pprof on this address, e.g. 127.0.0.1:6060")
+	flag.IntVar(&total, "n", 10000, "number of messages to send")
+	flag.IntVar(&concurrency, "c", 16, "number of concurrent senders")
+	flag.BoolVar(&real, "real", false, "send to the real open platform instead of a fake server")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"Usage: %s - %s\n", os.Args[0],
+			"send messages concurrently and report latency, for profiling the send path",
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if pprofAddress != "" {
+		go func() {
+			die(http.ListenAndServe(pprofAddress, nil))
+		}()
+		fmt.Fprintln(os.Stderr, "pprof listening", pprofAddress)
+	}
+
+	var l *larkslim.API
+	if real {
+		l = larkslim.NewAPI(appId, appSecret)
+		if l.AppId == "" || l.AppSecret == "" {
+			die("error: empty app id or app secret")
+		}
+	} else {
+		server := larktest.NewServer()
+		defer server.Close()
+		l = server.API()
+	}
+
+	jobs := make(chan int)
+	latencies := make([]time.Duration, total)
+	var errors int
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				t := time.Now()
+				_, err := l.SendMessage(sendTarget, fmt.Sprintf("message %d", n))
+				latencies[n] = time.Since(t)
+				if err != nil {
+					mutex.Lock()
+					errors++
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for n := 0; n < total; n++ {
+		jobs <- n
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		if len(latencies) == 0 {
+			return 0
+		}
+		return latencies[int(float64(len(latencies)-1)*p)]
+	}
+	fmt.Printf("sent %d messages in %s (%.0f/s), %d errors\n",
+		total, elapsed, float64(total)/elapsed.Seconds(), errors)
+	fmt.Printf("latency p50 %s, p90 %s, p99 %s, max %s\n",
+		percentile(0.5), percentile(0.9), percentile(0.99), percentile(1))
+}
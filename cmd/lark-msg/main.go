@@ -54,12 +54,9 @@ func main() {
 		content = strings.Join(flag.Args(), " ")
 	}
 
-	l := larkslim.API{
-		AppId:     appId,
-		AppSecret: appSecret,
-	}
+	l := larkslim.NewAPI(appId, appSecret)
 
-	err := l.SendMessage(sendTarget, content)
+	_, err := l.SendMessage(sendTarget, content)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
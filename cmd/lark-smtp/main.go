@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+const maxMessageSize = 20 << 20
+
+type (
+	email struct {
+		From        string
+		Subject     string
+		Text        string
+		Attachments []attachment
+	}
+
+	attachment struct {
+		Name        string
+		ContentType string
+		Data        []byte
+	}
+)
+
+var wordDecoder = mime.WordDecoder{}
+
+func die(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(1)
+}
+
+func main() {
+	var appId, appSecret, address, targets string
+	flag.StringVar(&appId, "app-id", "", "lark app id (you can also use env LARK_APP_ID)")
+	flag.StringVar(&appSecret, "app-secret", "", "lark app secret (you can also use env LARK_APP_SECRET)")
+	flag.StringVar(&address, "address", "127.0.0.1:2525", "address to listen on")
+	flag.StringVar(&targets, "target", "", "comma-separated open_ids, user_ids, emails or chat_ids to forward emails to")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"Usage: %s - %s\n", os.Args[0],
+			"forward emails received over smtp to lark",
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if appId == "" {
+		appId = os.Getenv("LARK_APP_ID")
+	}
+	if appId == "" {
+		die("error: empty app id")
+	}
+
+	if appSecret == "" {
+		appSecret = os.Getenv("LARK_APP_SECRET")
+	}
+	if appSecret == "" {
+		die("error: empty app secret")
+	}
+
+	var sendTargets []string
+	for _, target := range strings.Split(targets, ",") {
+		if target = strings.TrimSpace(target); target != "" {
+			sendTargets = append(sendTargets, target)
+		}
+	}
+	if len(sendTargets) == 0 {
+		die("error: empty target")
+	}
+
+	l := larkslim.NewAPI(appId, appSecret)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		die(err)
+	}
+	log.Println("listening", address)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		go serve(conn, func(data []byte) error {
+			e, err := parseEmail(data)
+			if err != nil {
+				return err
+			}
+			go forward(l, sendTargets, e)
+			return nil
+		})
+	}
+}
+
+// serve implements the minimal subset of SMTP needed to receive emails.
+func serve(conn net.Conn, handle func([]byte) error) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(code int, msg string) {
+		conn.SetWriteDeadline(time.Now().Add(time.Minute))
+		text.PrintfLine("%d %s", code, msg)
+	}
+	reply(220, "lark-smtp ready")
+	var hasSender, hasRecipient bool
+	for {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Minute))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "HELO", "EHLO":
+			hasSender, hasRecipient = false, false
+			reply(250, "lark-smtp")
+		case "MAIL":
+			hasSender, hasRecipient = true, false
+			reply(250, "ok")
+		case "RCPT":
+			if !hasSender {
+				reply(503, "need MAIL command")
+				continue
+			}
+			hasRecipient = true
+			reply(250, "ok")
+		case "DATA":
+			if !hasRecipient {
+				reply(503, "need RCPT command")
+				continue
+			}
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+			dot := text.DotReader()
+			data, err := ioutil.ReadAll(io.LimitReader(dot, maxMessageSize+1))
+			if err != nil {
+				return
+			}
+			hasSender, hasRecipient = false, false
+			if len(data) > maxMessageSize {
+				// read the rest of the message to get to the next command
+				if _, err := io.Copy(ioutil.Discard, dot); err != nil {
+					return
+				}
+				reply(552, "message exceeds fixed maximum message size")
+				continue
+			}
+			if err := handle(data); err != nil {
+				log.Println(err)
+				reply(554, "bad message")
+				continue
+			}
+			reply(250, "ok")
+		case "RSET":
+			hasSender, hasRecipient = false, false
+			reply(250, "ok")
+		case "NOOP":
+			reply(250, "ok")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+func parseEmail(data []byte) (e email, err error) {
+	var msg *mail.Message
+	msg, err = mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	e.From, _ = wordDecoder.DecodeHeader(msg.Header.Get("From"))
+	e.Subject, _ = wordDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	err = parsePart(&e, textproto.MIMEHeader(msg.Header), msg.Body)
+	return
+}
+
+func parsePart(e *email, header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := parsePart(e, part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	_, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := dispositionParams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	if name == "" && mediaType == "text/plain" && e.Text == "" {
+		e.Text = string(data)
+		return nil
+	}
+	if name == "" && strings.HasPrefix(mediaType, "text/") {
+		// alternative representations of the text, e.g. html
+		return nil
+	}
+	name, _ = wordDecoder.DecodeHeader(name)
+	e.Attachments = append(e.Attachments, attachment{
+		Name:        name,
+		ContentType: mediaType,
+		Data:        data,
+	})
+	return nil
+}
+
+func forward(l *larkslim.API, targets []string, e email) {
+	var lines larkslim.PostLines
+	lines = append(lines, larkslim.PostLine{{Tag: "text", Text: "From: " + e.From}})
+	for _, line := range strings.Split(strings.TrimSpace(e.Text), "\n") {
+		lines = append(lines, larkslim.PostLine{{Tag: "text", Text: strings.TrimRight(line, "\r")}})
+	}
+	var imageKeys, fileKeys []string
+	for _, a := range e.Attachments {
+		if strings.HasPrefix(a.ContentType, "image/") {
+			key, err := l.UploadMessageImage(bytes.NewReader(a.Data))
+			if err == nil {
+				imageKeys = append(imageKeys, key)
+				continue
+			}
+			log.Println(err)
+		}
+		name := a.Name
+		if name == "" {
+			name = "attachment"
+		}
+		lines = append(lines, larkslim.PostLine{{Tag: "text", Text: "Attachment: " + name}})
+		key, err := l.UploadFile(bytes.NewReader(a.Data), "", name)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		fileKeys = append(fileKeys, key)
+	}
+	post := larkslim.Post{
+		"zh_cn": larkslim.PostOfLocale{
+			Title:   e.Subject,
+			Content: lines,
+		},
+	}
+	for _, target := range targets {
+		if _, err := l.SendPost(target, post); err != nil {
+			log.Println(err)
+			continue
+		}
+		for _, key := range imageKeys {
+			if _, err := l.SendImageMessage(target, key); err != nil {
+				log.Println(err)
+			}
+		}
+		for _, key := range fileKeys {
+			if _, err := l.SendFileMessage(target, key); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEmail(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		message     string
+		subject     string
+		text        string
+		attachments []string
+	}{
+		{
+			name: "plain",
+			message: "From: cron@example.com\r\n" +
+				"Subject: backup\r\n" +
+				"\r\n" +
+				"backup finished\r\n",
+			subject: "backup",
+			text:    "backup finished\r\n",
+		},
+		{
+			name: "alternative",
+			message: "From: cron@example.com\r\n" +
+				"Subject: =?UTF-8?B?5aSH5Lu9?=\r\n" +
+				"Content-Type: multipart/alternative; boundary=b\r\n" +
+				"\r\n" +
+				"--b\r\n" +
+				"Content-Type: text/plain; charset=utf-8\r\n" +
+				"\r\n" +
+				"plain text\r\n" +
+				"--b\r\n" +
+				"Content-Type: text/html; charset=utf-8\r\n" +
+				"\r\n" +
+				"<p>html</p>\r\n" +
+				"--b--\r\n",
+			subject: "备份",
+			text:    "plain text",
+		},
+		{
+			name: "base64",
+			message: "From: cron@example.com\r\n" +
+				"Subject: report\r\n" +
+				"Content-Type: multipart/mixed; boundary=b\r\n" +
+				"\r\n" +
+				"--b\r\n" +
+				"Content-Type: text/plain\r\n" +
+				"Content-Transfer-Encoding: base64\r\n" +
+				"\r\n" +
+				"aGVsbG8g\r\n" +
+				"d29ybGQ=\r\n" +
+				"--b\r\n" +
+				"Content-Type: text/csv; name=report.csv\r\n" +
+				"Content-Transfer-Encoding: base64\r\n" +
+				"\r\n" +
+				"YSxi\r\n" +
+				"--b--\r\n",
+			subject:     "report",
+			text:        "hello world",
+			attachments: []string{"report.csv text/csv a,b"},
+		},
+		{
+			name: "quoted-printable",
+			message: "From: cron@example.com\r\n" +
+				"Subject: disk\r\n" +
+				"Content-Type: text/plain; charset=utf-8\r\n" +
+				"Content-Transfer-Encoding: quoted-printable\r\n" +
+				"\r\n" +
+				"disk usage =3D 95%, a very long line that is =\r\n" +
+				"wrapped\r\n",
+			subject: "disk",
+			text:    "disk usage = 95%, a very long line that is wrapped\r\n",
+		},
+	} {
+		e, err := parseEmail([]byte(test.message))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if e.From != "cron@example.com" || e.Subject != test.subject || e.Text != test.text {
+			t.Errorf("%s: wrong email %q %q %q", test.name, e.From, e.Subject, e.Text)
+		}
+		var attachments []string
+		for _, a := range e.Attachments {
+			attachments = append(attachments, a.Name+" "+a.ContentType+" "+string(a.Data))
+		}
+		if strings.Join(attachments, "\n") != strings.Join(test.attachments, "\n") {
+			t.Errorf("%s: wrong attachments %q", test.name, attachments)
+		}
+	}
+}
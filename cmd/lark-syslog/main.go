@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	entry struct {
+		Severity int
+		Host     string
+		App      string
+		Message  string
+	}
+
+	batcher struct {
+		sync.Mutex
+		lines []string
+		count map[string]int
+	}
+)
+
+var (
+	rfc5424 = regexp.MustCompile(`^<(\d{1,3})>\d+ (\S+) (\S+) (\S+) (\S+) (\S+) (-|\[.*?\]) ?(.*)$`)
+	rfc3164 = regexp.MustCompile(`^<(\d{1,3})>(?:[A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d )?(\S+) ([^:\[\s]+)(?:\[\d+\])?: ?(.*)$`)
+)
+
+func die(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(1)
+}
+
+func main() {
+	var appId, appSecret, targets, udpAddress, file, match string
+	var maxSeverity, maxLines int
+	var interval time.Duration
+	flag.StringVar(&appId, "app-id", "", "lark app id (you can also use env LARK_APP_ID)")
+	flag.StringVar(&appSecret, "app-secret", "", "lark app secret (you can also use env LARK_APP_SECRET)")
+	flag.StringVar(&targets, "target", "", "comma-separated open_ids, user_ids, emails or chat_ids to send to")
+	flag.StringVar(&udpAddress, "udp", "", "receive syslog (RFC5424 or RFC3164) over udp on this address")
+	flag.StringVar(&file, "file", "", "follow this log file")
+	flag.StringVar(&match, "match", "", "only forward lines matching this regular expression")
+	flag.IntVar(&maxSeverity, "severity", 7, "only forward syslog messages with this severity or more severe (0-7)")
+	flag.IntVar(&maxLines, "max-lines", 50, "max number of lines in one lark message")
+	flag.DurationVar(&interval, "interval", time.Minute, "send at most one lark message every interval")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"Usage: %s - %s\n", os.Args[0],
+			"forward syslog, log files or stdin (e.g. journalctl -f) to lark",
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if appId == "" {
+		appId = os.Getenv("LARK_APP_ID")
+	}
+	if appId == "" {
+		die("error: empty app id")
+	}
+
+	if appSecret == "" {
+		appSecret = os.Getenv("LARK_APP_SECRET")
+	}
+	if appSecret == "" {
+		die("error: empty app secret")
+	}
+
+	var sendTargets []string
+	for _, target := range strings.Split(targets, ",") {
+		if target = strings.TrimSpace(target); target != "" {
+			sendTargets = append(sendTargets, target)
+		}
+	}
+	if len(sendTargets) == 0 {
+		die("error: empty target")
+	}
+
+	var matchRe *regexp.Regexp
+	if match != "" {
+		var err error
+		matchRe, err = regexp.Compile(match)
+		if err != nil {
+			die(err)
+		}
+	}
+
+	l := larkslim.NewAPI(appId, appSecret)
+	b := &batcher{}
+
+	lines := make(chan string, 1000)
+	added := make(chan struct{}) // closed when all lines are added to b
+	go func() {
+		defer close(added)
+		for line := range lines {
+			e := parse(line)
+			if e.Severity > maxSeverity {
+				continue
+			}
+			text := e.String()
+			if matchRe != nil && !matchRe.MatchString(text) {
+				continue
+			}
+			b.Add(text)
+		}
+	}()
+
+	sent := make(chan struct{}) // closed when the last batch is sent
+	go func() {
+		defer close(sent)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			last := false
+			select {
+			case <-ticker.C:
+			case <-added:
+				last = true
+			}
+			if text := b.Flush(maxLines); text != "" {
+				for _, target := range sendTargets {
+					if _, err := l.SendMessage(target, text); err != nil {
+						log.Println(err)
+					}
+				}
+			}
+			if last {
+				return
+			}
+		}
+	}()
+
+	switch {
+	case udpAddress != "":
+		err := listenUDP(udpAddress, lines)
+		die(err)
+	case file != "":
+		err := follow(file, lines)
+		die(err)
+	default:
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+		<-sent
+	}
+}
+
+func listenUDP(address string, lines chan<- string) error {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return err
+	}
+	log.Println("listening", address)
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(buf[:n]), "\r\n\x00"), "\n") {
+			lines <- line
+		}
+	}
+}
+
+// follow reads new lines appended to file, like tail -F.
+func follow(file string, lines chan<- string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(f)
+	var partial string
+	for {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+		if err == nil {
+			lines <- strings.TrimRight(partial+line, "\r\n")
+			partial = ""
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		partial += line
+		time.Sleep(time.Second)
+		if fi, err := os.Stat(file); err == nil && fi.Size() < offset {
+			// truncated or rotated
+			f.Close()
+			if f, err = os.Open(file); err != nil {
+				return err
+			}
+			reader.Reset(f)
+			offset, partial = 0, ""
+		}
+	}
+}
+
+func parse(line string) (e entry) {
+	e.Severity = -1
+	if m := rfc5424.FindStringSubmatch(line); m != nil {
+		e.Severity = severity(m[1])
+		e.Host, e.App, e.Message = nilValue(m[3]), nilValue(m[4]), strings.TrimPrefix(m[8], "\ufeff")
+		return
+	}
+	if m := rfc3164.FindStringSubmatch(line); m != nil {
+		e.Severity = severity(m[1])
+		e.Host, e.App, e.Message = m[2], m[3], m[4]
+		return
+	}
+	e.Message = line
+	return
+}
+
+func severity(pri string) int {
+	n, _ := strconv.Atoi(pri)
+	return n % 8
+}
+
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+func (e entry) String() string {
+	var parts []string
+	if e.Host != "" {
+		parts = append(parts, e.Host)
+	}
+	if e.App != "" {
+		parts = append(parts, e.App+":")
+	}
+	parts = append(parts, e.Message)
+	return strings.Join(parts, " ")
+}
+
+// Add adds a line to the batch, identical lines are only counted.
+func (b *batcher) Add(line string) {
+	b.Lock()
+	defer b.Unlock()
+	if b.count == nil {
+		b.count = map[string]int{}
+	}
+	if b.count[line] == 0 {
+		b.lines = append(b.lines, line)
+	}
+	b.count[line]++
+}
+
+// Flush returns the batched lines as text and empties the batch.
+func (b *batcher) Flush(maxLines int) string {
+	b.Lock()
+	defer b.Unlock()
+	var s strings.Builder
+	for i, line := range b.lines {
+		if i == maxLines {
+			fmt.Fprintf(&s, "... and %d more\n", len(b.lines)-maxLines)
+			break
+		}
+		s.WriteString(line)
+		if n := b.count[line]; n > 1 {
+			fmt.Fprintf(&s, " (x%d)", n)
+		}
+		s.WriteString("\n")
+	}
+	b.lines, b.count = nil, nil
+	return strings.TrimRight(s.String(), "\n")
+}
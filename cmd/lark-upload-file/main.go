@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+func die(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(1)
+}
+
+func main() {
+	var appId, appSecret, fileType, fileName, sendTarget string
+	flag.StringVar(&appId, "app-id", "", "lark app id (you can also use env LARK_APP_ID)")
+	flag.StringVar(&appSecret, "app-secret", "", "lark app secret (you can also use env LARK_APP_SECRET)")
+	flag.StringVar(&fileType, "type", "", "file type (opus, mp4, pdf, doc, xls, ppt or stream), detected from file name if empty")
+	flag.StringVar(&fileName, "name", "", "file name of file from stdin")
+	flag.StringVar(&sendTarget, "send", "", "also send file message to open_id, user_id, email or chat_id")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"Usage: %s [file ...] - %s\n", os.Args[0],
+			"upload files from files or stdin to lark",
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if appId == "" {
+		appId = os.Getenv("LARK_APP_ID")
+	}
+	if appId == "" {
+		die("error: empty app id")
+	}
+
+	if appSecret == "" {
+		appSecret = os.Getenv("LARK_APP_SECRET")
+	}
+	if appSecret == "" {
+		die("error: empty app secret")
+	}
+
+	l := larkslim.NewAPI(appId, appSecret)
+
+	var hasErrors bool
+
+	process := func(key string) {
+		if sendTarget != "" {
+			_, err := l.SendFileMessage(sendTarget, key)
+			if err != nil {
+				hasErrors = true
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+		fmt.Println(key)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		if fileName == "" {
+			die("error: empty file name")
+		}
+		key, err := l.UploadFile(os.Stdin, fileType, fileName)
+		if err != nil {
+			die(err)
+		}
+		process(key)
+		return
+	}
+
+	for _, fn := range args {
+		f, err := os.Open(fn)
+		if err != nil {
+			hasErrors = true
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		key, err := l.UploadFile(f, fileType, filepath.Base(fn))
+		f.Close()
+		if err != nil {
+			hasErrors = true
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		process(key)
+	}
+	if hasErrors {
+		os.Exit(1)
+	}
+}
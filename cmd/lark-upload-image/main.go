@@ -16,10 +16,12 @@ func die(a ...interface{}) {
 
 func main() {
 	var appId, appSecret, imageType, sendTarget string
+	var concurrency int
 	flag.StringVar(&appId, "app-id", "", "lark app id (you can also use env LARK_APP_ID)")
 	flag.StringVar(&appSecret, "app-secret", "", "lark app secret (you can also use env LARK_APP_SECRET)")
 	flag.StringVar(&imageType, "type", "message", "image type (message or avatar)")
 	flag.StringVar(&sendTarget, "send", "", "also send image message to open_id, user_id, email or chat_id")
+	flag.IntVar(&concurrency, "concurrency", 4, "number of concurrent uploads of message images")
 	flag.Usage = func() {
 		fmt.Fprintf(
 			flag.CommandLine.Output(),
@@ -44,10 +46,7 @@ func main() {
 		die("error: empty app secret")
 	}
 
-	l := larkslim.API{
-		AppId:     appId,
-		AppSecret: appSecret,
-	}
+	l := larkslim.NewAPI(appId, appSecret)
 
 	args := flag.Args()
 	var uploadFunc func(io.Reader) (string, error)
@@ -63,7 +62,7 @@ func main() {
 
 	process := func(key string) {
 		if sendTarget != "" {
-			err := l.SendImageMessage(sendTarget, key)
+			_, err := l.SendImageMessage(sendTarget, key)
 			if err != nil {
 				hasErrors = true
 				fmt.Fprintln(os.Stderr, err)
@@ -81,21 +80,48 @@ func main() {
 		return
 	}
 
-	for _, fn := range args {
-		f, err := os.Open(fn)
-		if err != nil {
-			hasErrors = true
-			fmt.Fprintln(os.Stderr, err)
-			continue
+	if imageType == "message" && len(args) > 1 {
+		var files []larkslim.NamedReader
+		for _, fn := range args {
+			f, err := os.Open(fn)
+			if err != nil {
+				hasErrors = true
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			defer f.Close()
+			files = append(files, larkslim.NamedReader{Name: fn, Reader: f})
 		}
-		key, err := uploadFunc(f)
-		f.Close()
-		if err != nil {
-			hasErrors = true
-			fmt.Fprintln(os.Stderr, err)
-			continue
+		keys, err := l.UploadMessageImages(files, concurrency)
+		errs, _ := err.(larkslim.UploadErrors)
+		for i, key := range keys {
+			if errs != nil && errs[i] != nil {
+				hasErrors = true
+				fmt.Fprintln(os.Stderr, errs[i])
+				continue
+			}
+			process(key)
+		}
+		if err != nil && errs == nil {
+			die(err)
+		}
+	} else {
+		for _, fn := range args {
+			f, err := os.Open(fn)
+			if err != nil {
+				hasErrors = true
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			key, err := uploadFunc(f)
+			f.Close()
+			if err != nil {
+				hasErrors = true
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			process(key)
 		}
-		process(key)
 	}
 	if hasErrors {
 		os.Exit(1)
@@ -0,0 +1,78 @@
+package larkslim
+
+import (
+	"strings"
+)
+
+// CodeBlock returns a card markdown element displaying code in monospace
+// font, language (for example "go") is used for syntax highlighting and can
+// be empty.
+func CodeBlock(language, code string) map[string]interface{} {
+	return map[string]interface{}{
+		"tag":     "markdown",
+		"content": "```" + language + "\n" + escapeCodeFence(strings.TrimRight(code, "\n")) + "\n```",
+	}
+}
+
+// CodeBlockTag returns a post tag displaying code in monospace font. Code
+// blocks must be on their own line, see PostBuilder.CodeBlock.
+func CodeBlockTag(language, code string) PostTag {
+	return PostTag{
+		Tag:      "code_block",
+		Language: strings.ToUpper(language),
+		Text:     strings.TrimRight(code, "\n"),
+	}
+}
+
+// CodeBlock adds a code block on its own line.
+func (b *PostBuilder) CodeBlock(language, code string) *PostBuilder {
+	b.NewLine()
+	b.lines = append(b.lines, PostLine{CodeBlockTag(language, code)})
+	return b
+}
+
+// Diff returns a card markdown element of the unified diff, with added lines
+// in green, removed lines in red and hunk headers in grey.
+func Diff(diff string) map[string]interface{} {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		color := ""
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			color = "green"
+		case strings.HasPrefix(line, "-"):
+			color = "red"
+		case strings.HasPrefix(line, "@@"):
+			color = "grey"
+		}
+		line = escapeMarkdown(line)
+		if line == "" {
+			line = " "
+		}
+		if color != "" {
+			line = "<font color='" + color + "'>" + line + "</font>"
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return map[string]interface{}{
+		"tag":     "markdown",
+		"content": strings.TrimRight(b.String(), "\n"),
+	}
+}
+
+// escapeCodeFence breaks up ``` in code so it doesn't end the block early.
+func escapeCodeFence(code string) string {
+	return strings.ReplaceAll(code, "```", "`\u200b``")
+}
+
+var markdownEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
@@ -0,0 +1,192 @@
+package larkslim
+
+import (
+	"net/url"
+)
+
+// maximum number of emails or mobiles of each batch_get_id request
+const maxUserIdLookupsPerRequest = 50
+
+type (
+	// Department is a department of the contact, with open department
+	// ids.
+	Department struct {
+		Name               string `json:"name"`
+		DepartmentId       string `json:"department_id"`
+		OpenDepartmentId   string `json:"open_department_id"`
+		ParentDepartmentId string `json:"parent_department_id"`
+		LeaderUserId       string `json:"leader_user_id"`
+		MemberCount        int    `json:"member_count"`
+	}
+
+	// ContactUser is a user of the contact, with open ids and open
+	// department ids.
+	ContactUser struct {
+		OpenId          string   `json:"open_id"`
+		UserId          string   `json:"user_id"`
+		UnionId         string   `json:"union_id"`
+		Name            string   `json:"name"`
+		EnName          string   `json:"en_name"`
+		Email           string   `json:"email"`
+		EnterpriseEmail string   `json:"enterprise_email"`
+		Mobile          string   `json:"mobile"`
+		JobTitle        string   `json:"job_title"`
+		EmployeeNo      string   `json:"employee_no"`
+		DepartmentIds   []string `json:"department_ids"`
+	}
+
+	DepartmentsResponse struct {
+		APIResponse
+		Data struct {
+			Items     []Department `json:"items"`
+			PageToken string       `json:"page_token"`
+			HasMore   bool         `json:"has_more"`
+		} `json:"data"`
+	}
+
+	BatchGetIdResponse struct {
+		APIResponse
+		Data struct {
+			UserList []struct {
+				UserId string `json:"user_id"`
+				Email  string `json:"email"`
+				Mobile string `json:"mobile"`
+			} `json:"user_list"`
+		} `json:"data"`
+	}
+
+	ContactUsersResponse struct {
+		APIResponse
+		Data struct {
+			Items     []ContactUser `json:"items"`
+			PageToken string        `json:"page_token"`
+			HasMore   bool          `json:"has_more"`
+		} `json:"data"`
+	}
+)
+
+// ListDepartments returns a page of all descendants of the department of
+// the open department id, "0" for the whole tenant. Pass empty pageToken for
+// the first page and the returned nextPageToken for the next page, which is
+// empty if this is the last page. Only departments in the contact range of
+// the app are returned.
+func (api *API) ListDepartments(parentId, pageToken string) (departments []Department, nextPageToken string, err error) {
+	var data DepartmentsResponse
+	err = api.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/contact/v3/departments/"+url.PathEscape(parentId)+"/children?department_id_type=open_department_id&user_id_type=open_id&fetch_child=true&page_size=50&page_token="+url.QueryEscape(pageToken),
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	departments = data.Data.Items
+	if data.Data.HasMore {
+		nextPageToken = data.Data.PageToken
+	}
+	return
+}
+
+// ListAllDepartments returns all descendants of the department, see
+// ListDepartments.
+func (api *API) ListAllDepartments(parentId string) (departments []Department, err error) {
+	var pageToken string
+	for {
+		var page []Department
+		page, pageToken, err = api.ListDepartments(parentId, pageToken)
+		departments = append(departments, page...)
+		if err != nil || pageToken == "" {
+			return
+		}
+	}
+}
+
+// ListUsersInDepartment returns a page of direct members of the department
+// of the open department id, see ListDepartments for pageToken.
+func (api *API) ListUsersInDepartment(departmentId, pageToken string) (users []ContactUser, nextPageToken string, err error) {
+	var data ContactUsersResponse
+	err = api.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/contact/v3/users/find_by_department?department_id_type=open_department_id&user_id_type=open_id&page_size=50&department_id="+url.QueryEscape(departmentId)+"&page_token="+url.QueryEscape(pageToken),
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	users = data.Data.Items
+	if data.Data.HasMore {
+		nextPageToken = data.Data.PageToken
+	}
+	return
+}
+
+// ListAllUsersInDepartment returns all direct members of the department.
+func (api *API) ListAllUsersInDepartment(departmentId string) (users []ContactUser, err error) {
+	var pageToken string
+	for {
+		var page []ContactUser
+		page, pageToken, err = api.ListUsersInDepartment(departmentId, pageToken)
+		users = append(users, page...)
+		if err != nil || pageToken == "" {
+			return
+		}
+	}
+}
+
+// GetUserIdsByEmails returns a map of emails to open ids. Emails not found,
+// or of users out of the contact range of the app, are not in the map.
+func (api *API) GetUserIdsByEmails(emails []string) (openIds map[string]string, err error) {
+	return api.batchGetId("emails", emails)
+}
+
+// GetUserIdsByMobiles returns a map of mobiles to open ids. Mobiles without
+// country codes are of China (+86), others must start with "+", for example
+// "+14155550100".
+func (api *API) GetUserIdsByMobiles(mobiles []string) (openIds map[string]string, err error) {
+	return api.batchGetId("mobiles", mobiles)
+}
+
+func (api *API) batchGetId(key string, values []string) (openIds map[string]string, err error) {
+	openIds = map[string]string{}
+	for _, batch := range batches(values, maxUserIdLookupsPerRequest) {
+		var data BatchGetIdResponse
+		err = api.NewRequest(
+			// method
+			"POST",
+
+			// path
+			"/contact/v3/users/batch_get_id?user_id_type=open_id",
+
+			// request body
+			map[string][]string{key: batch},
+
+			// response
+			&data,
+		)
+		if err != nil {
+			return
+		}
+		for _, user := range data.Data.UserList {
+			// empty user id means the user is not found
+			if user.UserId == "" {
+				continue
+			}
+			if key == "emails" {
+				openIds[user.Email] = user.UserId
+			} else {
+				openIds[user.Mobile] = user.UserId
+			}
+		}
+	}
+	return
+}
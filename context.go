@@ -0,0 +1,136 @@
+package larkslim
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// Metadata is attached to a context with WithMetadata, for example job
+	// id or user id, to attribute API calls made under the context in logs
+	// and metrics.
+	Metadata map[string]string
+
+	// RequestInfo describes a completed request for API.OnRequest.
+	RequestInfo struct {
+		Method   string
+		Path     string
+		Status   int // zero if no response is received
+		Duration time.Duration
+		Err      error
+		Metadata Metadata
+	}
+
+	metadataKey struct{}
+)
+
+// WithMetadata returns a copy of ctx with key-value pairs added to its
+// metadata.
+func WithMetadata(ctx context.Context, keyvals ...string) context.Context {
+	md := Metadata{}
+	for k, v := range MetadataFrom(ctx) {
+		md[k] = v
+	}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		md[keyvals[i]] = keyvals[i+1]
+	}
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// MetadataFrom returns the metadata of ctx, nil if there is none.
+func MetadataFrom(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	return md
+}
+
+// String returns the metadata as sorted key=value pairs.
+func (md Metadata) String() string {
+	pairs := make([]string, 0, len(md))
+	for k, v := range md {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// WithContext returns a copy of the API that sends requests with ctx, so
+// they are canceled when ctx is done. Metadata of ctx is passed to OnRequest
+// and prepended to messages of Debugger. The copy shares the access token
+// and other state with the API.
+func (api *API) WithContext(ctx context.Context) *API {
+	api.state()
+	copied := *api
+	copied.ctx = ctx
+	if md := MetadataFrom(ctx); len(md) > 0 && api.Debugger != nil {
+		debugger := api.Debugger
+		prefix := "[" + md.String() + "]"
+		copied.Debugger = func(args ...interface{}) {
+			debugger(append([]interface{}{prefix}, args...)...)
+		}
+	}
+	return &copied
+}
+
+// detached returns a copy of the API whose requests are not canceled with
+// the context given to WithContext, for work that outlives the caller, for
+// example sending held messages and refreshing the shared access token.
+// Metadata of the context is kept.
+func (api *API) detached() *API {
+	if api.ctx == nil {
+		return api
+	}
+	api.state()
+	copied := *api
+	copied.ctx = detachedContext{api.ctx}
+	return &copied
+}
+
+func (api *API) context() context.Context {
+	if api.ctx != nil {
+		return api.ctx
+	}
+	return context.Background()
+}
+
+// onRequest calls OnRequest with the result of the request.
+func (api *API) onRequest(req *http.Request, start time.Time, resp *http.Response, err error) {
+	if api.OnRequest == nil {
+		return
+	}
+	info := RequestInfo{
+		Method:   req.Method,
+		Path:     req.URL.Path,
+		Duration: api.clock().Now().Sub(start),
+		Err:      err,
+		Metadata: MetadataFrom(req.Context()),
+	}
+	if resp != nil {
+		info.Status = resp.StatusCode
+	}
+	api.OnRequest(info)
+}
+
+// detachedContext has the values of its parent but is never canceled, like
+// context.WithoutCancel of newer Go versions.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
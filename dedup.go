@@ -0,0 +1,47 @@
+package larkslim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// dedupKey returns the key used to find identical messages, or empty string
+// if DedupWindow is not set.
+func (api *API) dedupKey(target, msgType string, content interface{}) string {
+	if api.DedupWindow <= 0 {
+		return ""
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	h.Write([]byte(target + "\x00" + msgType + "\x00"))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isDuplicate reports whether the message with the key has been sent within
+// DedupWindow.
+func (api *API) isDuplicate(key string) bool {
+	if key == "" {
+		return false
+	}
+	_, ok, err := api.cache().Get(api.cacheKey("dedup", key))
+	if err != nil && api.Debugger != nil {
+		api.Debugger("dedup cache error:", err)
+	}
+	return ok
+}
+
+// markSent records the message with the key as sent.
+func (api *API) markSent(key string) {
+	if key == "" {
+		return
+	}
+	err := api.cache().Set(api.cacheKey("dedup", key), []byte{1}, api.DedupWindow)
+	if err != nil && api.Debugger != nil {
+		api.Debugger("dedup cache error:", err)
+	}
+}
@@ -0,0 +1,58 @@
+package larkslim
+
+import (
+	"net/http"
+	"strings"
+)
+
+// deprecatedPaths maps legacy API paths to their replacements.
+var deprecatedPaths = map[string]string{
+	"/message/v4/send/":        "POST /im/v1/messages (set Version to V1)",
+	"/chat/v4/list/":           "GET /im/v1/chats (set Version to V1)",
+	"/chat/v4/info/":           "GET /im/v1/chats/:chat_id (set Version to V1)",
+	"/chat/v4/create/":         "POST /im/v1/chats",
+	"/chat/v4/update/":         "PUT /im/v1/chats/:chat_id",
+	"/chat/v4/disband/":        "DELETE /im/v1/chats/:chat_id",
+	"/chat/v4/chatter/add/":    "POST /im/v1/chats/:chat_id/members",
+	"/chat/v4/chatter/delete/": "DELETE /im/v1/chats/:chat_id/members",
+	"/image/v4/put/":           "POST /im/v1/images",
+}
+
+// checkDeprecation warns once for each path which is known to be deprecated
+// or whose response has Deprecation or Sunset headers (RFC 8594).
+func (api *API) checkDeprecation(req *http.Request, resp *http.Response) {
+	warn := api.Warn
+	if warn == nil {
+		warn = api.Debugger
+	}
+	if warn == nil {
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/open-apis")
+	var msg []interface{}
+	if replacement, ok := deprecatedPaths[path]; ok {
+		msg = append(msg, "use", replacement, "instead")
+	}
+	if v := resp.Header.Get("Deprecation"); v != "" {
+		msg = append(msg, "Deprecation:", v)
+	}
+	if v := resp.Header.Get("Sunset"); v != "" {
+		msg = append(msg, "Sunset:", v)
+	}
+	if len(msg) == 0 {
+		return
+	}
+	state := api.state()
+	state.warnedMutex.Lock()
+	warned := state.warned[path]
+	if !warned {
+		if state.warned == nil {
+			state.warned = map[string]bool{}
+		}
+		state.warned[path] = true
+	}
+	state.warnedMutex.Unlock()
+	if !warned {
+		warn(append([]interface{}{"deprecated API " + path + ":"}, msg...)...)
+	}
+}
@@ -0,0 +1,96 @@
+package larkslim
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type (
+	DiagnosticCheck struct {
+		Name   string
+		Detail string
+		Err    error
+	}
+
+	Diagnosis []DiagnosticCheck
+)
+
+// Diagnose verifies credentials, token issuance, bot visibility, chats the
+// bot can reach and event configuration of the application, and returns the
+// results of all checks. Checks depending on a failed check are skipped.
+func (api *API) Diagnose() (d Diagnosis) {
+	add := func(name, detail string, err error) bool {
+		d = append(d, DiagnosticCheck{name, detail, err})
+		return err == nil
+	}
+
+	if api.AppId == "" || api.AppSecret == "" {
+		add("credentials", "", errors.New("app id or app secret is empty"))
+		return
+	}
+	add("credentials", "app id "+api.AppId, nil)
+
+	_, expiresAt, err := api.AccessToken()
+	if !add("access token", "expires at "+expiresAt.Format("2006-01-02 15:04:05"), err) {
+		return
+	}
+
+	bot, err := api.GetBotInfo()
+	if err == nil && bot.ActivateStatus != 2 {
+		err = fmt.Errorf("bot is not activated (status %d)", bot.ActivateStatus)
+	}
+	add("bot", fmt.Sprintf("%s (%s)", bot.AppName, bot.OpenId), err)
+
+	chats, err := api.ListAllChats()
+	if err == nil && len(chats) == 0 {
+		err = errors.New("bot is not in any chat")
+	}
+	add("chats", fmt.Sprintf("%d chats", len(chats)), err)
+
+	version, err := api.GetOnlineAppVersion()
+	if !add("app version", version.Version, err) {
+		return
+	}
+	var events []string
+	for _, event := range version.Events {
+		events = append(events, event.EventType)
+	}
+	err = nil
+	if len(events) == 0 {
+		err = errors.New("no events subscribed")
+	}
+	add("events", strings.Join(events, ", "), err)
+	err = nil
+	if version.Ability.Bot.CardRequestUrl == "" {
+		err = errors.New("card request url is not configured")
+	}
+	add("card request url", version.Ability.Bot.CardRequestUrl, err)
+	return
+}
+
+// OK reports whether all checks passed.
+func (d Diagnosis) OK() bool {
+	for _, check := range d {
+		if check.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (d Diagnosis) String() string {
+	var b strings.Builder
+	for _, check := range d {
+		if check.Err != nil {
+			fmt.Fprintf(&b, "[FAIL] %s: %s", check.Name, check.Err)
+		} else {
+			fmt.Fprintf(&b, "[ OK ] %s", check.Name)
+		}
+		if check.Detail != "" {
+			fmt.Fprintf(&b, " (%s)", check.Detail)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
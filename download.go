@@ -0,0 +1,84 @@
+package larkslim
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GetImage downloads the image uploaded by the bot. The caller must close
+// the returned reader.
+func (api *API) GetImage(imageKey string) (io.ReadCloser, error) {
+	return api.download("/im/v1/images/"+imageKey, imageKey)
+}
+
+// GetFile downloads the file uploaded by the bot. The caller must close the
+// returned reader.
+func (api *API) GetFile(fileKey string) (io.ReadCloser, error) {
+	return api.download("/im/v1/files/"+fileKey, fileKey)
+}
+
+// GetMessageResource downloads the image or file of a message, including
+// messages sent by users. resourceType is "image" or "file" (also used for
+// audio and video). The caller must close the returned reader.
+func (api *API) GetMessageResource(messageId, key, resourceType string) (io.ReadCloser, error) {
+	return api.download("/im/v1/messages/"+messageId+"/resources/"+key+"?type="+url.QueryEscape(resourceType), key)
+}
+
+// download returns the body of the response of GET path, scanned with
+// Scanner if it is set. Errors are returned as JSON.
+func (api *API) download(path, name string) (body io.ReadCloser, err error) {
+	var req *http.Request
+	req, err = api.newRequest("GET", path, nil)
+	if err != nil {
+		return
+	}
+	api.wait(req)
+	if api.Signer != nil {
+		if err = api.sign(req); err != nil {
+			return
+		}
+	}
+	var resp *http.Response
+	start := api.clock().Now()
+	defer func() {
+		api.onRequest(req, start, resp, err)
+	}()
+	resp, err = api.client().Do(req)
+	if err != nil {
+		return
+	}
+	if api.Debugger != nil {
+		api.Debugger(req.URL.String(), "->", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK || strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		defer resp.Body.Close()
+		var res []byte
+		res, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return
+		}
+		var apiResp APIResponse
+		if json.Unmarshal(res, &apiResp) == nil && apiResp.Code != 0 {
+			err = api.newError(resp.StatusCode, apiResp.Code, apiResp.Msg)
+		} else {
+			err = &Error{Status: resp.StatusCode, RetryAfter: retryAfter(resp)}
+		}
+		return
+	}
+	if api.Scanner == nil {
+		body = resp.Body
+		return
+	}
+	defer resp.Body.Close()
+	var file io.Reader
+	file, err = api.scan(name, resp.Body)
+	if err != nil {
+		return
+	}
+	body = ioutil.NopCloser(file)
+	return
+}
@@ -0,0 +1,298 @@
+package larkslim
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+const (
+	V4 = "v4"
+	V1 = "v1"
+
+	endpointSendMessage = "message.send"
+	endpointGetChat     = "chat.get"
+	endpointListChats   = "chat.list"
+)
+
+type (
+	// endpointInfo is an entry of the endpoint registry.
+	endpointInfo struct {
+		Name    string
+		Version string
+		Method  string
+		Path    string
+	}
+
+	chatMembersResponse struct {
+		APIResponse
+		Data struct {
+			Items []struct {
+				MemberId string `json:"member_id"`
+				Name     string `json:"name"`
+			} `json:"items"`
+			PageToken string `json:"page_token"`
+			HasMore   bool   `json:"has_more"`
+		} `json:"data"`
+	}
+
+	chatsResponse struct {
+		APIResponse
+		Data struct {
+			Items []struct {
+				Avatar      string `json:"avatar"`
+				ChatId      string `json:"chat_id"`
+				Description string `json:"description"`
+				Name        string `json:"name"`
+				OwnerId     string `json:"owner_id"`
+			} `json:"items"`
+			PageToken string `json:"page_token"`
+			HasMore   bool   `json:"has_more"`
+		} `json:"data"`
+	}
+)
+
+// endpoints maps endpoint names to their implementations in each version.
+var endpoints = map[string]map[string]endpointInfo{
+	endpointSendMessage: {
+		V4: {endpointSendMessage, V4, "POST", "/message/v4/send/"},
+		V1: {endpointSendMessage, V1, "POST", "/im/v1/messages"},
+	},
+	endpointGetChat: {
+		V4: {endpointGetChat, V4, "POST", "/chat/v4/info/"},
+		V1: {endpointGetChat, V1, "GET", "/im/v1/chats/"},
+	},
+	endpointListChats: {
+		V4: {endpointListChats, V4, "POST", "/chat/v4/list/"},
+		V1: {endpointListChats, V1, "GET", "/im/v1/chats"},
+	},
+}
+
+// endpoint returns the endpoint of the version configured for name.
+func (api *API) endpoint(name string) endpointInfo {
+	version := api.EndpointVersions[name]
+	if version == "" {
+		version = api.Version
+	}
+	if endpoint, ok := endpoints[name][version]; ok {
+		return endpoint
+	}
+	return endpoints[name][V4]
+}
+
+// SendMessageV1 sends a message of any type with the im/v1 API regardless of
+// Version, see https://open.feishu.cn/document/server-docs/im-v1/message/create.
+// Target is an open_id, union_id, user_id, email or chat_id, its
+// receive_id_type is detected from its format. Content is the message
+// content (for example TextContent or Card), it is encoded to a JSON string
+// as required by im/v1.
+func (api *API) SendMessageV1(target, msgType string, content interface{}, opts ...SendOption) (messageId string, err error) {
+	opts = append(opts, func(o *sendOptions) { o.version = V1 })
+	return api.send(target, msgType, content, opts...)
+}
+
+// ReplyMessage replies to the message with the im/v1 API and returns the id
+// of the reply. The reply quotes the message in the chat, pass
+// InThread(messageId) to reply in the thread of the message instead.
+func (api *API) ReplyMessage(messageId, msgType string, content interface{}, opts ...SendOption) (replyId string, err error) {
+	opts = append([]SendOption{ReplyTo(messageId)}, opts...)
+	opts = append(opts, func(o *sendOptions) { o.version = V1 })
+	return api.send("", msgType, content, opts...)
+}
+
+func (api *API) sendV4(endpoint endpointInfo, target, rootId, msgType string, content interface{}) (messageId string, err error) {
+	a, b, c, d := parseTarget(target)
+	var card interface{}
+	if msgType == "interactive" {
+		card, content = content, nil
+	}
+	var data MessageResponse
+	err = api.NewRequest(
+		// method
+		endpoint.Method,
+
+		// path
+		endpoint.Path,
+
+		// request body
+		struct {
+			OpenId  *string     `json:"open_id,omitempty"`
+			ChatId  *string     `json:"chat_id,omitempty"`
+			Email   *string     `json:"email,omitempty"`
+			UserId  *string     `json:"user_id,omitempty"`
+			RootId  string      `json:"root_id,omitempty"`
+			MsgType string      `json:"msg_type"`
+			Content interface{} `json:"content,omitempty"`
+			Card    interface{} `json:"card,omitempty"`
+		}{a, b, c, d, rootId, msgType, content, card},
+
+		// response
+		&data,
+	)
+	messageId = data.Data.MessageId
+	return
+}
+
+// sendV1 sends the message, or replies to the message rootId if it is not
+// empty.
+func (api *API) sendV1(endpoint endpointInfo, target, rootId string, inThread bool, msgType string, content interface{}) (messageId, threadId, chatId string, err error) {
+	// im/v1 posts are not wrapped in {"post": ...}
+	if v, ok := content.(PostContent); ok {
+		content = v.Post
+	}
+	var contentJSON []byte
+	contentJSON, err = json.Marshal(content)
+	if err != nil {
+		return
+	}
+	path := endpoint.Path + "?receive_id_type=" + receiveIdType(target)
+	receiveId := target
+	if rootId != "" {
+		path = endpoint.Path + "/" + rootId + "/reply"
+		receiveId = ""
+	}
+	// with a uuid, the server sends the message only once, so it can be
+	// retried after a timeout
+	var uuid string
+	if api.MaxRetries > 0 {
+		uuid = newUuid()
+		api = api.idempotent()
+	}
+	var data MessageResponse
+	err = api.NewRequest(
+		// method
+		endpoint.Method,
+
+		// path
+		path,
+
+		// request body
+		struct {
+			ReceiveId     string `json:"receive_id,omitempty"`
+			MsgType       string `json:"msg_type"`
+			Content       string `json:"content"`
+			ReplyInThread bool   `json:"reply_in_thread,omitempty"`
+			Uuid          string `json:"uuid,omitempty"`
+		}{receiveId, msgType, string(contentJSON), rootId != "" && inThread, uuid},
+
+		// response
+		&data,
+	)
+	messageId, threadId, chatId = data.Data.MessageId, data.Data.ThreadId, data.Data.ChatId
+	return
+}
+
+func (api *API) getChatInfoV1(endpoint endpointInfo, chatId string) (group Group, err error) {
+	var data ChatInfoResponse
+	err = api.NewRequest(
+		// method
+		endpoint.Method,
+
+		// path
+		endpoint.Path+chatId+"?user_id_type=open_id",
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	group = Group{
+		Avatar:      data.Data.Avatar,
+		ChatId:      chatId,
+		Description: data.Data.Description,
+		Name:        data.Data.Name,
+		OwnerOpenId: data.Data.OwnerId,
+	}
+	var pageToken string
+	for {
+		var members chatMembersResponse
+		err = api.NewRequest(
+			// method
+			"GET",
+
+			// path
+			endpoint.Path+chatId+"/members?member_id_type=open_id&page_size=100&page_token="+url.QueryEscape(pageToken),
+
+			// request body
+			nil,
+
+			// response
+			&members,
+		)
+		if err != nil {
+			return
+		}
+		for _, item := range members.Data.Items {
+			group.Members = append(group.Members, struct {
+				OpenId string `json:"open_id"`
+			}{item.MemberId})
+		}
+		if !members.Data.HasMore {
+			return
+		}
+		pageToken = members.Data.PageToken
+	}
+}
+
+func (api *API) listChatsPageV1(endpoint endpointInfo, pageToken string) (groups Groups, nextPageToken string, err error) {
+	var data chatsResponse
+	err = api.NewRequest(
+		// method
+		endpoint.Method,
+
+		// path
+		endpoint.Path+"?user_id_type=open_id&page_size=100&page_token="+url.QueryEscape(pageToken),
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	for _, item := range data.Data.Items {
+		groups = append(groups, Group{
+			Avatar:      item.Avatar,
+			ChatId:      item.ChatId,
+			Description: item.Description,
+			Name:        item.Name,
+			OwnerOpenId: item.OwnerId,
+		})
+	}
+	if data.Data.HasMore {
+		nextPageToken = data.Data.PageToken
+	}
+	return
+}
+
+// userIdType returns user_id_type of contact APIs for the user id.
+func userIdType(userId string) string {
+	switch {
+	case strings.HasPrefix(userId, "ou_"):
+		return "open_id"
+	case strings.HasPrefix(userId, "on_"):
+		return "union_id"
+	}
+	return "user_id"
+}
+
+// receiveIdType returns receive_id_type of im/v1 APIs for the target.
+func receiveIdType(target string) string {
+	switch {
+	case strings.HasPrefix(target, "ou_"):
+		return "open_id"
+	case strings.HasPrefix(target, "oc_"):
+		return "chat_id"
+	case strings.HasPrefix(target, "on_"):
+		return "union_id"
+	case isEmail(target):
+		return "email"
+	}
+	return "user_id"
+}
@@ -0,0 +1,167 @@
+package larkslim
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	CategoryUnknown         Category = ""
+	CategoryAuth            Category = "auth"
+	CategoryPermission      Category = "permission"
+	CategoryRateLimit       Category = "rate_limit"
+	CategoryInvalidArgument Category = "invalid_argument"
+	CategoryNotFound        Category = "not_found"
+	CategoryServer          Category = "server"
+)
+
+type (
+	// Error is returned when the open platform responds with a non-zero
+	// code or an unexpected HTTP status.
+	Error struct {
+		// HTTP status code
+		Status int
+
+		Code int
+		Msg  string
+
+		// for permission errors, scopes mentioned in Msg and the developer
+		// console page where they are granted
+		Scopes     []string
+		ConsoleURL string
+
+		// value of the Retry-After header, if any
+		RetryAfter time.Duration
+	}
+
+	// Category groups errors by how callers usually handle them, for
+	// example CategoryRateLimit and CategoryServer are worth retrying while
+	// CategoryPermission needs human attention.
+	Category string
+)
+
+// errorCodes maps known error codes of the open platform to categories.
+var errorCodes = map[int]Category{
+	// tenant access token
+	10003:    CategoryAuth, // invalid app_id or app_secret
+	10014:    CategoryAuth, // app secret invalid
+	99991661: CategoryAuth, // missing access token
+	99991663: CategoryAuth, // invalid tenant access token
+	99991664: CategoryAuth, // invalid app access token
+	99991665: CategoryAuth, // invalid user access token
+	99991668: CategoryAuth, // invalid access token
+	99991671: CategoryAuth, // bad access token format
+
+	// permissions
+	99991401: CategoryPermission, // IP not allowed
+	99991672: CategoryPermission, // missing scope
+	99991679: CategoryPermission, // missing user scope
+	230002:   CategoryPermission, // bot is not in the chat
+	230006:   CategoryPermission, // bot ability is not enabled
+	230013:   CategoryPermission, // bot has no availability to the user
+	230027:   CategoryPermission, // lack of necessary permissions
+	41050:    CategoryPermission, // no user authority
+
+	// rate limits
+	99991400: CategoryRateLimit, // request trigger frequency limit
+	230020:   CategoryRateLimit, // message frequency limit
+	11232:    CategoryRateLimit, // v4 message frequency limit
+	1061045:  CategoryRateLimit, // drive frequency limit
+
+	// invalid arguments
+	10002:  CategoryInvalidArgument, // bad request
+	230001: CategoryInvalidArgument, // invalid request parameters
+	230025: CategoryInvalidArgument, // message content too long
+	230099: CategoryInvalidArgument, // failed to create card content
+
+	// not found
+	230011:  CategoryNotFound, // message has been recalled
+	230026:  CategoryNotFound, // message not found
+	232010:  CategoryNotFound, // chat not found
+	1061003: CategoryNotFound, // file not found
+
+	// server errors
+	99991000: CategoryServer, // internal error
+	99991001: CategoryServer, // internal error
+	1000004:  CategoryServer, // internal error
+}
+
+// scopePattern matches scope names like "im:message" or
+// "contact:user.base:readonly".
+var scopePattern = regexp.MustCompile(`\b[a-z_]+(?::[a-z_.]+)+\b`)
+
+// newError returns the Error of a response, with guidance to fix missing
+// permissions.
+func (api *API) newError(status, code int, msg string) *Error {
+	e := &Error{
+		Status: status,
+		Code:   code,
+		Msg:    msg,
+	}
+	switch code {
+	case 99991672, 99991679:
+		e.Scopes = scopePattern.FindAllString(msg, -1)
+		e.ConsoleURL = api.consoleURL("/auth")
+	case 99991401:
+		e.ConsoleURL = api.consoleURL("/safe")
+	}
+	return e
+}
+
+// consoleURL returns the URL of the page of the application in the developer
+// console.
+func (api *API) consoleURL(page string) string {
+	host := "https://open.feishu.cn"
+	if strings.Contains(api.baseURL(), "larksuite.com") {
+		host = "https://open.larksuite.com"
+	}
+	return host + "/app/" + api.AppId + page
+}
+
+func (e *Error) Error() string {
+	if e.Code == 0 && e.Msg == "" {
+		return fmt.Sprintf("unexpected HTTP status %d", e.Status)
+	}
+	msg := fmt.Sprintf("not ok or success returned: %s (code %d)", e.Msg, e.Code)
+	if len(e.Scopes) > 0 {
+		msg += "; required scopes: " + strings.Join(e.Scopes, ", ")
+	}
+	if e.ConsoleURL != "" {
+		msg += "; grant them at " + e.ConsoleURL
+	}
+	return msg
+}
+
+// Classify returns the category of the error, CategoryUnknown if the error
+// is not from the open platform or the error code is not known.
+func Classify(err error) Category {
+	var missingScopes *MissingScopesError
+	if errors.As(err, &missingScopes) {
+		return CategoryPermission
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return CategoryUnknown
+	}
+	if category, ok := errorCodes[e.Code]; ok {
+		return category
+	}
+	switch {
+	case e.Status == 401:
+		return CategoryAuth
+	case e.Status == 403:
+		return CategoryPermission
+	case e.Status == 404:
+		return CategoryNotFound
+	case e.Status == 429:
+		return CategoryRateLimit
+	case e.Status == 400:
+		return CategoryInvalidArgument
+	case e.Status >= 500:
+		return CategoryServer
+	}
+	return CategoryUnknown
+}
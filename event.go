@@ -0,0 +1,190 @@
+package larkslim
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+type (
+	// EventUserId is the ids of a user in events of schema 2.0.
+	EventUserId struct {
+		OpenId  string `json:"open_id"`
+		UserId  string `json:"user_id"`
+		UnionId string `json:"union_id"`
+	}
+
+	// MessageReceiveEvent is the event of the im.message.receive_v1 event
+	// of schema 2.0.
+	MessageReceiveEvent struct {
+		Sender struct {
+			SenderId   EventUserId `json:"sender_id"`
+			SenderType string      `json:"sender_type"`
+			TenantKey  string      `json:"tenant_key"`
+		} `json:"sender"`
+		Message struct {
+			MessageId   string    `json:"message_id"`
+			RootId      string    `json:"root_id"`
+			ParentId    string    `json:"parent_id"`
+			ThreadId    string    `json:"thread_id"`
+			CreateTime  string    `json:"create_time"`
+			ChatId      string    `json:"chat_id"`
+			ChatType    string    `json:"chat_type"`
+			MessageType string    `json:"message_type"`
+			Content     string    `json:"content"`
+			Mentions    []Mention `json:"mentions"`
+		} `json:"message"`
+	}
+
+	eventV2 struct {
+		Schema string          `json:"schema"`
+		Header EventHeader     `json:"header"`
+		Event  json.RawMessage `json:"event"`
+	}
+)
+
+// MessageReceiveEventType is the event type of received messages in schema
+// 2.0.
+const MessageReceiveEventType = "im.message.receive_v1"
+
+// ParseEvent parses the decrypted body of an event callback in schema 1.0 or
+// 2.0. Events of schema 2.0 are converted to EventResponse: Token is taken
+// from the header, Event.Type is the event type in the header, and received
+// messages are converted to the "message" event of schema 1.0, so that they
+// can be handled the same way. Since the open_id of the bot is not known, a
+// mention placeholder (@_user_1) at the start of the text is taken as the
+// bot and removed from TextWithoutAtBot, and IsMention is true if anyone is
+// mentioned, see ParseEventForBot. If strict is
+// true, schema 1.0 events with unknown fields are rejected.
+func ParseEvent(body []byte, strict bool) (event EventResponse, err error) {
+	return ParseEventForBot(body, strict, "")
+}
+
+// ParseEventForBot is like ParseEvent, but only mentions of the bot with
+// the open_id are removed from TextWithoutAtBot. Mentions of other users
+// are replaced with "@" and their names, so that commands like
+// "/assign @alice" keep their arguments, and IsMention is only true if the
+// bot is mentioned. See GetBotInfo for the open_id.
+func ParseEventForBot(body []byte, strict bool, botOpenId string) (event EventResponse, err error) {
+	var v2 eventV2
+	if err = json.Unmarshal(body, &v2); err != nil {
+		return
+	}
+	if v2.Schema != "2.0" {
+		if strict {
+			err = UnmarshalStrict(body, &event)
+		} else {
+			err = json.Unmarshal(body, &event)
+		}
+		return
+	}
+	event.Schema = v2.Schema
+	event.Header = v2.Header
+	event.Type = "event_callback"
+	event.Uuid = v2.Header.EventId
+	event.Ts = v2.Header.CreateTime
+	event.Token = v2.Header.Token
+	e := &event.Event
+	e.Type = v2.Header.EventType
+	e.AppId = v2.Header.AppId
+	e.TenantKey = v2.Header.TenantKey
+	if v2.Header.EventType != MessageReceiveEventType {
+		return
+	}
+	var m MessageReceiveEvent
+	if err = json.Unmarshal(v2.Event, &m); err != nil {
+		return
+	}
+	e.Type = "message"
+	e.ChatId = m.Message.ChatId
+	e.ChatType = m.Message.ChatType
+	if e.ChatType == "p2p" {
+		e.ChatType = "private"
+	}
+	e.MsgType = m.Message.MessageType
+	e.MessageId = m.Message.MessageId
+	e.RootId = m.Message.RootId
+	e.ParentId = m.Message.ParentId
+	e.ThreadId = m.Message.ThreadId
+	if botOpenId != "" {
+		e.IsMention = IsMentioned(m.Message.Mentions, botOpenId)
+	} else {
+		e.IsMention = len(m.Message.Mentions) > 0
+	}
+	e.Mentions = m.Message.Mentions
+	e.OpenId = m.Sender.SenderId.OpenId
+	e.UserOpenId = m.Sender.SenderId.OpenId
+	e.EmployeeId = m.Sender.SenderId.UserId
+	e.UnionId = m.Sender.SenderId.UnionId
+	e.SenderType = m.Sender.SenderType
+	e.Content = m.Message.Content
+	if e.MsgType == "text" {
+		var content TextContent
+		if json.Unmarshal([]byte(m.Message.Content), &content) == nil {
+			e.Text = content.Text
+			e.TextWithoutAtBot = stripBotMention(content.Text, m.Message.Mentions, botOpenId)
+		}
+	}
+	return
+}
+
+// stripBotMention removes mentions of the bot from text. If botOpenId is
+// empty, the mention at the start of text is taken as the bot.
+func stripBotMention(text string, mentions []Mention, botOpenId string) string {
+	if botOpenId == "" {
+		trimmed := strings.TrimLeft(text, " \t")
+		for _, m := range byKeyLength(mentions) {
+			if m.Key != "" && strings.HasPrefix(trimmed, m.Key) {
+				botOpenId = m.Id.OpenId
+				break
+			}
+		}
+	}
+	return StripMention(text, mentions, botOpenId)
+}
+
+// ParseEventContent returns the content of the message event as
+// TextContent, PostContent, ImageContent, FileContent or AudioContent
+// according to its msg_type. Posts have a single locale with an empty key,
+// since received posts are not localized.
+func ParseEventContent(event EventResponse) (content interface{}, err error) {
+	e := event.Event
+	raw := []byte(e.Content)
+	switch e.MsgType {
+	case "text":
+		v := TextContent{Text: e.Text}
+		if len(raw) > 0 {
+			err = json.Unmarshal(raw, &v)
+		}
+		content = v
+	case "image":
+		v := ImageContent{ImageKey: e.ImageKey}
+		if len(raw) > 0 {
+			err = json.Unmarshal(raw, &v)
+		}
+		content = v
+	case "file":
+		v := FileContent{FileKey: e.FileKey}
+		if len(raw) > 0 {
+			err = json.Unmarshal(raw, &v)
+		}
+		content = v
+	case "audio":
+		v := AudioContent{FileKey: e.FileKey}
+		if len(raw) > 0 {
+			err = json.Unmarshal(raw, &v)
+		}
+		content = v
+	case "post":
+		post := PostOfLocale{Title: e.Title}
+		if len(raw) > 0 {
+			err = json.Unmarshal(raw, &post)
+		} else if e.Text != "" {
+			post.Content = PostLines{{{Tag: "text", Text: e.Text}}}
+		}
+		content = PostContent{Post{"": post}}
+	default:
+		err = errors.New("unsupported message type: " + e.MsgType)
+	}
+	return
+}
@@ -0,0 +1,108 @@
+package larkslim
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maximum sizes of message content, larger messages are rejected
+const (
+	maxTextSize = 150 * 1024
+	maxCardSize = 30 * 1024
+)
+
+// oversized reports whether the content is too large to be sent as a
+// message of the type.
+func oversized(msgType string, content interface{}) bool {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return false
+	}
+	switch msgType {
+	case "text":
+		return len(data) > maxTextSize
+	case "post", "interactive":
+		return len(data) > maxCardSize
+	}
+	return false
+}
+
+// sendFileFallback uploads the text representation of the content as a file
+// and sends a pointer message followed by the file message.
+func (api *API) sendFileFallback(target, msgType string, content interface{}, fileName string, opts []SendOption) (messageId string, err error) {
+	text := contentText(content)
+	var key string
+	key, err = api.UploadFile(strings.NewReader(text), "stream", fileName)
+	if err != nil {
+		return
+	}
+	opts = append(opts, FileFallback(""))
+	summary := text
+	if i := strings.IndexByte(summary, '\n'); i > -1 {
+		summary = summary[:i]
+	}
+	if utf8.RuneCountInString(summary) > 100 {
+		summary = string([]rune(summary)[:100]) + "…"
+	}
+	_, err = api.send(target, "text", TextContent{summary + "\n(full content in " + fileName + ")"}, opts...)
+	if err != nil {
+		return
+	}
+	return api.send(target, "file", FileContent{key}, opts...)
+}
+
+// contentText returns the text of a text, post or card message content.
+func contentText(content interface{}) string {
+	switch v := content.(type) {
+	case Card:
+		return v.Text()
+	case TextContent:
+		return v.Text
+	case PostContent:
+		return v.Post.Text()
+	}
+	data, _ := json.Marshal(content)
+	return string(data)
+}
+
+// Text renders the post as plain text. If there are multiple locales, only
+// one of them is rendered, zh_cn is preferred.
+func (post Post) Text() string {
+	locale, ok := post["zh_cn"]
+	if !ok {
+		var keys []string
+		for key := range post {
+			keys = append(keys, key)
+		}
+		if len(keys) > 0 {
+			sort.Strings(keys)
+			locale = post[keys[0]]
+		}
+	}
+	var b strings.Builder
+	if locale.Title != "" {
+		b.WriteString(locale.Title)
+		b.WriteString("\n")
+	}
+	for _, line := range locale.Content {
+		for _, tag := range line {
+			switch tag.Tag {
+			case "at":
+				b.WriteString("@" + tag.UserId)
+			case "img":
+				b.WriteString("[image]")
+			case "a":
+				b.WriteString(tag.Text)
+				if tag.Href != tag.Text {
+					b.WriteString(" (" + tag.Href + ")")
+				}
+			default:
+				b.WriteString(tag.Text)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,97 @@
+package larkslim
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// hedgePaths are read-only endpoints that use POST.
+var hedgePaths = []string{
+	"/chat/v4/info/",
+	"/chat/v4/list/",
+}
+
+// resourcePaths are prefixes and parts of paths of downloads, which are too
+// large to be sent twice.
+var resourcePaths = []string{
+	"/im/v1/images/",
+	"/im/v1/files/",
+	"/resources/",
+}
+
+// hedgeable returns true if req is a read-only request of small JSON
+// metadata and can be hedged.
+func (api *API) hedgeable(req *http.Request) bool {
+	if api.HedgeDelay <= 0 {
+		return false
+	}
+	for _, path := range resourcePaths {
+		if strings.Contains(req.URL.Path, path) {
+			return false
+		}
+	}
+	if req.Method == "GET" {
+		return true
+	}
+	for _, path := range hedgePaths {
+		if strings.HasSuffix(req.URL.Path, path) {
+			return req.GetBody != nil
+		}
+	}
+	return false
+}
+
+// doHedged sends req, and sends it again if it has not completed after
+// HedgeDelay. The first successful response is used and the other request
+// is canceled.
+func (api *API) doHedged(req *http.Request, respData interface{}) (err error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	type result struct {
+		data interface{}
+		err  error
+	}
+	results := make(chan result, 2)
+	attempt := func(r *http.Request) {
+		// each attempt decodes into its own value
+		var data interface{}
+		if respData != nil {
+			data = reflect.New(reflect.TypeOf(respData).Elem()).Interface()
+		}
+		results <- result{data, api.doOnce(r, data)}
+	}
+	go attempt(req.WithContext(ctx))
+	timer := time.NewTimer(api.HedgeDelay)
+	defer timer.Stop()
+	pending := 1
+	for {
+		select {
+		case <-timer.C:
+			hedge := req.Clone(ctx)
+			if req.GetBody != nil {
+				if hedge.Body, err = req.GetBody(); err != nil {
+					err = nil
+					continue
+				}
+			}
+			if api.Debugger != nil {
+				api.Debugger("hedging", req.URL.String())
+			}
+			pending++
+			go attempt(hedge)
+		case res := <-results:
+			pending--
+			err = res.err
+			if err != nil && pending > 0 {
+				continue
+			}
+			if err == nil && respData != nil {
+				reflect.ValueOf(respData).Elem().Set(reflect.ValueOf(res.data).Elem())
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package larkslim
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type (
+	// UnsupportedImageTypeError is returned when uploading an image of a
+	// type not in API.AllowedImageTypes.
+	UnsupportedImageTypeError struct {
+		Type    string
+		Allowed []string
+	}
+)
+
+// DefaultImageTypes are MIME types of images supported by Lark, used if
+// API.AllowedImageTypes is empty.
+var DefaultImageTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"image/gif",
+	"image/tiff",
+	"image/bmp",
+	"image/x-icon",
+}
+
+var imageExtensions = map[string]string{
+	"image/jpeg":   ".jpg",
+	"image/png":    ".png",
+	"image/webp":   ".webp",
+	"image/gif":    ".gif",
+	"image/tiff":   ".tiff",
+	"image/bmp":    ".bmp",
+	"image/x-icon": ".ico",
+}
+
+// quoteEscaper escapes file names in Content-Disposition like mime/multipart.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func (e *UnsupportedImageTypeError) Error() string {
+	return fmt.Sprintf("unsupported image type %s, supported types: %s", e.Type, strings.Join(e.Allowed, ", "))
+}
+
+// sniffImage detects the MIME type of the image from its first bytes and
+// returns an error if the type is not allowed. The returned reader reads the
+// whole image.
+func (api *API) sniffImage(file io.Reader) (r io.Reader, mimeType string, err error) {
+	head := make([]byte, 512)
+	var n int
+	n, err = io.ReadFull(file, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+	head = head[:n]
+	r = io.MultiReader(bytes.NewReader(head), file)
+	if bytes.HasPrefix(head, []byte("II*\x00")) || bytes.HasPrefix(head, []byte("MM\x00*")) {
+		// not detected by http.DetectContentType
+		mimeType = "image/tiff"
+	} else {
+		mimeType = http.DetectContentType(head)
+	}
+	allowed := api.AllowedImageTypes
+	if len(allowed) == 0 {
+		allowed = DefaultImageTypes
+	}
+	for _, t := range allowed {
+		if t == mimeType {
+			return
+		}
+	}
+	err = &UnsupportedImageTypeError{mimeType, allowed}
+	return
+}
+
+// imageFileName returns name with the extension of the MIME type if it has
+// no extension.
+func imageFileName(name, mimeType string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return name + imageExtensions[mimeType]
+}
@@ -0,0 +1,116 @@
+// Package larkarchive archives inbound events and outbound messages to object
+// storage such as S3 or GCS for retention compliance.
+//
+// Archiver is a larkslim.TranscriptStore, use it as the Transcript of both
+// the API and the larkbot.Server:
+//
+//	archiver := &larkarchive.Archiver{Store: s3Store, Prefix: "lark/"}
+//	defer archiver.Close()
+//	api.Transcript = archiver
+//	server.Transcript = archiver
+package larkarchive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Store creates objects. Data written to the returned writer must be
+	// stored as the object named key when the writer is closed, for example
+	// with a S3 multipart upload.
+	Store interface {
+		Create(key string) (io.WriteCloser, error)
+	}
+
+	// Archiver writes transcript entries as newline delimited JSON to
+	// objects partitioned by day, named like
+	// "<Prefix>2006/01/02/<host>-150405.000000.ndjson". Objects are
+	// completed when the day changes, after RotateInterval and on Close.
+	Archiver struct {
+		Store Store
+
+		// prefix of object keys, for example "lark/"
+		Prefix string
+
+		// time zone of partitions, defaults to UTC
+		Location *time.Location
+
+		// maximum time an object stays open, defaults to 1 hour
+		RotateInterval time.Duration
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		mutex    sync.Mutex
+		w        io.WriteCloser
+		day      string
+		openedAt time.Time
+	}
+)
+
+// Append writes the entry to the object of the current day.
+func (a *Archiver) Append(entry larkslim.TranscriptEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	now := a.clock().Now()
+	loc := a.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	day := now.In(loc).Format("2006/01/02")
+	interval := a.RotateInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.w != nil && (day != a.day || now.Sub(a.openedAt) >= interval) {
+		if err := a.close(); err != nil {
+			return err
+		}
+	}
+	if a.w == nil {
+		host, _ := os.Hostname()
+		key := fmt.Sprintf("%s%s/%s-%s.ndjson", a.Prefix, day, host, now.In(loc).Format("150405.000000"))
+		w, err := a.Store.Create(key)
+		if err != nil {
+			return err
+		}
+		a.w, a.day, a.openedAt = w, day, now
+	}
+	_, err = a.w.Write(data)
+	return err
+}
+
+// Close completes the current object.
+func (a *Archiver) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.close()
+}
+
+func (a *Archiver) close() error {
+	if a.w == nil {
+		return nil
+	}
+	err := a.w.Close()
+	a.w = nil
+	return err
+}
+
+func (a *Archiver) clock() larkslim.Clock {
+	if a.Clock != nil {
+		return a.Clock
+	}
+	return larkslim.SystemClock
+}
@@ -0,0 +1,57 @@
+package larkarchive
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+type (
+	memoryStore map[string]*object
+
+	object struct {
+		bytes.Buffer
+		closed bool
+	}
+)
+
+func (s memoryStore) Create(key string) (io.WriteCloser, error) {
+	o := &object{}
+	s[key] = o
+	return o, nil
+}
+
+func (o *object) Close() error {
+	o.closed = true
+	return nil
+}
+
+func TestArchiver(t *testing.T) {
+	store := memoryStore{}
+	clock := larktest.NewFakeClock(time.Date(2020, 1, 1, 23, 30, 0, 0, time.UTC))
+	a := &Archiver{Store: store, Prefix: "lark/", Clock: clock}
+	a.Append(larkslim.TranscriptEntry{Direction: larkslim.Outbound, Chat: "oc_a"})
+	a.Append(larkslim.TranscriptEntry{Direction: larkslim.Inbound, Chat: "oc_a"})
+	clock.Advance(time.Hour)
+	a.Append(larkslim.TranscriptEntry{Direction: larkslim.Outbound, Chat: "oc_b"})
+	a.Close()
+	var days []string
+	for key, o := range store {
+		if !o.closed {
+			t.Errorf("%s should be closed", key)
+		}
+		days = append(days, key[:len("lark/2020/01/01")])
+		lines := strings.Count(o.String(), "\n")
+		if strings.HasPrefix(key, "lark/2020/01/01") && lines != 2 || strings.HasPrefix(key, "lark/2020/01/02") && lines != 1 {
+			t.Errorf("%s has wrong number of entries: %d", key, lines)
+		}
+	}
+	if len(days) != 2 {
+		t.Errorf("should have 2 objects, got %v", days)
+	}
+}
@@ -0,0 +1,180 @@
+package larkbot
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// IPAllowlist rejects callback requests from addresses outside the
+	// IP ranges of the open platform, as defense in depth alongside the
+	// verification token and signature checks.
+	IPAllowlist struct {
+		// CIDRs (or single IPs) of the open platform, for example
+		// "1.2.3.0/24"
+		CIDRs []string
+
+		// if set, called every RefreshInterval by Run to replace CIDRs,
+		// for example to download the latest published ranges; an empty
+		// list is rejected and the current CIDRs are kept
+		Source func() ([]string, error)
+
+		// defaults to 1 hour
+		RefreshInterval time.Duration
+
+		// use the last address of X-Forwarded-For, set by the reverse
+		// proxy in front of the server, instead of the remote address
+		TrustForwardedFor bool
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+
+		once  sync.Once
+		mutex sync.RWMutex
+		nets  []*net.IPNet
+	}
+)
+
+// Allow reports whether the request comes from an allowed address.
+func (l *IPAllowlist) Allow(r *http.Request) bool {
+	l.once.Do(func() {
+		if err := l.set(l.CIDRs); err != nil {
+			l.error(err)
+		}
+	})
+	ip := net.ParseIP(l.remoteIP(r))
+	if ip == nil {
+		return false
+	}
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	for _, n := range l.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrEmptyCIDRs is returned by Refresh if Source returns no CIDRs, which
+// would otherwise reject all requests.
+var ErrEmptyCIDRs = errors.New("IPAllowlist: Source returned no CIDRs")
+
+// Refresh replaces the CIDRs with the ones returned by Source.
+func (l *IPAllowlist) Refresh() error {
+	if l.Source == nil {
+		return nil
+	}
+	cidrs, err := l.Source()
+	if err != nil {
+		return err
+	}
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return err
+	}
+	if len(nets) == 0 {
+		return ErrEmptyCIDRs
+	}
+	l.once.Do(func() {})
+	l.mutex.Lock()
+	l.nets = nets
+	l.mutex.Unlock()
+	return nil
+}
+
+// Run refreshes the CIDRs every RefreshInterval until stop is closed.
+func (l *IPAllowlist) Run(stop <-chan struct{}) {
+	interval := l.RefreshInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := l.Refresh(); err != nil {
+			l.error(err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// set parses cidrs, the current list is kept if any of them is invalid.
+func (l *IPAllowlist) set(cidrs []string) error {
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return err
+	}
+	l.mutex.Lock()
+	l.nets = nets
+	l.mutex.Unlock()
+	return nil
+}
+
+// parseCIDRs parses CIDRs or single IPs, blank ones are skipped.
+func parseCIDRs(cidrs []string) (nets []*net.IPNet, err error) {
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		var n *net.IPNet
+		_, n, err = net.ParseCIDR(cidr)
+		if err != nil {
+			return
+		}
+		nets = append(nets, n)
+	}
+	return
+}
+
+func (l *IPAllowlist) remoteIP(r *http.Request) string {
+	if l.TrustForwardedFor {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			parts := strings.Split(xff, ",")
+			return strings.TrimSpace(parts[len(parts)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (l *IPAllowlist) error(args ...interface{}) {
+	if l.Logger != nil {
+		l.Logger.Error(args...)
+	}
+}
+
+// allowSource wraps a callback handler to reject requests not allowed by
+// IPAllowlist.
+func (h *Server) allowSource(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.IPAllowlist != nil && !h.IPAllowlist.Allow(r) {
+			if h.Logger != nil {
+				h.Logger.Error("request from disallowed address", r.RemoteAddr)
+			}
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
@@ -0,0 +1,51 @@
+package larkbot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPAllowlist(t *testing.T) {
+	h := &Server{
+		IPAllowlist: &IPAllowlist{
+			CIDRs: []string{"10.0.0.0/8", "192.168.1.1"},
+			Source: func() ([]string, error) {
+				return []string{"172.16.0.0/12"}, nil
+			},
+		},
+	}
+	handler := h.allowSource(func(w http.ResponseWriter, r *http.Request) {})
+	code := func(remoteAddr string) int {
+		r := httptest.NewRequest("POST", "/events/", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+	for addr, want := range map[string]int{
+		"10.1.2.3:1234":    http.StatusOK,
+		"192.168.1.1:1234": http.StatusOK,
+		"192.168.1.2:1234": http.StatusForbidden,
+		"172.16.0.1:1234":  http.StatusForbidden,
+	} {
+		if got := code(addr); got != want {
+			t.Errorf("%s: got %d, want %d", addr, got, want)
+		}
+	}
+	if err := h.IPAllowlist.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if code("172.16.0.1:1234") != http.StatusOK || code("10.1.2.3:1234") != http.StatusForbidden {
+		t.Error("CIDRs should be replaced after refresh")
+	}
+	h.IPAllowlist.Source = func() ([]string, error) {
+		return []string{" "}, nil
+	}
+	if err := h.IPAllowlist.Refresh(); err != ErrEmptyCIDRs {
+		t.Errorf("empty list should be rejected, got %v", err)
+	}
+	if code("172.16.0.1:1234") != http.StatusOK {
+		t.Error("CIDRs should be kept after empty refresh")
+	}
+}
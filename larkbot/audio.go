@@ -0,0 +1,71 @@
+package larkbot
+
+import (
+	"io"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Transcriber converts speech of received audio messages to text. The
+	// audio is in the opus format.
+	Transcriber func(audio io.Reader, event larkslim.EventResponse) (transcript string, err error)
+
+	// AudioMessage is a received audio message with its transcript.
+	AudioMessage struct {
+		Event    larkslim.EventResponse
+		FileKey  string
+		Duration time.Duration
+
+		// returned by the transcriber
+		Transcript string
+
+		// error of downloading or transcribing the audio
+		Err error
+	}
+)
+
+// OnAudioReceived registers a handler of audio messages. Each audio is
+// downloaded with api (which requires the im:resource scope) and passed to
+// the transcriber before the handler is called.
+func (d *Dispatcher) OnAudioReceived(api *larkslim.API, transcriber Transcriber, handler func(AudioMessage)) {
+	d.OnMessageReceived(func(event larkslim.EventResponse) {
+		if event.Event.MsgType != "audio" {
+			return
+		}
+		m := AudioMessage{Event: event}
+		content, err := larkslim.ParseEventContent(event)
+		if err != nil {
+			m.Err = err
+			handler(m)
+			return
+		}
+		audio := content.(larkslim.AudioContent)
+		m.FileKey = audio.FileKey
+		m.Duration = time.Duration(audio.Duration) * time.Millisecond
+		m.Transcript, m.Err = transcribeAudio(api, transcriber, event, m.FileKey)
+		handler(m)
+	})
+}
+
+// TextEvent returns a copy of the event as a text message of the
+// transcript, which can be passed to Router.HandleEvent so voice commands
+// work like typed ones.
+func (m AudioMessage) TextEvent() larkslim.EventResponse {
+	event := m.Event
+	event.Event.MsgType = "text"
+	event.Event.Text = m.Transcript
+	event.Event.TextWithoutAtBot = m.Transcript
+	event.Event.Content = ""
+	return event
+}
+
+func transcribeAudio(api *larkslim.API, transcriber Transcriber, event larkslim.EventResponse, fileKey string) (string, error) {
+	audio, err := api.GetMessageResource(event.Event.MessageId, fileKey, "file")
+	if err != nil {
+		return "", err
+	}
+	defer audio.Close()
+	return transcriber(audio, event)
+}
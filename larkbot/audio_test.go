@@ -0,0 +1,63 @@
+package larkbot
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+func TestOnAudioReceived(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v3/tenant_access_token/internal":
+			io.WriteString(w, `{"code":0,"msg":"ok","tenant_access_token":"t-test","expire":7200}`)
+		case "/im/v1/messages/om_1/resources/file_1":
+			if r.URL.Query().Get("type") != "file" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "audio/opus")
+			io.WriteString(w, "/deploy api")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := larkslim.NewAPI("cli_test", "secret")
+	api.BaseURL = server.URL
+	stt := func(audio io.Reader, event larkslim.EventResponse) (string, error) {
+		data, err := ioutil.ReadAll(audio)
+		return string(data), err
+	}
+	var got []AudioMessage
+	var deployed []string
+	router := &Router{}
+	router.Handle("deploy", Policy{}, func(cmd Command) {
+		deployed = append(deployed, cmd.Args...)
+	})
+	d := &Dispatcher{}
+	d.OnAudioReceived(api, stt, func(m AudioMessage) {
+		got = append(got, m)
+		router.HandleEvent(m.TextEvent())
+	})
+	for _, body := range []string{
+		`{"schema":"2.0","header":{"event_type":"im.message.receive_v1"},"event":{"message":{"message_id":"om_1","message_type":"audio","content":"{\"file_key\":\"file_1\",\"duration\":2000}"}}}`,
+		`{"schema":"2.0","header":{"event_type":"im.message.receive_v1"},"event":{"message":{"message_id":"om_2","message_type":"text","content":"{\"text\":\"hi\"}"}}}`,
+	} {
+		if err := d.Dispatch([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != 1 || got[0].Err != nil || got[0].FileKey != "file_1" || got[0].Duration != 2*time.Second ||
+		got[0].Transcript != "/deploy api" {
+		t.Errorf("wrong audio messages: %+v", got)
+	}
+	if len(deployed) != 1 || deployed[0] != "api" {
+		t.Errorf("voice command not run: %v", deployed)
+	}
+}
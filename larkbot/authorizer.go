@@ -0,0 +1,185 @@
+package larkbot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Policy lists who can run a command. The sender is allowed if any of
+	// the non-empty lists matches. An empty policy allows everyone.
+	Policy struct {
+		// open ids of users, for example admins
+		Users []string
+
+		// the command can be run by anyone in these chats
+		Chats []string
+
+		// open department ids
+		Departments []string
+
+		// ids of user groups
+		Groups []string
+	}
+
+	// Authorizer checks policies of commands. Departments and user groups
+	// of users are looked up with the contact API (requires the
+	// contact:user.department:readonly and contact:group:readonly scopes)
+	// only when a policy lists them, and cached separately.
+	Authorizer struct {
+		API *larkslim.API
+
+		// how long departments and groups of a user are cached, defaults
+		// to 10 minutes
+		CacheTTL time.Duration
+
+		// defaults to "You are not allowed to run this command."
+		DenyMessage string
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		mutex       sync.Mutex
+		departments map[string]cachedIds
+		groups      map[string]cachedIds
+	}
+
+	cachedIds struct {
+		ids       []string
+		expiresAt time.Time
+	}
+
+	contactUserResponse struct {
+		larkslim.APIResponse
+		Data struct {
+			User struct {
+				DepartmentIds []string `json:"department_ids"`
+			} `json:"user"`
+		} `json:"data"`
+	}
+
+	memberBelongResponse struct {
+		larkslim.APIResponse
+		Data struct {
+			GroupList []string `json:"group_list"`
+		} `json:"data"`
+	}
+)
+
+func (p Policy) empty() bool {
+	return len(p.Users) == 0 && len(p.Chats) == 0 && len(p.Departments) == 0 && len(p.Groups) == 0
+}
+
+// Authorize reports whether the user with the open id can run a command
+// with the policy in the chat.
+func (a *Authorizer) Authorize(policy Policy, openId, chatId string) (bool, error) {
+	if policy.empty() || contains(policy.Users, openId) || contains(policy.Chats, chatId) {
+		return true, nil
+	}
+	if len(policy.Departments) > 0 {
+		departments, err := a.cached(&a.departments, openId, a.fetchDepartments)
+		if err != nil {
+			return false, err
+		}
+		for _, id := range departments {
+			if contains(policy.Departments, id) {
+				return true, nil
+			}
+		}
+	}
+	if len(policy.Groups) > 0 {
+		groups, err := a.cached(&a.groups, openId, a.fetchGroups)
+		if err != nil {
+			return false, err
+		}
+		for _, id := range groups {
+			if contains(policy.Groups, id) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Deny replies the deny message to the command.
+func (a *Authorizer) Deny(cmd Command) error {
+	message := a.DenyMessage
+	if message == "" {
+		message = "You are not allowed to run this command."
+	}
+	return cmd.Reply(message)
+}
+
+// cached returns ids of the user in the cache, or fetches and caches them if
+// they have expired.
+func (a *Authorizer) cached(cache *map[string]cachedIds, openId string, fetch func(string) ([]string, error)) (ids []string, err error) {
+	now := a.clock().Now()
+	a.mutex.Lock()
+	c, ok := (*cache)[openId]
+	a.mutex.Unlock()
+	if ok && now.Before(c.expiresAt) {
+		return c.ids, nil
+	}
+	ids, err = fetch(openId)
+	if err != nil {
+		return
+	}
+	ttl := a.CacheTTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	a.mutex.Lock()
+	if *cache == nil {
+		*cache = map[string]cachedIds{}
+	}
+	(*cache)[openId] = cachedIds{ids: ids, expiresAt: now.Add(ttl)}
+	a.mutex.Unlock()
+	return
+}
+
+func (a *Authorizer) fetchDepartments(openId string) (ids []string, err error) {
+	var user contactUserResponse
+	err = a.API.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/contact/v3/users/"+openId+"?user_id_type=open_id&department_id_type=open_department_id",
+
+		// request body
+		nil,
+
+		// response
+		&user,
+	)
+	ids = user.Data.User.DepartmentIds
+	return
+}
+
+func (a *Authorizer) fetchGroups(openId string) (ids []string, err error) {
+	var groups memberBelongResponse
+	err = a.API.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/contact/v3/group/member_belong?member_id_type=open_id&member_id="+openId,
+
+		// request body
+		nil,
+
+		// response
+		&groups,
+	)
+	ids = groups.Data.GroupList
+	return
+}
+
+func (a *Authorizer) clock() larkslim.Clock {
+	if a.Clock != nil {
+		return a.Clock
+	}
+	return larkslim.SystemClock
+}
@@ -0,0 +1,62 @@
+package larkbot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func TestAuthorizer(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	server.Handle("/contact/v3/users/ou_user", map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"user": map[string]interface{}{"department_ids": []string{"od_ops"}},
+		},
+	})
+	server.Handle("/contact/v3/group/member_belong", map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{"group_list": []string{"g_admins"}},
+	})
+	clock := larktest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	authorizer := &Authorizer{API: server.API(), Clock: clock}
+	for _, test := range []struct {
+		policy Policy
+		allow  bool
+	}{
+		{Policy{Users: []string{"ou_admin"}}, false},
+		{Policy{Departments: []string{"od_ops"}}, true},
+		{Policy{Departments: []string{"od_dev"}}, false},
+		{Policy{Groups: []string{"g_admins"}}, true},
+		{Policy{Departments: []string{"od_dev"}, Groups: []string{"g_admins"}}, true},
+	} {
+		allow, err := authorizer.Authorize(test.policy, "ou_user", "oc_test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allow != test.allow {
+			t.Errorf("%+v: got %t, want %t", test.policy, allow, test.allow)
+		}
+	}
+	paths := func() (paths []string) {
+		for _, req := range server.Requests()[1:] {
+			paths = append(paths, req.Path)
+		}
+		return
+	}
+	want := "/contact/v3/users/ou_user,/contact/v3/group/member_belong"
+	if got := strings.Join(paths(), ","); got != want {
+		t.Errorf("departments and groups should be fetched once each, got %q", got)
+	}
+	clock.Advance(10 * time.Minute)
+	authorizer.Authorize(Policy{Groups: []string{"g_admins"}}, "ou_user", "oc_test")
+	want += ",/contact/v3/group/member_belong"
+	if got := strings.Join(paths(), ","); got != want {
+		t.Errorf("only expired groups should be fetched again, got %q", got)
+	}
+}
@@ -0,0 +1,46 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type (
+	// CardAction is the payload of card callbacks, sent when users click
+	// buttons, choose options or submit forms of cards.
+	CardAction struct {
+		OpenId        string           `json:"open_id"`
+		UserId        string           `json:"user_id"`
+		OpenMessageId string           `json:"open_message_id"`
+		OpenChatId    string           `json:"open_chat_id"`
+		TenantKey     string           `json:"tenant_key"`
+		Token         string           `json:"token"`
+		Action        CardActionDetail `json:"action"`
+	}
+
+	// CardActionDetail is the interactive element of CardAction.
+	CardActionDetail struct {
+		// "button", "select_static", "select_person", "overflow",
+		// "date_picker", "picker_time" or "picker_datetime"
+		Tag string `json:"tag"`
+
+		// value of the element set in the card
+		Value map[string]interface{} `json:"value"`
+
+		// chosen option of select menus and overflows
+		Option string `json:"option"`
+
+		// timezone of date pickers
+		Timezone string `json:"timezone"`
+
+		// values of the submitted form
+		FormValue map[string]interface{} `json:"form_value"`
+	}
+)
+
+// RespondCard responds to a card callback with the card to replace the
+// original card with.
+func RespondCard(w http.ResponseWriter, card interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(card)
+}
@@ -0,0 +1,29 @@
+package larkbot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCardCallback(t *testing.T) {
+	var got CardAction
+	h := &Server{
+		CardCallbackHandler: func(w http.ResponseWriter, action CardAction) {
+			got = action
+			RespondCard(w, map[string]string{"updated": action.Action.Option})
+		},
+	}
+	body := `{"open_id":"ou_1","open_message_id":"om_1","open_chat_id":"oc_1","token":"c-1",` +
+		`"action":{"tag":"select_static","option":"prod","value":{"key":"env"}}}`
+	w := httptest.NewRecorder()
+	h.handleLarkCards(w, httptest.NewRequest("POST", "/cards/", strings.NewReader(body)))
+	if got.OpenId != "ou_1" || got.OpenMessageId != "om_1" || got.Token != "c-1" ||
+		got.Action.Tag != "select_static" || got.Action.Option != "prod" || got.Action.Value["key"] != "env" {
+		t.Errorf("wrong action: %+v", got)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `{"updated":"prod"}` {
+		t.Errorf("wrong response: %s", body)
+	}
+}
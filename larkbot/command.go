@@ -0,0 +1,257 @@
+package larkbot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Command is a command sent to the bot as a text message, for example
+	// "/deploy web prod".
+	Command struct {
+		// name without prefix, for example "deploy"
+		Name string
+
+		// for example ["web", "prod"]
+		Args []string
+
+		Event larkslim.EventResponse
+
+		api *larkslim.API
+	}
+
+	// Router dispatches commands in message events to handlers:
+	//
+	//	router := &larkbot.Router{API: api, Authorizer: &larkbot.Authorizer{API: api}}
+	//	router.Handle("deploy", larkbot.Policy{Users: admins}, deploy)
+	//	server.EventCallbackHandler = router.HandleEvent
+	Router struct {
+		API *larkslim.API
+
+		// defaults to "/"
+		Prefix string
+
+		// checks policies of commands, required if any command has a
+		// non-empty policy
+		Authorizer *Authorizer
+
+		// called for unknown commands, ignored if nil
+		NotFound func(Command)
+
+		// minimum interval between commands of the same user and between
+		// commands in the same chat, zero to disable
+		UserCooldown time.Duration
+		ChatCooldown time.Duration
+
+		// replied to throttled commands, at most once per cooldown, %s is
+		// replaced with the remaining time; defaults to "Too many commands,
+		// please try again in %s."
+		ThrottleMessage string
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+
+		mutex     sync.Mutex
+		commands  map[string]command
+		lastRun   map[string]time.Time // "user:" or "chat:" + id -> time
+		throttled map[string]time.Time // id -> time of last throttle reply
+	}
+
+	command struct {
+		policy  Policy
+		handler func(Command)
+	}
+)
+
+// Handle registers the handler of the command name. The handler only runs
+// if the sender is allowed by the policy.
+func (r *Router) Handle(name string, policy Policy, handler func(Command)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.commands == nil {
+		r.commands = map[string]command{}
+	}
+	r.commands[name] = command{policy, handler}
+}
+
+// HandleEvent parses commands in text messages and runs their handlers. It
+// has the signature of Server.EventCallbackHandler. Handlers run in the
+// goroutine of the event request, start a new goroutine for slow commands.
+func (r *Router) HandleEvent(event larkslim.EventResponse) {
+	cmd, ok := r.parse(event)
+	if !ok {
+		return
+	}
+	r.mutex.Lock()
+	c, ok := r.commands[cmd.Name]
+	r.mutex.Unlock()
+	if !ok {
+		if r.NotFound != nil {
+			r.NotFound(cmd)
+		}
+		return
+	}
+	if wait := r.throttle(event.Event.OpenId, event.Event.ChatId); wait > 0 {
+		r.replyThrottled(cmd, wait)
+		return
+	}
+	if !c.policy.empty() {
+		if r.Authorizer == nil {
+			r.error("no authorizer for command", cmd.Name)
+			return
+		}
+		allowed, err := r.Authorizer.Authorize(c.policy, event.Event.OpenId, event.Event.ChatId)
+		if err != nil {
+			r.error(err)
+			return
+		}
+		if !allowed {
+			if err := r.Authorizer.Deny(cmd); err != nil {
+				r.error(err)
+			}
+			return
+		}
+	}
+	c.handler(cmd)
+}
+
+func (r *Router) parse(event larkslim.EventResponse) (cmd Command, ok bool) {
+	e := event.Event
+	if e.Type != "message" || e.MsgType != "text" {
+		return
+	}
+	text := e.TextWithoutAtBot
+	if text == "" {
+		text = e.Text
+	}
+	prefix := r.Prefix
+	if prefix == "" {
+		prefix = "/"
+	}
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, prefix) {
+		return
+	}
+	fields := strings.Fields(strings.TrimPrefix(text, prefix))
+	if len(fields) == 0 {
+		return
+	}
+	cmd = Command{
+		Name:  fields[0],
+		Args:  fields[1:],
+		Event: event,
+		api:   r.API,
+	}
+	ok = true
+	return
+}
+
+// throttle returns how long the user has to wait before running another
+// command, or records the run and returns zero.
+func (r *Router) throttle(openId, chatId string) (wait time.Duration) {
+	if r.UserCooldown <= 0 && r.ChatCooldown <= 0 {
+		return
+	}
+	now := r.clock().Now()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.lastRun == nil {
+		r.lastRun = map[string]time.Time{}
+	}
+	for key, last := range r.lastRun {
+		if now.Sub(last) >= r.UserCooldown && now.Sub(last) >= r.ChatCooldown {
+			delete(r.lastRun, key)
+		}
+	}
+	userKey, chatKey := "user:"+openId, "chat:"+chatId
+	if last, ok := r.lastRun[userKey]; ok && r.UserCooldown > 0 {
+		if d := r.UserCooldown - now.Sub(last); d > wait {
+			wait = d
+		}
+	}
+	if last, ok := r.lastRun[chatKey]; ok && r.ChatCooldown > 0 {
+		if d := r.ChatCooldown - now.Sub(last); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return
+	}
+	if r.UserCooldown > 0 && openId != "" {
+		r.lastRun[userKey] = now
+	}
+	if r.ChatCooldown > 0 && chatId != "" {
+		r.lastRun[chatKey] = now
+	}
+	return
+}
+
+// replyThrottled replies the throttle message, unless it has been replied
+// to the user within the cooldown.
+func (r *Router) replyThrottled(cmd Command, wait time.Duration) {
+	now := r.clock().Now()
+	cooldown := r.UserCooldown
+	if r.ChatCooldown > cooldown {
+		cooldown = r.ChatCooldown
+	}
+	openId := cmd.Event.Event.OpenId
+	r.mutex.Lock()
+	if r.throttled == nil {
+		r.throttled = map[string]time.Time{}
+	}
+	for id, t := range r.throttled {
+		if now.Sub(t) >= cooldown {
+			delete(r.throttled, id)
+		}
+	}
+	_, replied := r.throttled[openId]
+	if !replied {
+		r.throttled[openId] = now
+	}
+	r.mutex.Unlock()
+	if replied {
+		return
+	}
+	message := r.ThrottleMessage
+	if message == "" {
+		message = "Too many commands, please try again in %s."
+	}
+	if err := cmd.Reply(fmt.Sprintf(message, wait.Round(time.Second))); err != nil {
+		r.error(err)
+	}
+}
+
+func (r *Router) clock() larkslim.Clock {
+	if r.Clock != nil {
+		return r.Clock
+	}
+	return larkslim.SystemClock
+}
+
+func (r *Router) error(args ...interface{}) {
+	if r.Logger != nil {
+		r.Logger.Error(args...)
+	}
+}
+
+// Reply sends text to the chat of the command, as a reply to the command
+// message.
+func (cmd Command) Reply(text string) error {
+	_, err := cmd.api.SendMessage(cmd.Event.Event.ChatId, text, larkslim.InThread(cmd.Event.Event.MessageId))
+	return err
+}
+
+// React adds an emoji reaction, for example "THUMBSUP", to the command
+// message to acknowledge it without a reply.
+func (cmd Command) React(emojiType string) error {
+	_, err := cmd.api.CreateReaction(cmd.Event.Event.MessageId, emojiType)
+	return err
+}
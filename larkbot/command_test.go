@@ -0,0 +1,82 @@
+package larkbot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func textMessage(openId, text string) larkslim.EventResponse {
+	var event larkslim.EventResponse
+	event.Type = "event_callback"
+	event.Event.Type = "message"
+	event.Event.MsgType = "text"
+	event.Event.ChatId = "oc_test"
+	event.Event.OpenId = openId
+	event.Event.TextWithoutAtBot = text
+	return event
+}
+
+func TestRouter(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	api := server.API()
+	var ran []string
+	router := &Router{
+		API:        api,
+		Authorizer: &Authorizer{API: api},
+	}
+	router.Handle("deploy", Policy{Users: []string{"ou_admin"}}, func(cmd Command) {
+		ran = append(ran, cmd.Event.Event.OpenId+" "+strings.Join(cmd.Args, " "))
+	})
+	router.HandleEvent(textMessage("ou_admin", " /deploy web prod"))
+	router.HandleEvent(textMessage("ou_admin", "deploy web"))
+	router.HandleEvent(textMessage("ou_admin", "/other"))
+	router.HandleEvent(textMessage("ou_guest", "/deploy web"))
+	if len(ran) != 1 || ran[0] != "ou_admin web prod" {
+		t.Errorf("wrong commands run: %q", ran)
+	}
+	var denied int
+	for _, req := range server.Requests() {
+		if req.Path == "/message/v4/send/" && strings.Contains(string(req.Body), "not allowed") {
+			denied++
+		}
+	}
+	if denied != 1 {
+		t.Errorf("should reply deny message once, got %d", denied)
+	}
+}
+
+func TestRouterCooldown(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	clock := larktest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := &Router{
+		API:          server.API(),
+		UserCooldown: 10 * time.Second,
+		Clock:        clock,
+	}
+	var ran int
+	router.Handle("ping", Policy{}, func(cmd Command) { ran++ })
+	router.HandleEvent(textMessage("ou_a", "/ping"))
+	router.HandleEvent(textMessage("ou_a", "/ping"))
+	router.HandleEvent(textMessage("ou_a", "/ping"))
+	router.HandleEvent(textMessage("ou_b", "/ping"))
+	clock.Advance(10 * time.Second)
+	router.HandleEvent(textMessage("ou_a", "/ping"))
+	if ran != 3 {
+		t.Errorf("should run 3 commands, got %d", ran)
+	}
+	var replies int
+	for _, req := range server.Requests() {
+		if req.Path == "/message/v4/send/" && strings.Contains(string(req.Body), "try again in 10s") {
+			replies++
+		}
+	}
+	if replies != 1 {
+		t.Errorf("should reply throttle message once, got %d", replies)
+	}
+}
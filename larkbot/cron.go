@@ -0,0 +1,248 @@
+package larkbot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Scheduler runs jobs on cron schedules:
+	//
+	//	scheduler := &larkbot.Scheduler{API: api, Location: shanghai}
+	//	scheduler.Every("0 9 * * MON", func(api *larkslim.API) error {
+	//		_, err := api.SendMessage(chatId, "Standup time!")
+	//		return err
+	//	})
+	//	go scheduler.Run(stop)
+	Scheduler struct {
+		API *larkslim.API
+
+		// time zone of schedules, defaults to time.Local; can be
+		// overridden for each job with a "TZ=Asia/Shanghai " prefix
+		Location *time.Location
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+
+		mutex sync.Mutex
+		jobs  []*cronJob
+	}
+
+	cronJob struct {
+		spec     string
+		schedule cronSchedule
+		location *time.Location
+		run      func(api *larkslim.API) error
+
+		mutex   sync.Mutex
+		running bool
+	}
+
+	// cronSchedule has the allowed values of each field.
+	cronSchedule struct {
+		minute, hour, dom, month, dow map[int]bool
+
+		// whether day of month and day of week are not "*"
+		domRestricted, dowRestricted bool
+	}
+)
+
+var (
+	cronMonths = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+	cronDays = map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}
+)
+
+// Every adds a job running f on the cron schedule spec ("minute hour
+// day-of-month month day-of-week", for example "0 9 * * MON-FRI"). A job is
+// skipped if its previous run has not finished yet.
+func (s *Scheduler) Every(spec string, f func(api *larkslim.API) error) error {
+	job := &cronJob{spec: spec, run: f}
+	fields := strings.Fields(spec)
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "TZ=") {
+		loc, err := time.LoadLocation(strings.TrimPrefix(fields[0], "TZ="))
+		if err != nil {
+			return err
+		}
+		job.location = loc
+		fields = fields[1:]
+	}
+	schedule, err := parseCron(fields)
+	if err != nil {
+		return fmt.Errorf("bad schedule %q: %s", spec, err)
+	}
+	job.schedule = schedule
+	s.mutex.Lock()
+	s.jobs = append(s.jobs, job)
+	s.mutex.Unlock()
+	return nil
+}
+
+// Run runs jobs when they are due until stop is closed.
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	clock := s.clock()
+	for {
+		now := clock.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		clock.Sleep(next.Sub(now))
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		s.mutex.Lock()
+		jobs := append([]*cronJob(nil), s.jobs...)
+		s.mutex.Unlock()
+		for _, job := range jobs {
+			loc := job.location
+			if loc == nil {
+				loc = s.Location
+			}
+			if loc == nil {
+				loc = time.Local
+			}
+			if job.schedule.match(next.In(loc)) {
+				go s.runJob(job)
+			}
+		}
+	}
+}
+
+func (s *Scheduler) runJob(job *cronJob) {
+	job.mutex.Lock()
+	if job.running {
+		job.mutex.Unlock()
+		s.error("skipped", job.spec, "because previous run has not finished")
+		return
+	}
+	job.running = true
+	job.mutex.Unlock()
+	defer func() {
+		job.mutex.Lock()
+		job.running = false
+		job.mutex.Unlock()
+	}()
+	if err := job.run(s.API); err != nil {
+		s.error(job.spec, err)
+	}
+}
+
+func (s *Scheduler) clock() larkslim.Clock {
+	if s.Clock != nil {
+		return s.Clock
+	}
+	return larkslim.SystemClock
+}
+
+func (s *Scheduler) error(args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Error(args...)
+	}
+}
+
+func (c cronSchedule) match(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+	dom, dow := c.dom[t.Day()], c.dow[int(t.Weekday())]
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func parseCron(fields []string) (c cronSchedule, err error) {
+	if len(fields) != 5 {
+		err = fmt.Errorf("expected 5 fields, got %d", len(fields))
+		return
+	}
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, cronDays); err != nil {
+		return
+	}
+	if c.dow[7] {
+		c.dow[0] = true
+	}
+	c.domRestricted = fields[2] != "*"
+	c.dowRestricted = fields[4] != "*"
+	return
+}
+
+// parseCronField parses lists of values, ranges and steps like "1,15",
+// "MON-FRI" or "*/5".
+func parseCronField(field string, min, max int, names map[string]int) (values map[int]bool, err error) {
+	values = map[int]bool{}
+	parse := func(s string) (int, error) {
+		if n, ok := names[strings.ToUpper(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("bad value %q", s)
+		}
+		if n < min || n > max {
+			return 0, fmt.Errorf("value %d out of range %d-%d", n, min, max)
+		}
+		return n, nil
+	}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i > -1 {
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("bad step %q", part[i+1:])
+			}
+			part = part[:i]
+		}
+		start, end := min, max
+		if part != "*" {
+			if i := strings.Index(part, "-"); i > -1 {
+				if start, err = parse(part[:i]); err != nil {
+					return
+				}
+				if end, err = parse(part[i+1:]); err != nil {
+					return
+				}
+			} else {
+				if start, err = parse(part); err != nil {
+					return
+				}
+				end = start
+				if step > 1 {
+					end = max
+				}
+			}
+		}
+		if start > end {
+			return nil, fmt.Errorf("bad range %q", part)
+		}
+		for n := start; n <= end; n += step {
+			values[n] = true
+		}
+	}
+	return
+}
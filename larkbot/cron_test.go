@@ -0,0 +1,39 @@
+package larkbot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	for _, test := range []struct {
+		spec    string
+		time    string
+		matches bool
+	}{
+		{"0 9 * * MON", "2020-01-06 09:00", true},
+		{"0 9 * * MON", "2020-01-07 09:00", false},
+		{"*/15 * * * *", "2020-01-07 10:45", true},
+		{"*/15 * * * *", "2020-01-07 10:46", false},
+		{"0 9-17/2 * * MON-FRI", "2020-01-08 13:00", true},
+		{"0 9-17/2 * * MON-FRI", "2020-01-08 14:00", false},
+		{"0 0 1 * SUN", "2020-01-05 00:00", true},
+		{"0 0 1 JAN *", "2020-01-01 00:00", true},
+		{"0 0 1 JAN *", "2020-02-01 00:00", false},
+	} {
+		schedule, err := parseCron(strings.Fields(test.spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tm, _ := time.Parse("2006-01-02 15:04", test.time)
+		if schedule.match(tm) != test.matches {
+			t.Errorf("%s at %s should match: %t", test.spec, test.time, test.matches)
+		}
+	}
+	for _, spec := range []string{"* * * *", "60 * * * *", "* * * * FOO", "*/0 * * * *"} {
+		if _, err := parseCron(strings.Fields(spec)); err == nil {
+			t.Errorf("%s should be invalid", spec)
+		}
+	}
+}
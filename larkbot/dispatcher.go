@@ -0,0 +1,342 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Dispatcher routes events to handlers registered for their event
+	// types, with payloads unmarshaled into the struct of each type. Events
+	// of schema 2.0 are passed to the handlers of the same events of
+	// schema 1.0. Set it as Server.Dispatcher:
+	//
+	//	dispatcher := &larkbot.Dispatcher{}
+	//	dispatcher.OnMessageReceived(router.HandleEvent)
+	//	dispatcher.OnChatDisbanded(func(e larkbot.ChatDisbandedEvent) {
+	//		...
+	//	})
+	Dispatcher struct {
+		// called for events without handlers
+		Default func(eventType string, event json.RawMessage)
+
+		// open_id of the bot, if set, only mentions of the bot are
+		// removed from TextWithoutAtBot, see larkslim.ParseEventForBot
+		BotOpenId string
+
+		// if set, events it doesn't allow are dropped by Dispatch, for
+		// example when the Dispatcher is used without Server
+		EventFilter *EventFilter
+
+		mutex    sync.Mutex
+		handlers map[string][]func(body []byte) error
+	}
+
+	// EventOperator is the user who triggered an event.
+	EventOperator struct {
+		OpenId string `json:"open_id"`
+		UserId string `json:"user_id"`
+	}
+
+	// EventUser is a user in an event.
+	EventUser struct {
+		Name   string `json:"name"`
+		OpenId string `json:"open_id"`
+		UserId string `json:"user_id"`
+	}
+
+	// ChatMembersEvent is the add_user_to_chat, remove_user_from_chat or
+	// revoke_add_user_from_chat event.
+	ChatMembersEvent struct {
+		Type     string        `json:"type"`
+		AppId    string        `json:"app_id"`
+		ChatId   string        `json:"chat_id"`
+		Operator EventOperator `json:"operator"`
+		Users    []EventUser   `json:"users"`
+	}
+
+	// ChatDisbandedEvent is the chat_disband event.
+	ChatDisbandedEvent struct {
+		AppId    string        `json:"app_id"`
+		ChatId   string        `json:"chat_id"`
+		Operator EventOperator `json:"operator"`
+	}
+
+	// BotChatEvent is the add_bot or remove_bot event.
+	BotChatEvent struct {
+		Type           string             `json:"type"`
+		AppId          string             `json:"app_id"`
+		ChatName       string             `json:"chat_name"`
+		ChatI18nNames  larkslim.I18nNames `json:"chat_i18n_names"`
+		ChatId         string             `json:"open_chat_id"`
+		OperatorName   string             `json:"operator_name"`
+		OperatorOpenId string             `json:"operator_open_id"`
+	}
+
+	// P2PChatCreatedEvent is the p2p_chat_create event, sent when a user
+	// opens a one-on-one chat with the bot for the first time.
+	P2PChatCreatedEvent struct {
+		AppId    string        `json:"app_id"`
+		ChatId   string        `json:"chat_id"`
+		Operator EventOperator `json:"operator"`
+		User     EventUser     `json:"user"`
+	}
+
+	// ReactionEvent is the im.message.reaction.created_v1 or
+	// im.message.reaction.deleted_v1 event of schema 2.0.
+	ReactionEvent struct {
+		MessageId    string                `json:"message_id"`
+		ReactionType larkslim.ReactionType `json:"reaction_type"`
+		// "user" or "app"
+		OperatorType string               `json:"operator_type"`
+		UserId       larkslim.EventUserId `json:"user_id"`
+		AppId        string               `json:"app_id"`
+		// milliseconds since epoch
+		ActionTime string `json:"action_time"`
+	}
+
+	eventBody struct {
+		Schema string               `json:"schema"`
+		Header larkslim.EventHeader `json:"header"`
+		Event  json.RawMessage      `json:"event"`
+	}
+
+	// chatEventV2 is the event of chat events of schema 2.0.
+	chatEventV2 struct {
+		ChatId     string               `json:"chat_id"`
+		OperatorId larkslim.EventUserId `json:"operator_id"`
+		Name       string               `json:"name"`
+		I18nNames  larkslim.I18nNames   `json:"i18n_names"`
+		Users      []struct {
+			Name   string               `json:"name"`
+			UserId larkslim.EventUserId `json:"user_id"`
+		} `json:"users"`
+	}
+)
+
+// On registers a handler of the raw event of the type, for example
+// "add_bot" of schema 1.0 or "im.chat.updated_v1" of schema 2.0.
+func (d *Dispatcher) On(eventType string, handler func(event json.RawMessage)) {
+	d.handleEvent(eventType, func(event json.RawMessage) error {
+		handler(event)
+		return nil
+	})
+}
+
+// OnMessageReceived registers a handler of message events. Messages of
+// schema 2.0 are converted by larkslim.ParseEvent.
+func (d *Dispatcher) OnMessageReceived(handler func(larkslim.EventResponse)) {
+	f := func(body []byte) error {
+		e, err := larkslim.ParseEventForBot(body, false, d.BotOpenId)
+		if err != nil {
+			return err
+		}
+		handler(e)
+		return nil
+	}
+	d.handle("message", f)
+	d.handle(larkslim.MessageReceiveEventType, f)
+}
+
+// OnUserAddedToChat registers a handler of add_user_to_chat events.
+func (d *Dispatcher) OnUserAddedToChat(handler func(ChatMembersEvent)) {
+	d.onChatMembers("add_user_to_chat", "im.chat.member.user.added_v1", handler)
+}
+
+// OnUserRemovedFromChat registers a handler of remove_user_from_chat events.
+func (d *Dispatcher) OnUserRemovedFromChat(handler func(ChatMembersEvent)) {
+	d.onChatMembers("remove_user_from_chat", "im.chat.member.user.deleted_v1", handler)
+}
+
+// OnChatDisbanded registers a handler of chat_disband events.
+func (d *Dispatcher) OnChatDisbanded(handler func(ChatDisbandedEvent)) {
+	d.handleEvent("chat_disband", func(event json.RawMessage) error {
+		var e ChatDisbandedEvent
+		return decodeEvent(event, &e, func() { handler(e) })
+	})
+	d.handleEvent("im.chat.disbanded_v1", func(event json.RawMessage) error {
+		var v2 chatEventV2
+		return decodeEvent(event, &v2, func() {
+			handler(ChatDisbandedEvent{
+				ChatId:   v2.ChatId,
+				Operator: v2.operator(),
+			})
+		})
+	})
+}
+
+// OnBotAdded registers a handler of add_bot events.
+func (d *Dispatcher) OnBotAdded(handler func(BotChatEvent)) {
+	d.onBotChat("add_bot", "im.chat.member.bot.added_v1", handler)
+}
+
+// OnBotRemoved registers a handler of remove_bot events.
+func (d *Dispatcher) OnBotRemoved(handler func(BotChatEvent)) {
+	d.onBotChat("remove_bot", "im.chat.member.bot.deleted_v1", handler)
+}
+
+// OnP2PChatCreated registers a handler of p2p_chat_create events.
+func (d *Dispatcher) OnP2PChatCreated(handler func(P2PChatCreatedEvent)) {
+	d.handleEvent("p2p_chat_create", func(event json.RawMessage) error {
+		var e P2PChatCreatedEvent
+		return decodeEvent(event, &e, func() { handler(e) })
+	})
+	d.handleEvent("im.chat.access_event.bot_p2p_chat_entered_v1", func(event json.RawMessage) error {
+		var v2 chatEventV2
+		return decodeEvent(event, &v2, func() {
+			operator := v2.operator()
+			handler(P2PChatCreatedEvent{
+				ChatId:   v2.ChatId,
+				Operator: operator,
+				User:     EventUser{OpenId: operator.OpenId, UserId: operator.UserId},
+			})
+		})
+	})
+}
+
+// OnReactionAdded registers a handler of reactions added to messages.
+func (d *Dispatcher) OnReactionAdded(handler func(ReactionEvent)) {
+	d.onReaction("im.message.reaction.created_v1", handler)
+}
+
+// OnReactionRemoved registers a handler of reactions removed from messages.
+func (d *Dispatcher) OnReactionRemoved(handler func(ReactionEvent)) {
+	d.onReaction("im.message.reaction.deleted_v1", handler)
+}
+
+// Dispatch calls handlers of the event in the decrypted JSON body of an
+// event callback of schema 1.0 or 2.0.
+func (d *Dispatcher) Dispatch(body []byte) error {
+	var e eventBody
+	if err := json.Unmarshal(body, &e); err != nil {
+		return err
+	}
+	if d.EventFilter != nil {
+		event, err := larkslim.ParseEventForBot(body, false, d.BotOpenId)
+		if err != nil {
+			return err
+		}
+		if !d.EventFilter.allow(event, body) {
+			return nil
+		}
+	}
+	eventType := e.eventType()
+	d.mutex.Lock()
+	handlers := d.handlers[eventType]
+	d.mutex.Unlock()
+	if len(handlers) == 0 {
+		if d.Default != nil {
+			d.Default(eventType, e.Event)
+		}
+		return nil
+	}
+	for _, handler := range handlers {
+		if err := handler(body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) onChatMembers(v1, v2 string, handler func(ChatMembersEvent)) {
+	d.handleEvent(v1, func(event json.RawMessage) error {
+		var e ChatMembersEvent
+		return decodeEvent(event, &e, func() { handler(e) })
+	})
+	d.handleEvent(v2, func(event json.RawMessage) error {
+		var e chatEventV2
+		return decodeEvent(event, &e, func() {
+			users := make([]EventUser, len(e.Users))
+			for i, user := range e.Users {
+				users[i] = EventUser{
+					Name:   user.Name,
+					OpenId: user.UserId.OpenId,
+					UserId: user.UserId.UserId,
+				}
+			}
+			handler(ChatMembersEvent{
+				Type:     v1,
+				ChatId:   e.ChatId,
+				Operator: e.operator(),
+				Users:    users,
+			})
+		})
+	})
+}
+
+func (d *Dispatcher) onBotChat(v1, v2 string, handler func(BotChatEvent)) {
+	d.handleEvent(v1, func(event json.RawMessage) error {
+		var e BotChatEvent
+		return decodeEvent(event, &e, func() { handler(e) })
+	})
+	d.handleEvent(v2, func(event json.RawMessage) error {
+		var e chatEventV2
+		return decodeEvent(event, &e, func() {
+			operator := e.operator()
+			handler(BotChatEvent{
+				Type:           v1,
+				ChatName:       e.Name,
+				ChatI18nNames:  e.I18nNames,
+				ChatId:         e.ChatId,
+				OperatorOpenId: operator.OpenId,
+			})
+		})
+	})
+}
+
+func (d *Dispatcher) onReaction(eventType string, handler func(ReactionEvent)) {
+	d.handleEvent(eventType, func(event json.RawMessage) error {
+		var e ReactionEvent
+		return decodeEvent(event, &e, func() { handler(e) })
+	})
+}
+
+func (d *Dispatcher) handle(eventType string, handler func(body []byte) error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.handlers == nil {
+		d.handlers = map[string][]func([]byte) error{}
+	}
+	d.handlers[eventType] = append(d.handlers[eventType], handler)
+}
+
+// handleEvent registers a handler of the event field of the body.
+func (d *Dispatcher) handleEvent(eventType string, handler func(event json.RawMessage) error) {
+	d.handle(eventType, func(body []byte) error {
+		var e eventBody
+		if err := json.Unmarshal(body, &e); err != nil {
+			return err
+		}
+		return handler(e.Event)
+	})
+}
+
+// eventType returns the event type of schema 1.0 or 2.0.
+func (e eventBody) eventType() string {
+	if e.Schema == "2.0" {
+		return e.Header.EventType
+	}
+	var header struct {
+		Type string `json:"type"`
+	}
+	json.Unmarshal(e.Event, &header)
+	return header.Type
+}
+
+func (e chatEventV2) operator() EventOperator {
+	return EventOperator{
+		OpenId: e.OperatorId.OpenId,
+		UserId: e.OperatorId.UserId,
+	}
+}
+
+// decodeEvent unmarshals event into v and calls f if there is no error.
+func decodeEvent(event json.RawMessage, v interface{}, f func()) error {
+	if err := json.Unmarshal(event, v); err != nil {
+		return err
+	}
+	f()
+	return nil
+}
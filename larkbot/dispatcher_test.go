@@ -0,0 +1,144 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caiguanhao/larkslim"
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func TestSchema2(t *testing.T) {
+	var received []string
+	h := &Server{
+		EventVerificationToken: "token",
+		EventFilter:            &EventFilter{IgnoreBots: true},
+		EventCallbackHandler: func(event larkslim.EventResponse) {
+			received = append(received, event.Event.ChatType+" "+event.Event.OpenId+" "+event.Event.Text)
+		},
+	}
+	post := func(token, senderType string) {
+		body := `{"schema":"2.0","header":{"event_id":"1","event_type":"im.message.receive_v1","token":"` + token + `"},` +
+			`"event":{"sender":{"sender_id":{"open_id":"ou_1"},"sender_type":"` + senderType + `"},` +
+			`"message":{"chat_id":"oc_1","chat_type":"p2p","message_type":"text","content":"{\"text\":\"hi\"}"}}}`
+		h.handleLarkEvents(httptest.NewRecorder(), httptest.NewRequest("POST", "/events/", strings.NewReader(body)))
+	}
+	post("token", "user")
+	post("wrong", "user")
+	post("token", "app")
+	if len(received) != 1 || received[0] != "private ou_1 hi" {
+		t.Errorf("wrong events received: %q", received)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	var got []string
+	d := &Dispatcher{
+		Default: func(eventType string, event json.RawMessage) {
+			got = append(got, "default "+eventType)
+		},
+	}
+	d.OnMessageReceived(func(e larkslim.EventResponse) {
+		got = append(got, "message "+e.Event.TextWithoutAtBot)
+	})
+	d.OnUserAddedToChat(func(e ChatMembersEvent) {
+		got = append(got, "added "+e.ChatId+" "+e.Users[0].OpenId)
+	})
+	d.OnChatDisbanded(func(e ChatDisbandedEvent) {
+		got = append(got, "disbanded "+e.ChatId)
+	})
+	for _, body := range []string{
+		`{"type":"event_callback","event":{"type":"message","text_without_at_bot":"hi"}}`,
+		`{"type":"event_callback","event":{"type":"add_user_to_chat","chat_id":"oc_1","users":[{"open_id":"ou_1"}]}}`,
+		`{"type":"event_callback","event":{"type":"chat_disband","chat_id":"oc_2"}}`,
+		`{"type":"event_callback","event":{"type":"add_bot"}}`,
+		`{"schema":"2.0","header":{"event_type":"im.message.receive_v1"},"event":{"message":{"message_type":"text","content":"{\"text\":\"@_user_1 hello\"}","mentions":[{"key":"@_user_1"}]}}}`,
+		`{"schema":"2.0","header":{"event_type":"im.chat.disbanded_v1"},"event":{"chat_id":"oc_3","operator_id":{"open_id":"ou_1"}}}`,
+	} {
+		if err := d.Dispatch([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"message hi", "added oc_1 ou_1", "disbanded oc_2", "default add_bot", "message hello", "disbanded oc_3"}
+	if len(got) != len(want) {
+		t.Fatalf("wrong events: %q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestChatRepair(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	var lost []ChatLostEvent
+	d := &Dispatcher{}
+	repair := &ChatRepair{
+		API:    server.API(),
+		Admins: []string{"ou_admin"},
+		Policy: func(e ChatLostEvent) {
+			lost = append(lost, e)
+		},
+	}
+	repair.Register(d)
+	for _, body := range []string{
+		`{"type":"event_callback","event":{"type":"remove_bot","open_chat_id":"oc_1","chat_name":"Ops","operator_open_id":"ou_1"}}`,
+		`{"schema":"2.0","header":{"event_type":"im.chat.disbanded_v1"},"event":{"chat_id":"oc_2","operator_id":{"open_id":"ou_2"}}}`,
+	} {
+		if err := d.Dispatch([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(lost) != 2 || lost[0].Type != "remove_bot" || lost[0].ChatId != "oc_1" ||
+		lost[1].Type != "chat_disband" || lost[1].ChatId != "oc_2" {
+		t.Fatalf("wrong events: %+v", lost)
+	}
+	var notified []string
+	for _, req := range server.Requests() {
+		if req.Path == "/message/v4/send/" {
+			notified = append(notified, string(req.Body))
+		}
+	}
+	if len(notified) != 2 || !strings.Contains(notified[0], "Bot was removed from chat Ops (oc_1) by ou_1") ||
+		!strings.Contains(notified[1], "Chat oc_2 was disbanded by ou_2") {
+		t.Errorf("wrong notifications: %q", notified)
+	}
+}
+
+func TestReactionTriggers(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	server.Handle("/im/v1/messages/om_1", map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"items": []map[string]string{{"message_id": "om_1", "chat_id": "oc_alerts"}},
+		},
+	})
+	var resolved []string
+	triggers := &ReactionTriggers{API: server.API()}
+	triggers.Handle("DONE", "oc_alerts", func(r Reaction) {
+		resolved = append(resolved, r.Message.MessageId+" "+r.Event.UserId.OpenId)
+	})
+	triggers.Handle("DONE", "oc_other", func(r Reaction) {
+		resolved = append(resolved, "other")
+	})
+	d := &Dispatcher{}
+	triggers.Register(d)
+	for _, body := range []string{
+		`{"schema":"2.0","header":{"event_type":"im.message.reaction.created_v1"},"event":{"message_id":"om_1","reaction_type":{"emoji_type":"DONE"},"operator_type":"user","user_id":{"open_id":"ou_1"}}}`,
+		`{"schema":"2.0","header":{"event_type":"im.message.reaction.created_v1"},"event":{"message_id":"om_1","reaction_type":{"emoji_type":"THUMBSUP"},"operator_type":"user","user_id":{"open_id":"ou_1"}}}`,
+		`{"schema":"2.0","header":{"event_type":"im.message.reaction.created_v1"},"event":{"message_id":"om_1","reaction_type":{"emoji_type":"DONE"},"operator_type":"app","app_id":"cli_test"}}`,
+	} {
+		if err := d.Dispatch([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(resolved) != 1 || resolved[0] != "om_1 ou_1" {
+		t.Errorf("wrong triggers: %q", resolved)
+	}
+}
@@ -0,0 +1,93 @@
+package larkbot
+
+import (
+	"encoding/json"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// EventFilter drops events before they reach the EventSink and the
+	// EventCallbackHandler of Server, or the handlers of Dispatcher. Empty
+	// fields do not filter anything. Dropped events are still
+	// acknowledged.
+	EventFilter struct {
+		// only accept events from these chats; events without a chat,
+		// like contact or approval events, are not filtered by chat
+		ChatIds []string
+
+		// only accept messages sent in one-on-one chats
+		P2POnly bool
+
+		// only accept messages of these types, for example "text"
+		MsgTypes []string
+
+		// ignore messages sent by other bots; schema 1.0 message events
+		// are only sent for messages from users, schema 2.0 events may
+		// be sent by bots
+		IgnoreBots bool
+
+		// ignore events from these open_ids
+		IgnoreUsers []string
+	}
+)
+
+// Allow reports whether the event passes the filter.
+func (f *EventFilter) Allow(event larkslim.EventResponse) bool {
+	e := event.Event
+	if len(f.ChatIds) > 0 && e.ChatId != "" && !contains(f.ChatIds, e.ChatId) {
+		return false
+	}
+	isMessage := e.Type == "message"
+	if f.P2POnly && isMessage && e.ChatType != "private" {
+		return false
+	}
+	if len(f.MsgTypes) > 0 && isMessage && !contains(f.MsgTypes, e.MsgType) {
+		return false
+	}
+	if f.IgnoreBots && isMessage && e.SenderType == "app" {
+		return false
+	}
+	if len(f.IgnoreUsers) > 0 {
+		if contains(f.IgnoreUsers, e.OpenId) || contains(f.IgnoreUsers, e.UserOpenId) {
+			return false
+		}
+	}
+	return true
+}
+
+// allow is like Allow, but also finds the chat id of events other than
+// messages, like chat events, in the body of the event.
+func (f *EventFilter) allow(event larkslim.EventResponse, body []byte) bool {
+	if event.Event.ChatId == "" {
+		event.Event.ChatId = eventChatId(body)
+	}
+	return f.Allow(event)
+}
+
+// eventChatId returns the chat_id or open_chat_id of the event in body.
+func eventChatId(body []byte) string {
+	var e struct {
+		Event struct {
+			ChatId     string `json:"chat_id"`
+			OpenChatId string `json:"open_chat_id"`
+		} `json:"event"`
+	}
+	json.Unmarshal(body, &e)
+	if e.Event.ChatId != "" {
+		return e.Event.ChatId
+	}
+	return e.Event.OpenChatId
+}
+
+func contains(list []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+// im.message.receive_v1 events as delivered to a bot with permission to read
+// all messages of group chats
+const (
+	userMessageEvent = `{"schema":"2.0","header":{"event_id":"5e3702a84e847582be8db7fb73283c02",` +
+		`"event_type":"im.message.receive_v1","create_time":"1608725989000","token":"token",` +
+		`"app_id":"cli_test","tenant_key":"2ca1d211f64f6438"},"event":{"sender":{"sender_id":` +
+		`{"union_id":"on_8ed6aa67826108097d9ee143816345","user_id":"e33ggbyz","open_id":"ou_user"},` +
+		`"sender_type":"user","tenant_key":"2ca1d211f64f6438"},"message":{"message_id":"om_user",` +
+		`"create_time":"1609073151345","chat_id":"oc_group","chat_type":"group","message_type":"text",` +
+		`"content":"{\"text\":\"deploy finished\"}"}}}`
+
+	botMessageEvent = `{"schema":"2.0","header":{"event_id":"0d46a6dd3c1eb3f5e1bde3e8a3f06ac1",` +
+		`"event_type":"im.message.receive_v1","create_time":"1608725990000","token":"token",` +
+		`"app_id":"cli_test","tenant_key":"2ca1d211f64f6438"},"event":{"sender":{"sender_id":` +
+		`{"union_id":"on_2b5a3fbd1d8c6f1b3c0a6ecb58c5e2c4","user_id":"","open_id":"ou_otherbot"},` +
+		`"sender_type":"app","tenant_key":"2ca1d211f64f6438"},"message":{"message_id":"om_bot",` +
+		`"create_time":"1609073152345","chat_id":"oc_group","chat_type":"group","message_type":"interactive",` +
+		`"content":"{\"title\":\"Build\",\"elements\":[[{\"tag\":\"text\",\"text\":\"passed\"}]]}"}}}`
+)
+
+func TestEventFilterIgnoreBots(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		body   string
+		filter EventFilter
+		allow  bool
+	}{
+		{"user", userMessageEvent, EventFilter{IgnoreBots: true}, true},
+		{"bot", botMessageEvent, EventFilter{IgnoreBots: true}, false},
+		{"bot allowed", botMessageEvent, EventFilter{}, true},
+		{"bot in other chat", botMessageEvent, EventFilter{ChatIds: []string{"oc_other"}}, false},
+	} {
+		event, err := larkslim.ParseEvent([]byte(test.body), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allow := test.filter.Allow(event); allow != test.allow {
+			t.Errorf("%s: got %t, want %t", test.name, allow, test.allow)
+		}
+	}
+}
+
+func TestEventFilterChatIds(t *testing.T) {
+	filter := &EventFilter{ChatIds: []string{"oc_group"}}
+	var got []string
+	d := &Dispatcher{
+		EventFilter: filter,
+		Default: func(eventType string, event json.RawMessage) {
+			got = append(got, eventType)
+		},
+	}
+	d.OnMessageReceived(func(e larkslim.EventResponse) {
+		got = append(got, "message "+e.Event.ChatId)
+	})
+	for _, body := range []string{
+		userMessageEvent,
+		strings.Replace(userMessageEvent, "oc_group", "oc_other", 1),
+		`{"schema":"2.0","header":{"event_type":"contact.user.created_v3"},"event":{"object":{"open_id":"ou_new"}}}`,
+		`{"schema":"2.0","header":{"event_type":"im.chat.disbanded_v1"},"event":{"chat_id":"oc_other"}}`,
+		`{"schema":"2.0","header":{"event_type":"im.chat.updated_v1"},"event":{"chat_id":"oc_group"}}`,
+	} {
+		if err := d.Dispatch([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "message oc_group,contact.user.created_v3,im.chat.updated_v1"
+	if strings.Join(got, ",") != want {
+		t.Errorf("got %q, want %q", strings.Join(got, ","), want)
+	}
+}
@@ -0,0 +1,295 @@
+package larkbot
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+// formKey is the key of the submit button value of Form cards.
+const formKey = "larkbot_form"
+
+const (
+	FieldInput       = "input"
+	FieldSelect      = "select_static"
+	FieldMultiSelect = "multi_select_static"
+	FieldDate        = "date_picker"
+)
+
+type (
+	// Form is a card with input fields and a submit button. Values of all
+	// fields are sent in one card callback when the button is clicked, see
+	// Forms.
+	Form struct {
+		// identifies the form in callbacks
+		Name string
+
+		Title    string
+		Fields   []FormField
+		Template string
+
+		// defaults to "Submit"
+		SubmitText string
+
+		// if not empty, a confirmation dialog with the text is shown
+		// before submitting
+		ConfirmText string
+	}
+
+	FormField struct {
+		// key of the value, see FormValues.Decode
+		Name string
+
+		Label string
+
+		// one of FieldInput, FieldSelect, FieldMultiSelect or FieldDate
+		Type string
+
+		Placeholder string
+		Options     []FormOption
+		Required    bool
+	}
+
+	FormOption struct {
+		Text  string
+		Value string
+	}
+
+	// FormValues are values of a submitted form. Selects and inputs are
+	// strings, multi-selects are lists of strings and dates are strings
+	// like "2006-01-02 +0800".
+	FormValues map[string]interface{}
+
+	// Forms dispatches card callbacks of submitted forms to handlers:
+	//
+	//	forms := &larkbot.Forms{}
+	//	forms.Handle("leave", func(values larkbot.FormValues) {
+	//		var leave struct {
+	//			Type  string    `form:"type"`
+	//			Start time.Time `form:"start"`
+	//		}
+	//		if err := values.Decode(&leave); err != nil {
+	//			...
+	//		}
+	//	})
+	//	api.SendCard(openId, form.Card())
+	//	server.CardCallbackHandler = func(w http.ResponseWriter, action larkbot.CardAction) {
+	//		if forms.HandleCardCallback(w, action) {
+	//			return
+	//		}
+	//	}
+	Forms struct {
+		mutex    sync.Mutex
+		handlers map[string]func(FormValues)
+	}
+)
+
+// Card returns the form as a card.
+func (f Form) Card() larkslim.Card {
+	var elements []interface{}
+	plainText := func(text string) map[string]interface{} {
+		return map[string]interface{}{
+			"tag":     "plain_text",
+			"content": text,
+		}
+	}
+	for _, field := range f.Fields {
+		element := map[string]interface{}{
+			"tag":      field.Type,
+			"name":     field.Name,
+			"required": field.Required,
+		}
+		if field.Placeholder != "" {
+			element["placeholder"] = plainText(field.Placeholder)
+		}
+		if field.Type == FieldInput {
+			element["label"] = plainText(field.Label)
+		} else if field.Label != "" {
+			elements = append(elements, map[string]interface{}{
+				"tag":     "markdown",
+				"content": "**" + field.Label + "**",
+			})
+		}
+		if len(field.Options) > 0 {
+			var options []interface{}
+			for _, option := range field.Options {
+				options = append(options, map[string]interface{}{
+					"text":  plainText(option.Text),
+					"value": option.Value,
+				})
+			}
+			element["options"] = options
+		}
+		elements = append(elements, element)
+	}
+	submitText := f.SubmitText
+	if submitText == "" {
+		submitText = "Submit"
+	}
+	submit := map[string]interface{}{
+		"tag":         "button",
+		"name":        "submit",
+		"text":        plainText(submitText),
+		"type":        "primary",
+		"action_type": "form_submit",
+		"value": map[string]interface{}{
+			formKey: f.Name,
+		},
+	}
+	if f.ConfirmText != "" {
+		submit["confirm"] = map[string]interface{}{
+			"title": plainText(f.Title),
+			"text":  plainText(f.ConfirmText),
+		}
+	}
+	elements = append(elements, submit)
+	return larkslim.Card{
+		Config: larkslim.CardConfig{
+			WideScreenMode: true,
+		},
+		Header: larkslim.CardHeader{
+			Title: larkslim.CardHeaderTitle{
+				Tag:     "plain_text",
+				Content: f.Title,
+			},
+			Template: f.Template,
+		},
+		Elements: []interface{}{
+			map[string]interface{}{
+				"tag":      "form",
+				"name":     "form_" + f.Name,
+				"elements": elements,
+			},
+		},
+	}
+}
+
+// Handle registers the handler of submitted forms with the name.
+func (f *Forms) Handle(name string, handler func(FormValues)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.handlers == nil {
+		f.handlers = map[string]func(FormValues){}
+	}
+	f.handlers[name] = handler
+}
+
+// HandleCardCallback runs the handler of the submitted form. It returns
+// false if the action is not a submission of a form with a handler.
+func (f *Forms) HandleCardCallback(w http.ResponseWriter, action CardAction) bool {
+	name, ok := action.Action.Value[formKey].(string)
+	if !ok {
+		return false
+	}
+	f.mutex.Lock()
+	handler, ok := f.handlers[name]
+	f.mutex.Unlock()
+	if !ok {
+		return false
+	}
+	handler(FormValues(action.Action.FormValue))
+	w.WriteHeader(http.StatusOK)
+	return true
+}
+
+// Decode stores the values in the struct pointed to by v. Fields are
+// matched by the "form" struct tag or the field name. Supported field types
+// are string, []string, bool, integers, floats and time.Time.
+func (values FormValues) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form values can only be decoded into pointer to struct, not %T", v)
+	}
+	rv = rv.Elem()
+	typ := rv.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get("form")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		value, ok := values[name]
+		if !ok || value == nil {
+			continue
+		}
+		if err := setFormValue(rv.Field(i), value); err != nil {
+			return fmt.Errorf("form field %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func setFormValue(field reflect.Value, value interface{}) error {
+	var list []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			list = append(list, fmt.Sprint(item))
+		}
+	default:
+		list = []string{fmt.Sprint(v)}
+	}
+	text := strings.Join(list, ",")
+	if _, ok := field.Interface().(time.Time); ok {
+		var t time.Time
+		var err error
+		for _, layout := range []string{"2006-01-02 -0700", "2006-01-02 15:04 -0700", "2006-01-02", time.RFC3339} {
+			if t, err = time.Parse(layout, text); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		field.Set(reflect.ValueOf(list).Convert(field.Type()))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package larkbot
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestForms(t *testing.T) {
+	type leave struct {
+		Type   string    `form:"type"`
+		Start  time.Time `form:"start"`
+		Days   int       `form:"days"`
+		Notify []string  `form:"notify"`
+	}
+	var got leave
+	forms := &Forms{}
+	forms.Handle("leave", func(values FormValues) {
+		if err := values.Decode(&got); err != nil {
+			t.Error(err)
+		}
+	})
+	handled := forms.HandleCardCallback(httptest.NewRecorder(), CardAction{
+		Action: CardActionDetail{
+			Tag:   "button",
+			Value: map[string]interface{}{formKey: "leave"},
+			FormValue: map[string]interface{}{
+				"type":   "annual",
+				"start":  "2020-01-02 +0800",
+				"days":   "3",
+				"notify": []interface{}{"ou_a", "ou_b"},
+			},
+		},
+	})
+	if !handled {
+		t.Fatal("form should be handled")
+	}
+	start := time.Date(2020, 1, 2, 0, 0, 0, 0, time.FixedZone("", 8*3600))
+	if got.Type != "annual" || !got.Start.Equal(start) || got.Days != 3 || len(got.Notify) != 2 {
+		t.Errorf("wrong values: %+v", got)
+	}
+}
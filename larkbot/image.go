@@ -0,0 +1,56 @@
+package larkbot
+
+import (
+	"io"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// ImageAnalyzer analyzes images of received messages, for example with
+	// OCR or content moderation, and returns a result of any type.
+	ImageAnalyzer func(image io.Reader, event larkslim.EventResponse) (result interface{}, err error)
+
+	// ImageMessage is a received image message with the result of its
+	// ImageAnalyzer.
+	ImageMessage struct {
+		Event    larkslim.EventResponse
+		ImageKey string
+
+		// returned by the analyzer
+		Result interface{}
+
+		// error of downloading or analyzing the image
+		Err error
+	}
+)
+
+// OnImageReceived registers a handler of image messages. Each image is
+// downloaded with api (which requires the im:resource scope) and passed to
+// the analyzer before the handler is called.
+func (d *Dispatcher) OnImageReceived(api *larkslim.API, analyzer ImageAnalyzer, handler func(ImageMessage)) {
+	d.OnMessageReceived(func(event larkslim.EventResponse) {
+		if event.Event.MsgType != "image" {
+			return
+		}
+		m := ImageMessage{Event: event}
+		content, err := larkslim.ParseEventContent(event)
+		if err != nil {
+			m.Err = err
+			handler(m)
+			return
+		}
+		m.ImageKey = content.(larkslim.ImageContent).ImageKey
+		m.Result, m.Err = analyzeImage(api, analyzer, event, m.ImageKey)
+		handler(m)
+	})
+}
+
+func analyzeImage(api *larkslim.API, analyzer ImageAnalyzer, event larkslim.EventResponse, imageKey string) (interface{}, error) {
+	image, err := api.GetMessageResource(event.Event.MessageId, imageKey, "image")
+	if err != nil {
+		return nil, err
+	}
+	defer image.Close()
+	return analyzer(image, event)
+}
@@ -0,0 +1,48 @@
+package larkbot
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+func TestOnImageReceived(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v3/tenant_access_token/internal":
+			io.WriteString(w, `{"code":0,"msg":"ok","tenant_access_token":"t-test","expire":7200}`)
+		case "/im/v1/messages/om_1/resources/img_1":
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, "INVOICE 42")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	api := larkslim.NewAPI("cli_test", "secret")
+	api.BaseURL = server.URL
+	ocr := func(image io.Reader, event larkslim.EventResponse) (interface{}, error) {
+		data, err := ioutil.ReadAll(image)
+		return string(data), err
+	}
+	var got []ImageMessage
+	d := &Dispatcher{}
+	d.OnImageReceived(api, ocr, func(m ImageMessage) {
+		got = append(got, m)
+	})
+	for _, body := range []string{
+		`{"schema":"2.0","header":{"event_type":"im.message.receive_v1"},"event":{"message":{"message_id":"om_1","message_type":"image","content":"{\"image_key\":\"img_1\"}"}}}`,
+		`{"schema":"2.0","header":{"event_type":"im.message.receive_v1"},"event":{"message":{"message_id":"om_2","message_type":"text","content":"{\"text\":\"hi\"}"}}}`,
+	} {
+		if err := d.Dispatch([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != 1 || got[0].Err != nil || got[0].ImageKey != "img_1" || got[0].Result != "INVOICE 42" {
+		t.Errorf("wrong image messages: %+v", got)
+	}
+}
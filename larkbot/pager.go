@@ -0,0 +1,191 @@
+package larkbot
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+// pagerKey is the key of button values of Pager cards.
+const pagerKey = "larkbot_pager"
+
+type (
+	// Pager renders lists as cards with previous and next buttons. Lists
+	// are kept in memory for TTL so that the buttons can re-render pages:
+	//
+	//	pager := &larkbot.Pager{}
+	//	api.SendCard(chatId, pager.Card("Servers", servers, 0))
+	//	server.CardCallbackHandler = func(w http.ResponseWriter, action larkbot.CardAction) {
+	//		if pager.HandleCardCallback(w, action) {
+	//			return
+	//		}
+	//		// other cards
+	//	}
+	Pager struct {
+		// defaults to 10
+		PageSize int
+
+		// header color of cards, for example "blue"
+		Template string
+
+		// how long lists are kept, defaults to 24 hours
+		TTL time.Duration
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		mutex sync.Mutex
+		lists map[string]pagerList
+		next  int
+	}
+
+	pagerList struct {
+		title     string
+		items     []string
+		expiresAt time.Time
+	}
+)
+
+// Card saves the list and returns the card of the page (zero-based). Items
+// are lark_md (markdown) text, one for each line.
+func (p *Pager) Card(title string, items []string, page int) larkslim.Card {
+	now := p.clock().Now()
+	ttl := p.TTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	p.mutex.Lock()
+	if p.lists == nil {
+		p.lists = map[string]pagerList{}
+	}
+	for id, list := range p.lists {
+		if !now.Before(list.expiresAt) {
+			delete(p.lists, id)
+		}
+	}
+	p.next++
+	id := strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.Itoa(p.next)
+	p.lists[id] = pagerList{title, items, now.Add(ttl)}
+	p.mutex.Unlock()
+	return p.render(id, title, items, page)
+}
+
+// HandleCardCallback re-renders the list when previous or next buttons of
+// cards returned by Card are clicked. It returns false if the action is not
+// from a Pager card.
+func (p *Pager) HandleCardCallback(w http.ResponseWriter, action CardAction) bool {
+	id, ok := action.Action.Value[pagerKey].(string)
+	if !ok {
+		return false
+	}
+	page, _ := action.Action.Value["page"].(float64)
+	p.mutex.Lock()
+	list, ok := p.lists[id]
+	p.mutex.Unlock()
+	if !ok || !p.clock().Now().Before(list.expiresAt) {
+		// list expired, keep the card unchanged
+		w.WriteHeader(http.StatusOK)
+		return true
+	}
+	RespondCard(w, p.render(id, list.title, list.items, int(page)))
+	return true
+}
+
+func (p *Pager) render(id, title string, items []string, page int) larkslim.Card {
+	size := p.PageSize
+	if size <= 0 {
+		size = 10
+	}
+	pages := (len(items) + size - 1) / size
+	if pages < 1 {
+		pages = 1
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	start := page * size
+	end := start + size
+	if end > len(items) {
+		end = len(items)
+	}
+	content := strings.Join(items[start:end], "\n")
+	if content == "" {
+		content = "(empty)"
+	}
+	elements := []interface{}{
+		map[string]interface{}{
+			"tag": "div",
+			"text": map[string]interface{}{
+				"tag":     "lark_md",
+				"content": content,
+			},
+		},
+	}
+	if pages > 1 {
+		var buttons []interface{}
+		button := func(text string, page int) map[string]interface{} {
+			return map[string]interface{}{
+				"tag": "button",
+				"text": map[string]interface{}{
+					"tag":     "plain_text",
+					"content": text,
+				},
+				"type": "default",
+				"value": map[string]interface{}{
+					pagerKey: id,
+					"page":   page,
+				},
+			}
+		}
+		if page > 0 {
+			buttons = append(buttons, button("Previous", page-1))
+		}
+		if page < pages-1 {
+			buttons = append(buttons, button("Next", page+1))
+		}
+		elements = append(elements,
+			map[string]interface{}{
+				"tag":     "action",
+				"actions": buttons,
+			},
+			map[string]interface{}{
+				"tag": "note",
+				"elements": []interface{}{
+					map[string]interface{}{
+						"tag":     "plain_text",
+						"content": fmt.Sprintf("Page %d of %d", page+1, pages),
+					},
+				},
+			},
+		)
+	}
+	return larkslim.Card{
+		Config: larkslim.CardConfig{
+			WideScreenMode: true,
+			EnableForward:  true,
+		},
+		Header: larkslim.CardHeader{
+			Title: larkslim.CardHeaderTitle{
+				Tag:     "plain_text",
+				Content: title,
+			},
+			Template: p.Template,
+		},
+		Elements: elements,
+	}
+}
+
+func (p *Pager) clock() larkslim.Clock {
+	if p.Clock != nil {
+		return p.Clock
+	}
+	return larkslim.SystemClock
+}
@@ -0,0 +1,38 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+func TestPager(t *testing.T) {
+	var items []string
+	for i := 1; i <= 25; i++ {
+		items = append(items, fmt.Sprint("item ", i))
+	}
+	pager := &Pager{}
+	card := pager.Card("list", items, 0)
+	if text := card.Text(); text != "list\nitem 1\nitem 2\nitem 3\nitem 4\nitem 5\nitem 6\nitem 7\nitem 8\nitem 9\nitem 10\n[Next]\nPage 1 of 3" {
+		t.Errorf("wrong first page: %q", text)
+	}
+	// simulate clicking next button
+	data, _ := json.Marshal(card.Elements[1].(map[string]interface{})["actions"].([]interface{})[0])
+	var action CardAction
+	json.Unmarshal(data, &action.Action)
+	w := httptest.NewRecorder()
+	if !pager.HandleCardCallback(w, action) {
+		t.Fatal("action should be handled")
+	}
+	var next larkslim.Card
+	json.Unmarshal(w.Body.Bytes(), &next)
+	if text := next.Text(); text != "list\nitem 11\nitem 12\nitem 13\nitem 14\nitem 15\nitem 16\nitem 17\nitem 18\nitem 19\nitem 20\n[Previous]\n[Next]\nPage 2 of 3" {
+		t.Errorf("wrong second page: %q", text)
+	}
+	if pager.HandleCardCallback(httptest.NewRecorder(), CardAction{}) {
+		t.Error("other actions should not be handled")
+	}
+}
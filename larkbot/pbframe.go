@@ -0,0 +1,187 @@
+package larkbot
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// frame methods of the long connection
+const (
+	frameControl = 0
+	frameData    = 1
+)
+
+type (
+	// frame is the protobuf message of the long connection (pbbp2.Frame).
+	frame struct {
+		SeqId           uint64
+		LogId           uint64
+		Service         int32
+		Method          int32
+		Headers         []frameHeader
+		PayloadEncoding string
+		PayloadType     string
+		Payload         []byte
+		LogIdNew        string
+	}
+
+	frameHeader struct {
+		Key   string
+		Value string
+	}
+)
+
+var errBadFrame = errors.New("bad long connection frame")
+
+func (f *frame) header(key string) string {
+	for _, h := range f.Headers {
+		if h.Key == key {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+func (f *frame) setHeader(key, value string) {
+	for i, h := range f.Headers {
+		if h.Key == key {
+			f.Headers[i].Value = value
+			return
+		}
+	}
+	f.Headers = append(f.Headers, frameHeader{key, value})
+}
+
+func (f *frame) marshal() []byte {
+	var b []byte
+	b = appendVarintField(b, 1, f.SeqId)
+	b = appendVarintField(b, 2, f.LogId)
+	b = appendVarintField(b, 3, uint64(f.Service))
+	b = appendVarintField(b, 4, uint64(f.Method))
+	for _, h := range f.Headers {
+		var hb []byte
+		hb = appendBytesField(hb, 1, []byte(h.Key))
+		hb = appendBytesField(hb, 2, []byte(h.Value))
+		b = appendBytesField(b, 5, hb)
+	}
+	if f.PayloadEncoding != "" {
+		b = appendBytesField(b, 6, []byte(f.PayloadEncoding))
+	}
+	if f.PayloadType != "" {
+		b = appendBytesField(b, 7, []byte(f.PayloadType))
+	}
+	if f.Payload != nil {
+		b = appendBytesField(b, 8, f.Payload)
+	}
+	if f.LogIdNew != "" {
+		b = appendBytesField(b, 9, []byte(f.LogIdNew))
+	}
+	return b
+}
+
+func (f *frame) unmarshal(b []byte) error {
+	return readFields(b, func(field int, value uint64, data []byte) error {
+		switch field {
+		case 1:
+			f.SeqId = value
+		case 2:
+			f.LogId = value
+		case 3:
+			f.Service = int32(value)
+		case 4:
+			f.Method = int32(value)
+		case 5:
+			var h frameHeader
+			err := readFields(data, func(field int, _ uint64, data []byte) error {
+				switch field {
+				case 1:
+					h.Key = string(data)
+				case 2:
+					h.Value = string(data)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			f.Headers = append(f.Headers, h)
+		case 6:
+			f.PayloadEncoding = string(data)
+		case 7:
+			f.PayloadType = string(data)
+		case 8:
+			f.Payload = append([]byte(nil), data...)
+		case 9:
+			f.LogIdNew = string(data)
+		}
+		return nil
+	})
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendUvarint(b, uint64(field)<<3)
+	return appendUvarint(b, v)
+}
+
+func appendBytesField(b []byte, field int, data []byte) []byte {
+	b = appendUvarint(b, uint64(field)<<3|2)
+	b = appendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+// readFields calls f with the value of varint fields or the data of length
+// delimited fields of the protobuf message b.
+func readFields(b []byte, f func(field int, value uint64, data []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errBadFrame
+		}
+		b = b[n:]
+		field := int(key >> 3)
+		var value uint64
+		var data []byte
+		switch key & 7 {
+		case 0:
+			value, n = binary.Uvarint(b)
+			if n <= 0 {
+				return errBadFrame
+			}
+			b = b[n:]
+		case 2:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return errBadFrame
+			}
+			data = b[n : n+int(length)]
+			b = b[n+int(length):]
+		case 1:
+			if len(b) < 8 {
+				return errBadFrame
+			}
+			b = b[8:]
+			continue
+		case 5:
+			if len(b) < 4 {
+				return errBadFrame
+			}
+			b = b[4:]
+			continue
+		default:
+			return errBadFrame
+		}
+		if err := f(field, value, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
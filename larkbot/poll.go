@@ -0,0 +1,212 @@
+package larkbot
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+// pollKey is the key of button values of Polls cards.
+const pollKey = "larkbot_poll"
+
+type (
+	// Polls renders polls as cards with a button for each option. Each
+	// user has one vote, which can be changed by clicking another option
+	// until the poll is closed by its creator or with Close. Cards are
+	// updated with live tallies on every vote:
+	//
+	//	polls := &larkbot.Polls{OnClose: func(result larkbot.PollResult) {
+	//		...
+	//	}}
+	//	_, card := polls.Create(openId, "Lunch?", []string{"Pizza", "Sushi"})
+	//	api.SendCard(chatId, card)
+	//	server.CardCallbackHandler = func(w http.ResponseWriter, action larkbot.CardAction) {
+	//		if polls.HandleCardCallback(w, action) {
+	//			return
+	//		}
+	//	}
+	Polls struct {
+		// header color of cards, for example "blue"
+		Template string
+
+		// if set, called when a poll is closed
+		OnClose func(PollResult)
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		mutex sync.Mutex
+		polls map[string]*PollResult
+		next  int
+	}
+
+	// PollResult is the state of a poll.
+	PollResult struct {
+		Id       string
+		Creator  string
+		Question string
+		Options  []string
+
+		// open ids of users to indexes of the options they voted for
+		Votes map[string]int
+
+		Closed bool
+	}
+)
+
+// Create saves a new poll created by the user of the open id, who can close
+// it with the close button of the card, and returns its id and card.
+func (p *Polls) Create(creator, question string, options []string) (id string, card larkslim.Card) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.polls == nil {
+		p.polls = map[string]*PollResult{}
+	}
+	p.next++
+	id = strconv.FormatInt(p.clock().Now().UnixNano(), 36) + "-" + strconv.Itoa(p.next)
+	poll := &PollResult{
+		Id:       id,
+		Creator:  creator,
+		Question: question,
+		Options:  options,
+		Votes:    map[string]int{},
+	}
+	p.polls[id] = poll
+	card = p.render(*poll)
+	return
+}
+
+// Result returns the current state of the poll.
+func (p *Polls) Result(id string) (result PollResult, ok bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	poll, ok := p.polls[id]
+	if ok {
+		result = poll.copy()
+	}
+	return
+}
+
+// Close closes the poll, calls OnClose and returns the final result and
+// card. Polls are removed from memory when they are closed.
+func (p *Polls) Close(id string) (result PollResult, card larkslim.Card, ok bool) {
+	p.mutex.Lock()
+	poll, ok := p.polls[id]
+	if ok {
+		delete(p.polls, id)
+		poll.Closed = true
+		result = poll.copy()
+		card = p.render(result)
+	}
+	p.mutex.Unlock()
+	if ok && p.OnClose != nil {
+		p.OnClose(result)
+	}
+	return
+}
+
+// HandleCardCallback records votes and closes polls when buttons of cards
+// returned by Create are clicked, and responds with the updated card. It
+// returns false if the action is not from a Polls card.
+func (p *Polls) HandleCardCallback(w http.ResponseWriter, action CardAction) bool {
+	id, ok := action.Action.Value[pollKey].(string)
+	if !ok {
+		return false
+	}
+	if closing, _ := action.Action.Value["close"].(bool); closing {
+		p.mutex.Lock()
+		poll, ok := p.polls[id]
+		allowed := ok && poll.Creator == action.OpenId
+		p.mutex.Unlock()
+		if !allowed {
+			w.WriteHeader(http.StatusOK)
+			return true
+		}
+		_, card, _ := p.Close(id)
+		RespondCard(w, card)
+		return true
+	}
+	option, _ := action.Action.Value["option"].(float64)
+	p.mutex.Lock()
+	poll, ok := p.polls[id]
+	if !ok || int(option) < 0 || int(option) >= len(poll.Options) || action.OpenId == "" {
+		// closed, expired or invalid vote, keep the card unchanged
+		p.mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+		return true
+	}
+	poll.Votes[action.OpenId] = int(option)
+	card := p.render(*poll)
+	p.mutex.Unlock()
+	RespondCard(w, card)
+	return true
+}
+
+// Tally returns the number of votes of each option.
+func (result PollResult) Tally() []int {
+	tally := make([]int, len(result.Options))
+	for _, option := range result.Votes {
+		if option >= 0 && option < len(tally) {
+			tally[option]++
+		}
+	}
+	return tally
+}
+
+func (result PollResult) copy() PollResult {
+	votes := make(map[string]int, len(result.Votes))
+	for user, option := range result.Votes {
+		votes[user] = option
+	}
+	result.Votes = votes
+	return result
+}
+
+func (p *Polls) render(poll PollResult) larkslim.Card {
+	tally := poll.Tally()
+	var lines []string
+	for i, option := range poll.Options {
+		bar := ""
+		if len(poll.Votes) > 0 {
+			bar = strings.Repeat("▇", tally[i]*10/len(poll.Votes)) + " "
+		}
+		lines = append(lines, fmt.Sprintf("**%s**  %s%d", option, bar, tally[i]))
+	}
+	// the card is shared, so that everyone sees the live tally
+	builder := larkslim.NewCardBuilder(poll.Question).
+		Template(p.Template).
+		UpdateMulti().
+		Markdown(strings.Join(lines, "\n"))
+	if poll.Closed {
+		builder.Note(fmt.Sprintf("Closed with %d votes", len(poll.Votes)))
+		return builder.Build()
+	}
+	var buttons []larkslim.CardActionElement
+	for i, option := range poll.Options {
+		buttons = append(buttons, larkslim.CardButton{
+			Text:  larkslim.PlainText(option),
+			Type:  "default",
+			Value: map[string]interface{}{pollKey: poll.Id, "option": i},
+		})
+	}
+	buttons = append(buttons, larkslim.CardButton{
+		Text:  larkslim.PlainText("Close poll"),
+		Type:  "danger",
+		Value: map[string]interface{}{pollKey: poll.Id, "close": true},
+	})
+	return builder.
+		Actions(buttons...).
+		Note(fmt.Sprintf("%d votes", len(poll.Votes))).
+		Build()
+}
+
+func (p *Polls) clock() larkslim.Clock {
+	if p.Clock != nil {
+		return p.Clock
+	}
+	return larkslim.SystemClock
+}
@@ -0,0 +1,62 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+func TestPolls(t *testing.T) {
+	var closed []PollResult
+	polls := &Polls{
+		OnClose: func(result PollResult) {
+			closed = append(closed, result)
+		},
+	}
+	id, card := polls.Create("ou_owner", "Lunch?", []string{"Pizza", "Sushi"})
+	if text := card.Text(); text != "Lunch?\n**Pizza**  0\n**Sushi**  0\n[Pizza]\n[Sushi]\n[Close poll]\n0 votes" {
+		t.Errorf("wrong card: %q", text)
+	}
+	if data, _ := json.Marshal(card); !strings.Contains(string(data), `"update_multi":true`) {
+		t.Errorf("poll card should be shared: %s", data)
+	}
+	vote := func(openId string, value map[string]interface{}) string {
+		// values are sent back as JSON
+		data, _ := json.Marshal(value)
+		action := CardAction{OpenId: openId}
+		json.Unmarshal(data, &action.Action.Value)
+		w := httptest.NewRecorder()
+		if !polls.HandleCardCallback(w, action) {
+			t.Fatal("action should be handled")
+		}
+		var card larkslim.Card
+		json.Unmarshal(w.Body.Bytes(), &card)
+		return card.Text()
+	}
+	vote("ou_a", map[string]interface{}{pollKey: id, "option": 0})
+	vote("ou_b", map[string]interface{}{pollKey: id, "option": 0})
+	text := vote("ou_a", map[string]interface{}{pollKey: id, "option": 1})
+	if !strings.Contains(text, "**Pizza**  ▇▇▇▇▇ 1\n**Sushi**  ▇▇▇▇▇ 1") || !strings.HasSuffix(text, "2 votes") {
+		t.Errorf("wrong tally: %q", text)
+	}
+	if result, _ := polls.Result(id); result.Votes["ou_a"] != 1 || len(result.Votes) != 2 {
+		t.Errorf("wrong votes: %v", result.Votes)
+	}
+	vote("ou_a", map[string]interface{}{pollKey: id, "close": true})
+	if len(closed) != 0 {
+		t.Fatal("only creator can close the poll")
+	}
+	text = vote("ou_owner", map[string]interface{}{pollKey: id, "close": true})
+	if text != "Lunch?\n**Pizza**  ▇▇▇▇▇ 1\n**Sushi**  ▇▇▇▇▇ 1\nClosed with 2 votes" {
+		t.Errorf("wrong closed card: %q", text)
+	}
+	if len(closed) != 1 || !closed[0].Closed || closed[0].Tally()[0] != 1 {
+		t.Errorf("wrong result: %+v", closed)
+	}
+	if polls.HandleCardCallback(httptest.NewRecorder(), CardAction{}) {
+		t.Error("other actions should not be handled")
+	}
+}
@@ -0,0 +1,94 @@
+package larkbot
+
+import (
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// QueueStats describes the event queue of a Server with Workers.
+	QueueStats struct {
+		// number of events waiting for workers
+		Depth int
+
+		Capacity int
+
+		// number of events rejected with status 503 because the queue
+		// was full
+		Rejected int64
+	}
+
+	queuedEvent struct {
+		event larkslim.EventResponse
+		body  []byte
+	}
+)
+
+// QueueStats returns the current state of the event queue, for example to
+// export as metrics.
+func (h *Server) QueueStats() QueueStats {
+	h.startWorkers()
+	h.rejectedMutex.Lock()
+	defer h.rejectedMutex.Unlock()
+	return QueueStats{
+		Depth:    len(h.queue),
+		Capacity: cap(h.queue),
+		Rejected: h.rejected,
+	}
+}
+
+// reserve reserves a place in the queue of workers for an event, it
+// returns false if the queue is full. The place must be filled with enqueue
+// or given up with release.
+func (h *Server) reserve() bool {
+	h.startWorkers()
+	select {
+	case h.slots <- struct{}{}:
+		return true
+	default:
+		h.rejectedMutex.Lock()
+		h.rejected++
+		h.rejectedMutex.Unlock()
+		return false
+	}
+}
+
+func (h *Server) release() {
+	<-h.slots
+}
+
+// enqueue adds the event to the queue of workers in the place reserved by
+// reserve, so it never blocks.
+func (h *Server) enqueue(event larkslim.EventResponse, body []byte) {
+	h.queue <- queuedEvent{event, body}
+}
+
+func (h *Server) startWorkers() {
+	h.workersOnce.Do(func() {
+		size := h.QueueSize
+		if size <= 0 {
+			size = h.Workers
+		}
+		h.queue = make(chan queuedEvent, size)
+		h.slots = make(chan struct{}, size)
+		for i := 0; i < h.Workers; i++ {
+			go func() {
+				for e := range h.queue {
+					h.release()
+					h.handleEvent(e.event, e.body)
+				}
+			}()
+		}
+	})
+}
+
+// handleEvent passes the event to EventCallbackHandler and Dispatcher.
+func (h *Server) handleEvent(event larkslim.EventResponse, body []byte) {
+	if h.EventCallbackHandler != nil {
+		h.EventCallbackHandler(event)
+	}
+	if h.Dispatcher != nil {
+		if err := h.Dispatcher.Dispatch(body); err != nil && h.Logger != nil {
+			h.Logger.Error(err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package larkbot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type memorySink struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (s *memorySink) WriteEvent(body []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, string(body))
+	return nil
+}
+
+func TestServerWorkers(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan string, 3)
+	sink := &memorySink{}
+	h := &Server{
+		Workers:   1,
+		QueueSize: 1,
+		EventSink: sink,
+		EventCallbackHandler: func(event larkslim.EventResponse) {
+			<-release
+			handled <- event.Event.Text
+		},
+	}
+	post := func(text string) *httptest.ResponseRecorder {
+		body := `{"type":"event_callback","event":{"type":"message","text":"` + text + `"}}`
+		w := httptest.NewRecorder()
+		h.handleLarkEvents(w, httptest.NewRequest("POST", "/events/", strings.NewReader(body)))
+		return w
+	}
+	// the first event is taken by the worker, the second one waits in the
+	// queue, wait until the worker has taken the first one
+	if w := post("1"); w.Code != http.StatusNoContent {
+		t.Fatalf("first event should be accepted, got %d", w.Code)
+	}
+	for h.QueueStats().Depth != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if w := post("2"); w.Code != http.StatusNoContent {
+		t.Fatalf("second event should be queued, got %d", w.Code)
+	}
+	w := post("3")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("third event should be rejected with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if stats := h.QueueStats(); stats.Depth != 1 || stats.Capacity != 1 || stats.Rejected != 1 {
+		t.Errorf("wrong queue stats: %+v", stats)
+	}
+	// rejected events are delivered again, so they are not persisted
+	if len(sink.events) != 2 {
+		t.Errorf("only accepted events should be persisted, got %d", len(sink.events))
+	}
+	close(release)
+	if a, b := <-handled, <-handled; a != "1" || b != "2" {
+		t.Errorf("wrong handled events: %s %s", a, b)
+	}
+}
@@ -0,0 +1,103 @@
+package larkbot
+
+import (
+	"sync"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// ReactionTriggers runs handlers when users add reactions of certain
+	// emojis to messages in certain chats, for lightweight approvals such
+	// as resolving an alert with a DONE reaction:
+	//
+	//	triggers := &larkbot.ReactionTriggers{API: api}
+	//	triggers.Handle("DONE", "oc_alerts", func(r larkbot.Reaction) {
+	//		resolve(r.Message)
+	//	})
+	//	triggers.Register(dispatcher)
+	ReactionTriggers struct {
+		// used to get the chat of the message, since reaction events
+		// only have the message id
+		API *larkslim.API
+
+		// also trigger on reactions of bots
+		IncludeBots bool
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+
+		mutex    sync.Mutex
+		triggers []reactionTrigger
+	}
+
+	// Reaction is a reaction that triggered a handler.
+	Reaction struct {
+		Event ReactionEvent
+
+		// the message reacted to
+		Message larkslim.Message
+	}
+
+	reactionTrigger struct {
+		emojiType string
+		chatId    string
+		handler   func(Reaction)
+	}
+)
+
+// Handle registers a handler of reactions of emojiType, for example "DONE"
+// or "THUMBSUP", added to messages in the chat, or in any chat if chatId is
+// empty.
+func (t *ReactionTriggers) Handle(emojiType, chatId string, handler func(Reaction)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.triggers = append(t.triggers, reactionTrigger{emojiType, chatId, handler})
+}
+
+// Register registers HandleEvent as the handler of added reactions on the
+// dispatcher.
+func (t *ReactionTriggers) Register(d *Dispatcher) {
+	d.OnReactionAdded(t.HandleEvent)
+}
+
+// HandleEvent calls handlers matching the emoji of the reaction and the chat
+// of the message.
+func (t *ReactionTriggers) HandleEvent(e ReactionEvent) {
+	if e.OperatorType == "app" && !t.IncludeBots {
+		return
+	}
+	var triggers []reactionTrigger
+	t.mutex.Lock()
+	for _, trigger := range t.triggers {
+		if trigger.emojiType == e.ReactionType.EmojiType {
+			triggers = append(triggers, trigger)
+		}
+	}
+	t.mutex.Unlock()
+	if len(triggers) == 0 {
+		return
+	}
+	reaction := Reaction{Event: e}
+	reaction.Message.MessageId = e.MessageId
+	if t.API != nil {
+		message, err := t.API.GetMessage(e.MessageId)
+		if err != nil {
+			t.error(err)
+			return
+		}
+		reaction.Message = message
+	}
+	for _, trigger := range triggers {
+		if trigger.chatId == "" || trigger.chatId == reaction.Message.ChatId {
+			trigger.handler(reaction)
+		}
+	}
+}
+
+func (t *ReactionTriggers) error(args ...interface{}) {
+	if t.Logger != nil {
+		t.Logger.Error(args...)
+	}
+}
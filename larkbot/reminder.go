@@ -0,0 +1,301 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Reminders sends reminders to users or chats at given times. Due
+	// reminders are delivered every minute by the scheduler:
+	//
+	//	reminders := &larkbot.Reminders{API: api, Cache: cache}
+	//	reminders.Register(scheduler)
+	//	router.Handle("remind", larkbot.Policy{}, reminders.HandleCommand)
+	//
+	// Reminders are stored as one value in Cache and Cache has no atomic
+	// operations, so only one replica of the bot may use Reminders: other
+	// replicas would deliver the same reminders and overwrite each other's
+	// changes.
+	Reminders struct {
+		API *larkslim.API
+
+		// if set, reminders are stored in it, for example a Redis cache,
+		// so they survive restarts, otherwise they are kept in memory
+		Cache larkslim.Cache
+
+		// key of reminders in Cache, defaults to "larkbot:reminders"
+		CacheKey string
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+
+		mutex     sync.Mutex
+		loaded    bool
+		reminders []Reminder
+		next      int
+	}
+
+	// Reminder is a message to send to Target at At.
+	Reminder struct {
+		Id     string    `json:"id"`
+		Target string    `json:"target"`
+		Text   string    `json:"text"`
+		At     time.Time `json:"at"`
+
+		// open id of the user who created the reminder
+		Creator string `json:"creator,omitempty"`
+	}
+)
+
+const reminderUsage = "Usage: remind me|here in 2h|at 2006-01-02T15:04:05+08:00 <text>, remind list, remind cancel <id>"
+
+// ParseReminderTime parses relative times like "in 2h", "in 1h30m" or "in
+// 3d", and absolute times in RFC 3339 like "2020-01-02T09:00:00+08:00".
+func ParseReminderTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(strings.TrimPrefix(s, "at "))); err == nil {
+		return t, nil
+	}
+	s = strings.TrimSpace(strings.TrimPrefix(s, "in "))
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err == nil && days > 0 {
+			return now.AddDate(0, 0, days), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("bad reminder time %q", s)
+	}
+	return now.Add(d), nil
+}
+
+// Add saves a reminder of text to the target (open id, chat id or email).
+func (r *Reminders) Add(target, text string, at time.Time, creator string) (reminder Reminder, err error) {
+	now := r.clock().Now()
+	if !at.After(now) {
+		err = errors.New("reminder time is in the past")
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err = r.load(); err != nil {
+		return
+	}
+	r.next++
+	reminder = Reminder{
+		Id:      strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.Itoa(r.next),
+		Target:  target,
+		Text:    text,
+		At:      at,
+		Creator: creator,
+	}
+	r.reminders = append(r.reminders, reminder)
+	sort.SliceStable(r.reminders, func(i, j int) bool {
+		return r.reminders[i].At.Before(r.reminders[j].At)
+	})
+	err = r.save()
+	return
+}
+
+// List returns pending reminders created by the user of the open id, or
+// all pending reminders if creator is empty.
+func (r *Reminders) List(creator string) (reminders []Reminder) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err := r.load(); err != nil {
+		r.error(err)
+	}
+	for _, reminder := range r.reminders {
+		if creator == "" || reminder.Creator == creator {
+			reminders = append(reminders, reminder)
+		}
+	}
+	return
+}
+
+// Cancel removes the pending reminder of the id created by the user of the
+// open id, or by anyone if creator is empty. It returns false if there is
+// no such reminder.
+func (r *Reminders) Cancel(id, creator string) (ok bool, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err = r.load(); err != nil {
+		return
+	}
+	for i, reminder := range r.reminders {
+		if reminder.Id == id && (creator == "" || reminder.Creator == creator) {
+			r.reminders = append(r.reminders[:i], r.reminders[i+1:]...)
+			ok = true
+			err = r.save()
+			return
+		}
+	}
+	return
+}
+
+// Deliver sends due reminders. Reminders failed to send are kept and sent
+// again on the next delivery.
+func (r *Reminders) Deliver() error {
+	now := r.clock().Now()
+	r.mutex.Lock()
+	if err := r.load(); err != nil {
+		r.mutex.Unlock()
+		return err
+	}
+	var due []Reminder
+	for _, reminder := range r.reminders {
+		if reminder.At.After(now) {
+			break
+		}
+		due = append(due, reminder)
+	}
+	r.mutex.Unlock()
+	var lastErr error
+	for _, reminder := range due {
+		if _, err := r.API.SendMessage(reminder.Target, reminder.Text); err != nil {
+			r.error("reminder", reminder.Id, err)
+			lastErr = err
+			continue
+		}
+		if _, err := r.Cancel(reminder.Id, ""); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// Register adds a job delivering due reminders every minute to the
+// scheduler.
+func (r *Reminders) Register(s *Scheduler) {
+	s.Every("* * * * *", func(api *larkslim.API) error {
+		return r.Deliver()
+	})
+}
+
+// HandleCommand handles the commands "remind me in 2h <text>", "remind here
+// at <RFC 3339 time> <text>", "remind list" and "remind cancel <id>".
+// Register it on a Router.
+func (r *Reminders) HandleCommand(cmd Command) {
+	reply := func(text string) {
+		if err := cmd.Reply(text); err != nil {
+			r.error(err)
+		}
+	}
+	openId := cmd.Event.Event.OpenId
+	if len(cmd.Args) == 1 && cmd.Args[0] == "list" {
+		reminders := r.List(openId)
+		if len(reminders) == 0 {
+			reply("No reminders.")
+			return
+		}
+		var lines []string
+		for _, reminder := range reminders {
+			lines = append(lines, reminder.Id+" "+reminder.At.Format(time.RFC3339)+" "+reminder.Text)
+		}
+		reply(strings.Join(lines, "\n"))
+		return
+	}
+	if len(cmd.Args) == 2 && cmd.Args[0] == "cancel" {
+		ok, err := r.Cancel(cmd.Args[1], openId)
+		if err != nil {
+			r.error(err)
+		}
+		if !ok {
+			reply("Reminder not found.")
+			return
+		}
+		reply("Reminder cancelled.")
+		return
+	}
+	if len(cmd.Args) < 4 || (cmd.Args[1] != "in" && cmd.Args[1] != "at") {
+		reply(reminderUsage)
+		return
+	}
+	var target string
+	switch cmd.Args[0] {
+	case "me":
+		target = openId
+	case "here":
+		target = cmd.Event.Event.ChatId
+	default:
+		reply(reminderUsage)
+		return
+	}
+	at, err := ParseReminderTime(cmd.Args[1]+" "+cmd.Args[2], r.clock().Now())
+	if err != nil {
+		reply(err.Error())
+		return
+	}
+	reminder, err := r.Add(target, strings.Join(cmd.Args[3:], " "), at, openId)
+	if err != nil {
+		reply(err.Error())
+		return
+	}
+	reply("Reminder " + reminder.Id + " set for " + reminder.At.Format(time.RFC3339) + ".")
+}
+
+// save writes reminders to Cache, with mutex held.
+func (r *Reminders) save() error {
+	if r.Cache == nil {
+		return nil
+	}
+	data, err := json.Marshal(r.reminders)
+	if err != nil {
+		return err
+	}
+	return r.Cache.Set(r.cacheKey(), data, 0)
+}
+
+// load reads reminders from Cache once, with mutex held.
+func (r *Reminders) load() error {
+	if r.loaded || r.Cache == nil {
+		return nil
+	}
+	data, ok, err := r.Cache.Get(r.cacheKey())
+	if err != nil {
+		return err
+	}
+	var reminders []Reminder
+	if ok {
+		if err := json.Unmarshal(data, &reminders); err != nil {
+			return err
+		}
+	}
+	r.reminders = reminders
+	r.loaded = true
+	return nil
+}
+
+func (r *Reminders) cacheKey() string {
+	if r.CacheKey != "" {
+		return r.CacheKey
+	}
+	return "larkbot:reminders"
+}
+
+func (r *Reminders) clock() larkslim.Clock {
+	if r.Clock != nil {
+		return r.Clock
+	}
+	return larkslim.SystemClock
+}
+
+func (r *Reminders) error(args ...interface{}) {
+	if r.Logger != nil {
+		r.Logger.Error(args...)
+	}
+}
@@ -0,0 +1,65 @@
+package larkbot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func TestParseReminderTime(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for s, want := range map[string]time.Time{
+		"in 2h":                        now.Add(2 * time.Hour),
+		"in 1h30m":                     now.Add(90 * time.Minute),
+		"3d":                           now.AddDate(0, 0, 3),
+		"at 2020-01-02T09:00:00+08:00": time.Date(2020, 1, 2, 1, 0, 0, 0, time.UTC),
+	} {
+		got, err := ParseReminderTime(s, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("%s: got %s, want %s", s, got, want)
+		}
+	}
+	if _, err := ParseReminderTime("in -1h", now); err == nil {
+		t.Error("negative time should be rejected")
+	}
+}
+
+func TestReminders(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	clock := larktest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	cache := &larkslim.MemoryCache{}
+	reminders := &Reminders{API: server.API(), Cache: cache, Clock: clock}
+	router := &Router{API: server.API(), Clock: clock}
+	router.Handle("remind", Policy{}, reminders.HandleCommand)
+	router.HandleEvent(textMessage("ou_a", "/remind me in 2h check the build"))
+	router.HandleEvent(textMessage("ou_a", "/remind here in 1d standup"))
+	if list := reminders.List("ou_a"); len(list) != 2 || list[0].Target != "ou_a" || list[1].Target != "oc_test" {
+		t.Fatalf("wrong reminders: %+v", list)
+	}
+
+	// reload from the cache
+	reminders = &Reminders{API: server.API(), Cache: cache, Clock: clock}
+	clock.Advance(3 * time.Hour)
+	if err := reminders.Deliver(); err != nil {
+		t.Fatal(err)
+	}
+	if list := reminders.List(""); len(list) != 1 || list[0].Text != "standup" {
+		t.Errorf("wrong pending reminders: %+v", list)
+	}
+	var sent []string
+	for _, req := range server.Requests() {
+		if req.Path == "/message/v4/send/" && strings.Contains(string(req.Body), "check the build") {
+			sent = append(sent, string(req.Body))
+		}
+	}
+	if len(sent) != 1 || !strings.Contains(sent[0], `"open_id":"ou_a"`) {
+		t.Errorf("wrong reminders sent: %q", sent)
+	}
+}
@@ -0,0 +1,96 @@
+package larkbot
+
+import (
+	"fmt"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// ChatRepair repairs local state when the bot loses a chat, that is,
+	// when the bot is removed from the chat or the chat is disbanded.
+	// Register it on a Dispatcher:
+	//
+	//	repair := &larkbot.ChatRepair{API: api, Admins: []string{"ou_xxx"}}
+	//	repair.Register(dispatcher)
+	ChatRepair struct {
+		// if set, cached chat info and chat list are invalidated and
+		// Admins are notified
+		API *larkslim.API
+
+		// open ids, emails or chat ids to notify
+		Admins []string
+
+		// if set, called after the built-in repairs, for example to
+		// remove the chat from subscriptions or to ask someone to add
+		// the bot back
+		Policy func(ChatLostEvent)
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+	}
+
+	// ChatLostEvent is a remove_bot or chat_disband event.
+	ChatLostEvent struct {
+		// "remove_bot" or "chat_disband"
+		Type     string
+		ChatId   string
+		ChatName string
+
+		// open id of the user who removed the bot or disbanded the chat
+		OperatorOpenId string
+	}
+)
+
+// Register registers handlers of remove_bot and chat_disband events of
+// schema 1.0 and 2.0 on the dispatcher.
+func (r *ChatRepair) Register(d *Dispatcher) {
+	d.OnBotRemoved(func(e BotChatEvent) {
+		r.repair(ChatLostEvent{
+			Type:           "remove_bot",
+			ChatId:         e.ChatId,
+			ChatName:       e.ChatName,
+			OperatorOpenId: e.OperatorOpenId,
+		})
+	})
+	d.OnChatDisbanded(func(e ChatDisbandedEvent) {
+		r.repair(ChatLostEvent{
+			Type:           "chat_disband",
+			ChatId:         e.ChatId,
+			OperatorOpenId: e.Operator.OpenId,
+		})
+	})
+}
+
+func (r *ChatRepair) repair(e ChatLostEvent) {
+	if r.API != nil {
+		r.API.InvalidateChat(e.ChatId)
+		for _, admin := range r.Admins {
+			if _, err := r.API.SendMessage(admin, e.String()); err != nil {
+				r.error(err)
+			}
+		}
+	}
+	if r.Policy != nil {
+		r.Policy(e)
+	}
+}
+
+func (r *ChatRepair) error(args ...interface{}) {
+	if r.Logger != nil {
+		r.Logger.Error(args...)
+	}
+}
+
+// String returns the notification sent to admins.
+func (e ChatLostEvent) String() string {
+	chat := e.ChatId
+	if e.ChatName != "" {
+		chat = fmt.Sprintf("%s (%s)", e.ChatName, e.ChatId)
+	}
+	if e.Type == "chat_disband" {
+		return fmt.Sprintf("Chat %s was disbanded by %s", chat, e.OperatorOpenId)
+	}
+	return fmt.Sprintf("Bot was removed from chat %s by %s", chat, e.OperatorOpenId)
+}
@@ -0,0 +1,120 @@
+package larkbot
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SelfTestEventType is the event type of the synthetic event sent by
+// SelfTest. Event handlers may want to ignore it.
+const SelfTestEventType = "larkbot_self_test"
+
+// SelfTest sends a synthetic url_verification request and a signed
+// event_callback to the events endpoint at url, the same way the open
+// platform does, and returns an error if the endpoint does not handle them
+// properly. Use the encrypt key and verification token configured in the
+// developer console to catch misconfigured servers before real traffic
+// arrives.
+func SelfTest(url, encryptKey, token string) error {
+	challenge := strconv.FormatInt(time.Now().UnixNano(), 36)
+	resp, err := selfTestPost(url, encryptKey, map[string]string{
+		"type":      "url_verification",
+		"token":     token,
+		"challenge": challenge,
+	})
+	if err != nil {
+		return err
+	}
+	var verification struct {
+		Challenge string `json:"challenge"`
+	}
+	if err := json.Unmarshal(resp, &verification); err != nil {
+		return fmt.Errorf("url_verification: bad response: %s", resp)
+	}
+	if verification.Challenge != challenge {
+		return fmt.Errorf("url_verification: challenge mismatch: %s", resp)
+	}
+
+	_, err = selfTestPost(url, encryptKey, map[string]interface{}{
+		"type":  "event_callback",
+		"token": token,
+		"event": map[string]string{
+			"type": SelfTestEventType,
+		},
+	})
+	return err
+}
+
+func selfTestPost(url, encryptKey string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if encryptKey != "" {
+		encrypted, err := encrypt(encryptKey, body)
+		if err != nil {
+			return nil, err
+		}
+		body, err = json.Marshal(map[string]string{
+			"encrypt": encrypted,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := strconv.FormatInt(time.Now().UnixNano(), 36)
+	sig := sha256.Sum256([]byte(timestamp + nonce + encryptKey + string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Lark-Request-Timestamp", timestamp)
+	req.Header.Set("X-Lark-Request-Nonce", nonce)
+	req.Header.Set("X-Lark-Signature", hex.EncodeToString(sig[:]))
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return respBody, nil
+}
+
+func encrypt(encryptKey string, plainText []byte) (string, error) {
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", err
+	}
+	padding := aes.BlockSize - len(plainText)%aes.BlockSize
+	plainText = append(plainText, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipherText := make([]byte, aes.BlockSize+len(plainText))
+	iv := cipherText[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(cipherText[aes.BlockSize:], plainText)
+	return base64.StdEncoding.EncodeToString(cipherText), nil
+}
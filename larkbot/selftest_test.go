@@ -0,0 +1,60 @@
+package larkbot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type errorLogger struct {
+	errors []string
+}
+
+func (l *errorLogger) Debug(args ...interface{}) {}
+func (l *errorLogger) Info(args ...interface{})  {}
+func (l *errorLogger) Fatal(args ...interface{}) {}
+
+func (l *errorLogger) Error(args ...interface{}) {
+	l.errors = append(l.errors, fmt.Sprint(args...))
+}
+
+func TestSelfTest(t *testing.T) {
+	var received []larkslim.EventResponse
+	logger := &errorLogger{}
+	h := &Server{
+		EventEncrytionKey:      "key",
+		EventVerificationToken: "token",
+		EventCallbackHandler: func(event larkslim.EventResponse) {
+			received = append(received, event)
+		},
+		Logger: logger,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(h.handleLarkEvents))
+	defer ts.Close()
+
+	if err := SelfTest(ts.URL, "key", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.errors) > 0 {
+		t.Fatalf("server should accept requests of self test: %q", logger.errors)
+	}
+	if len(received) != 1 {
+		t.Fatalf("should receive one event, got %d", len(received))
+	}
+	if e := received[0]; e.Type != "event_callback" || e.Token != "token" || e.Event.Type != SelfTestEventType {
+		t.Errorf("wrong event received: %+v", e)
+	}
+
+	if err := SelfTest(ts.URL, "key", "wrong"); err == nil {
+		t.Error("wrong token should fail")
+	}
+	if len(logger.errors) != 1 || logger.errors[0] != "wrong verification token" {
+		t.Errorf("server should reject wrong token: %q", logger.errors)
+	}
+	if len(received) != 1 {
+		t.Errorf("should not receive events with wrong token, got %d", len(received))
+	}
+}
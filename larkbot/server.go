@@ -11,7 +11,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/caiguanhao/larkslim"
@@ -20,25 +22,80 @@ import (
 type (
 	Server struct {
 		GetAccessToken         func() (int, error)
-		CardCallbackHandler    func(http.ResponseWriter, interface{})
+		CardCallbackHandler    func(http.ResponseWriter, CardAction)
 		EventCallbackHandler   func(larkslim.EventResponse)
+		Dispatcher             *Dispatcher
+		EventSink              EventSink
+		EventFilter            *EventFilter
+		Transcript             larkslim.TranscriptStore
 		EventEncrytionKey      string
 		EventVerificationToken string
 
+		// open_id of the bot, if set, only mentions of the bot are
+		// removed from TextWithoutAtBot, see larkslim.ParseEventForBot
+		BotOpenId string
+
+		// if true, events of schema 1.0 containing fields unknown to
+		// EventResponse are rejected, to detect event schema changes in
+		// tests
+		StrictEvents bool
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		// if greater than zero, events are handled by this number of
+		// goroutines after they are acknowledged
+		Workers int
+
+		// maximum number of events waiting for Workers, defaults to
+		// Workers; events arriving when the queue is full are rejected
+		// with status 503, so that the open platform delivers them again
+		QueueSize int
+
+		// Retry-After of rejected events, defaults to 1 second
+		RetryAfter time.Duration
+
+		// if set, callback requests from other addresses are rejected
+		// with status 403; call IPAllowlist.Run to refresh it
+		IPAllowlist *IPAllowlist
+
+		// path to JSON file to persist url_verification status of callback
+		// paths, empty to keep it in memory
+		VerificationStateFile string
+
 		Logger interface {
 			Debug(args ...interface{})
 			Info(args ...interface{})
 			Error(args ...interface{})
 			Fatal(args ...interface{})
 		}
+
+		workersOnce   sync.Once
+		queue         chan queuedEvent
+		slots         chan struct{}
+		rejectedMutex sync.Mutex
+		rejected      int64
+
+		verificationsOnce  sync.Once
+		verificationsMutex sync.Mutex
+		verifications      map[string]Verification
+	}
+
+	// EventSink persists events (for example to Kafka, NATS or SQS) before
+	// they are acknowledged. Body is the decrypted JSON of the event. If
+	// WriteEvent returns an error, the server responds with status 500 so
+	// that the open platform will deliver the event again.
+	EventSink interface {
+		WriteEvent(body []byte) error
 	}
 )
 
 func (h *Server) Serve(address string) {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/cards/", h.handleLarkCards)
-	mux.HandleFunc("/events/", h.handleLarkEvents)
+	mux.HandleFunc("/cards/", h.allowSource(h.handleLarkCards))
+	mux.HandleFunc("/events/", h.allowSource(h.handleLarkEvents))
 	mux.HandleFunc("/204/", h.handle204)
+	mux.HandleFunc("/health", h.handleHealth)
 	mux.HandleFunc("/", h.handle404)
 	server := &http.Server{
 		Addr:    address,
@@ -56,7 +113,7 @@ func (h *Server) updateAccessToken() {
 		return
 	}
 	defer func() {
-		time.Sleep(5 * time.Second)
+		h.clock().Sleep(5 * time.Second)
 		h.updateAccessToken()
 	}()
 	expire, err := h.GetAccessToken()
@@ -73,7 +130,14 @@ func (h *Server) updateAccessToken() {
 	if h.Logger != nil {
 		h.Logger.Info("update access token in", secs, "seconds")
 	}
-	time.Sleep(time.Duration(secs) * time.Second)
+	h.clock().Sleep(time.Duration(secs) * time.Second)
+}
+
+func (h *Server) clock() larkslim.Clock {
+	if h.Clock != nil {
+		return h.Clock
+	}
+	return larkslim.SystemClock
 }
 
 func (h *Server) handleLarkCards(w http.ResponseWriter, r *http.Request) {
@@ -114,12 +178,15 @@ func (h *Server) handleLarkCards(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		if h.EventVerificationToken != "" && h.EventVerificationToken != token {
-			returnError(errors.New("wrong verification token"))
+			err := errors.New("wrong verification token")
+			h.verified(r.URL.Path, err)
+			returnError(err)
 			return
 		}
 		if data, err := json.Marshal(map[string]interface{}{
 			"challenge": resp["challenge"],
 		}); err == nil {
+			h.verified(r.URL.Path, nil)
 			w.Header().Set("Content-Type", "application/json")
 			fmt.Fprint(w, string(data))
 			return
@@ -142,9 +209,14 @@ func (h *Server) handleLarkCards(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if v, ok := resp["action"]; ok {
+	if _, ok := resp["action"]; ok {
 		if h.CardCallbackHandler != nil {
-			h.CardCallbackHandler(w, v)
+			var action CardAction
+			if err := json.Unmarshal(body, &action); err != nil {
+				returnError(err)
+				return
+			}
+			h.CardCallbackHandler(w, action)
 			return
 		}
 	}
@@ -190,8 +262,8 @@ func (h *Server) handleLarkEvents(w http.ResponseWriter, r *http.Request) {
 		body = cipherText[:bufLen] // unpad
 	}
 
-	var resp larkslim.EventResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
+	resp, err := larkslim.ParseEventForBot(body, h.StrictEvents, h.BotOpenId)
+	if err != nil {
 		returnError(err)
 		return
 	}
@@ -199,7 +271,11 @@ func (h *Server) handleLarkEvents(w http.ResponseWriter, r *http.Request) {
 		h.Logger.Debug(string(body))
 	}
 	if h.EventVerificationToken != "" && h.EventVerificationToken != resp.Token {
-		returnError(errors.New("wrong verification token"))
+		err := errors.New("wrong verification token")
+		if resp.Type == "url_verification" {
+			h.verified(r.URL.Path, err)
+		}
+		returnError(err)
 		return
 	}
 	switch resp.Type {
@@ -207,18 +283,75 @@ func (h *Server) handleLarkEvents(w http.ResponseWriter, r *http.Request) {
 		if data, err := json.Marshal(map[string]string{
 			"challenge": resp.Challenge,
 		}); err == nil {
+			h.verified(r.URL.Path, nil)
 			w.Header().Set("Content-Type", "application/json")
 			fmt.Fprint(w, string(data))
 			return
 		}
 	case "event_callback":
-		if h.EventCallbackHandler != nil {
-			h.EventCallbackHandler(resp)
+		status := h.processEvent(resp, body)
+		if status == http.StatusServiceUnavailable {
+			retryAfter := h.RetryAfter
+			if retryAfter <= 0 {
+				retryAfter = time.Second
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
 		}
+		w.WriteHeader(status)
+		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// processEvent filters, records, persists and handles the event, and
+// returns the status of the response to the open platform. An event is
+// recorded and persisted only if it is accepted, so events delivered again
+// after status 503 are not recorded twice.
+func (h *Server) processEvent(resp larkslim.EventResponse, body []byte) int {
+	if h.EventFilter != nil && !h.EventFilter.allow(resp, body) {
+		h.record(resp, body)
+		if h.Logger != nil {
+			h.Logger.Debug("event filtered")
+		}
+		return http.StatusNoContent
+	}
+	if h.Workers > 0 && !h.reserve() {
+		// the open platform delivers the event again later
+		if h.Logger != nil {
+			h.Logger.Error("event queue is full")
+		}
+		return http.StatusServiceUnavailable
+	}
+	if h.EventSink != nil {
+		if err := h.EventSink.WriteEvent(body); err != nil {
+			if h.Logger != nil {
+				h.Logger.Error(err)
+			}
+			if h.Workers > 0 {
+				h.release()
+			}
+			return http.StatusInternalServerError
+		}
+	}
+	h.record(resp, body)
+	if h.Workers <= 0 {
+		h.handleEvent(resp, body)
+	} else {
+		h.enqueue(resp, body)
+	}
+	return http.StatusNoContent
+}
+
+// record appends the event to Transcript.
+func (h *Server) record(resp larkslim.EventResponse, body []byte) {
+	if h.Transcript == nil {
+		return
+	}
+	if err := h.Transcript.Append(larkslim.NewInboundTranscriptEntry(resp, body)); err != nil && h.Logger != nil {
+		h.Logger.Error(err)
+	}
+}
+
 func (h *Server) handle204(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,223 @@
+package larkbot
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Standup asks a roster of users for their updates in direct messages
+	// on a schedule, collects their replies and posts a summary to a chat
+	// when everyone has replied or Window has passed:
+	//
+	//	standup := &larkbot.Standup{
+	//		API:      api,
+	//		Roster:   []string{"ou_a", "ou_b"},
+	//		Schedule: "30 9 * * MON-FRI",
+	//		ChatId:   "oc_team",
+	//	}
+	//	standup.Register(scheduler)
+	//	server.EventCallbackHandler = func(event larkslim.EventResponse) {
+	//		if standup.HandleEvent(event) {
+	//			return
+	//		}
+	//		router.HandleEvent(event)
+	//	}
+	Standup struct {
+		API *larkslim.API
+
+		// open ids of users to ask
+		Roster []string
+
+		// cron schedule of Scheduler, for example "30 9 * * MON-FRI"
+		Schedule string
+
+		// chat to post summaries to
+		ChatId string
+
+		// defaults to "What did you do yesterday? What will you do today?
+		// Any blockers?"
+		Prompt string
+
+		// title of summaries, defaults to "Standup"
+		Title string
+
+		// locale of summary posts, defaults to "zh_cn"
+		Locale string
+
+		// how long replies are collected, defaults to 1 hour
+		Window time.Duration
+
+		// messages starting with it are not taken as replies, so that
+		// they reach the Router, defaults to "/" like Router.Prefix
+		CommandPrefix string
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+
+		mutex sync.Mutex
+		round *standupRound
+	}
+
+	standupRound struct {
+		deadline time.Time
+		replies  map[string][]string
+	}
+)
+
+// Register adds jobs starting the standup on Schedule and posting the
+// summary when Window has passed to the scheduler.
+func (s *Standup) Register(scheduler *Scheduler) error {
+	if err := scheduler.Every(s.Schedule, func(*larkslim.API) error {
+		return s.Start()
+	}); err != nil {
+		return err
+	}
+	return scheduler.Every("* * * * *", func(*larkslim.API) error {
+		s.mutex.Lock()
+		due := s.round != nil && !s.clock().Now().Before(s.round.deadline)
+		s.mutex.Unlock()
+		if !due {
+			return nil
+		}
+		return s.Finish()
+	})
+}
+
+// Start sends the prompt to every user of the roster. The summary of the
+// previous standup is posted first if it has not finished yet.
+func (s *Standup) Start() error {
+	s.mutex.Lock()
+	running := s.round != nil
+	s.mutex.Unlock()
+	if running {
+		if err := s.Finish(); err != nil {
+			s.error(err)
+		}
+	}
+	window := s.Window
+	if window <= 0 {
+		window = time.Hour
+	}
+	s.mutex.Lock()
+	s.round = &standupRound{
+		deadline: s.clock().Now().Add(window),
+		replies:  map[string][]string{},
+	}
+	s.mutex.Unlock()
+	prompt := s.Prompt
+	if prompt == "" {
+		prompt = "What did you do yesterday? What will you do today? Any blockers?"
+	}
+	var lastErr error
+	for _, openId := range s.Roster {
+		if _, err := s.API.SendMessage(openId, prompt); err != nil {
+			s.error("standup", openId, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// HandleEvent collects text messages sent to the bot by users of the roster
+// while the standup is running, except commands starting with
+// CommandPrefix. It returns false if the event is not a reply to the
+// standup.
+func (s *Standup) HandleEvent(event larkslim.EventResponse) bool {
+	e := event.Event
+	if e.Type != "message" || e.ChatType != "private" || e.Text == "" || !s.inRoster(e.OpenId) {
+		return false
+	}
+	prefix := s.CommandPrefix
+	if prefix == "" {
+		prefix = "/"
+	}
+	if strings.HasPrefix(strings.TrimSpace(e.Text), prefix) {
+		return false
+	}
+	s.mutex.Lock()
+	round := s.round
+	if round == nil {
+		s.mutex.Unlock()
+		return false
+	}
+	round.replies[e.OpenId] = append(round.replies[e.OpenId], e.Text)
+	done := len(round.replies) == len(s.Roster)
+	s.mutex.Unlock()
+	if done {
+		if err := s.Finish(); err != nil {
+			s.error(err)
+		}
+	}
+	return true
+}
+
+// Finish posts the summary of the running standup to ChatId.
+func (s *Standup) Finish() error {
+	s.mutex.Lock()
+	round := s.round
+	s.round = nil
+	s.mutex.Unlock()
+	if round == nil {
+		return errors.New("standup is not running")
+	}
+	title := s.Title
+	if title == "" {
+		title = "Standup"
+	}
+	locale := s.Locale
+	if locale == "" {
+		locale = "zh_cn"
+	}
+	b := larkslim.NewPostBuilder(title)
+	var missing []string
+	for _, openId := range s.Roster {
+		replies, ok := round.replies[openId]
+		if !ok {
+			missing = append(missing, openId)
+			continue
+		}
+		b.At(openId).NewLine()
+		for _, reply := range replies {
+			b.Text(reply).NewLine()
+		}
+	}
+	if len(missing) > 0 {
+		b.Text("No reply: ")
+		for _, openId := range missing {
+			b.At(openId)
+		}
+	}
+	_, err := s.API.SendPost(s.ChatId, b.Post(locale))
+	return err
+}
+
+func (s *Standup) inRoster(openId string) bool {
+	for _, id := range s.Roster {
+		if id == openId {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Standup) clock() larkslim.Clock {
+	if s.Clock != nil {
+		return s.Clock
+	}
+	return larkslim.SystemClock
+}
+
+func (s *Standup) error(args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Error(args...)
+	}
+}
@@ -0,0 +1,61 @@
+package larkbot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func TestStandup(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	standup := &Standup{
+		API:    server.API(),
+		Roster: []string{"ou_a", "ou_b"},
+		ChatId: "oc_team",
+	}
+	if err := standup.Start(); err != nil {
+		t.Fatal(err)
+	}
+	reply := func(openId, text string) bool {
+		event := textMessage(openId, text)
+		event.Event.ChatType = "private"
+		event.Event.Text = text
+		return standup.HandleEvent(event)
+	}
+	if !reply("ou_a", "fixed the build") {
+		t.Error("reply should be collected")
+	}
+	if reply("ou_a", "/remind me in 1h update the doc") {
+		t.Error("commands should not be collected")
+	}
+	if reply("ou_c", "not in roster") {
+		t.Error("users not in roster should be ignored")
+	}
+	if err := standup.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if reply("ou_b", "too late") {
+		t.Error("replies after standup should be ignored")
+	}
+	var prompts, summaries []string
+	for _, req := range server.Requests() {
+		body := string(req.Body)
+		if req.Path != "/message/v4/send/" {
+			continue
+		}
+		if strings.Contains(body, `"msg_type":"post"`) {
+			summaries = append(summaries, body)
+		} else if strings.Contains(body, "Any blockers?") {
+			prompts = append(prompts, body)
+		}
+	}
+	if len(prompts) != 2 {
+		t.Errorf("should ask 2 users, got %d", len(prompts))
+	}
+	if len(summaries) != 1 || !strings.Contains(summaries[0], `"chat_id":"oc_team"`) ||
+		!strings.Contains(summaries[0], "fixed the build") || strings.Contains(summaries[0], "remind") || !strings.Contains(summaries[0], `No reply: "},{"tag":"at","user_id":"ou_b"}`) {
+		t.Errorf("wrong summary: %q", summaries)
+	}
+}
@@ -0,0 +1,114 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+type (
+	// Verification is the url_verification handshake status of a callback
+	// path.
+	Verification struct {
+		// true if the last url_verification request succeeded
+		Verified bool `json:"verified"`
+
+		// time of the last successful url_verification
+		VerifiedAt time.Time `json:"verified_at,omitempty"`
+
+		// error of the last failed url_verification
+		Error string `json:"error,omitempty"`
+	}
+
+	// Health is the response of the /health endpoint.
+	Health struct {
+		// url_verification status by callback path, for example
+		// "/events/"
+		Verifications map[string]Verification `json:"verifications"`
+
+		// nil if Workers is not set
+		Queue *QueueStats `json:"queue,omitempty"`
+	}
+)
+
+// Verifications returns the url_verification status of callback paths that
+// have received url_verification requests.
+func (h *Server) Verifications() map[string]Verification {
+	h.loadVerifications()
+	h.verificationsMutex.Lock()
+	defer h.verificationsMutex.Unlock()
+	verifications := map[string]Verification{}
+	for path, v := range h.verifications {
+		verifications[path] = v
+	}
+	return verifications
+}
+
+// Health returns the health status served at /health.
+func (h *Server) Health() Health {
+	health := Health{
+		Verifications: h.Verifications(),
+	}
+	if h.Workers > 0 {
+		stats := h.QueueStats()
+		health.Queue = &stats
+	}
+	return health
+}
+
+func (h *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Health())
+}
+
+// verified records the result of the url_verification request of path and
+// saves it to VerificationStateFile.
+func (h *Server) verified(path string, err error) {
+	h.loadVerifications()
+	h.verificationsMutex.Lock()
+	v := h.verifications[path]
+	if err == nil {
+		v = Verification{Verified: true, VerifiedAt: h.clock().Now()}
+	} else {
+		v.Verified = false
+		v.Error = err.Error()
+	}
+	h.verifications[path] = v
+	data, err := json.Marshal(h.verifications)
+	h.verificationsMutex.Unlock()
+	if err == nil {
+		err = h.saveVerifications(data)
+	}
+	if err != nil && h.Logger != nil {
+		h.Logger.Error(err)
+	}
+}
+
+func (h *Server) saveVerifications(data []byte) error {
+	if h.VerificationStateFile == "" {
+		return nil
+	}
+	tmp := h.VerificationStateFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.VerificationStateFile)
+}
+
+func (h *Server) loadVerifications() {
+	h.verificationsOnce.Do(func() {
+		h.verifications = map[string]Verification{}
+		if h.VerificationStateFile == "" {
+			return
+		}
+		data, err := ioutil.ReadFile(h.VerificationStateFile)
+		if err == nil {
+			err = json.Unmarshal(data, &h.verifications)
+		}
+		if err != nil && !os.IsNotExist(err) && h.Logger != nil {
+			h.Logger.Error(err)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifications(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "verifications.json")
+	h := &Server{
+		EventVerificationToken: "token",
+		VerificationStateFile:  file,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events/", h.handleLarkEvents)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	if err := SelfTest(ts.URL+"/events/", "", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SelfTest(ts.URL+"/events/other", "", "wrong"); err == nil {
+		t.Fatal("wrong token should fail")
+	}
+
+	// status is loaded from the file after restart
+	h = &Server{VerificationStateFile: file}
+	w := httptest.NewRecorder()
+	h.handleHealth(w, httptest.NewRequest("GET", "/health", nil))
+	var health Health
+	if err := json.NewDecoder(w.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if v := health.Verifications["/events/"]; !v.Verified || v.VerifiedAt.IsZero() {
+		t.Errorf("/events/ should be verified: %+v", v)
+	}
+	if v := health.Verifications["/events/other"]; v.Verified || v.Error != "wrong verification token" {
+		t.Errorf("/events/other should not be verified: %+v", v)
+	}
+}
@@ -0,0 +1,253 @@
+package larkbot
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// opcodes of WebSocket frames
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maximum size of a message
+const wsMaxMessageSize = 16 << 20
+
+type (
+	// wsConn is a minimal client side WebSocket connection.
+	wsConn struct {
+		conn       net.Conn
+		r          *bufio.Reader
+		writeMutex sync.Mutex
+
+		// if greater than zero, ReadMessage fails if no frame (including
+		// pings and pongs) is received for this duration, so half-open
+		// connections are detected
+		readTimeout time.Duration
+	}
+)
+
+var errWSClosed = errors.New("websocket closed")
+
+// dialWS opens a WebSocket connection to rawurl (ws:// or wss://).
+func dialWS(rawurl string, timeout time.Duration) (*wsConn, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "wss" {
+			host += ":443"
+		} else {
+			host += ":80"
+		}
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "wss":
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	case "ws":
+		conn, err = dialer.Dial("tcp", host)
+	default:
+		err = fmt.Errorf("unsupported websocket scheme: %s", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	u.Scheme = "http"
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake: %s", resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) {
+		conn.Close()
+		return nil, errors.New("websocket handshake: wrong Sec-WebSocket-Accept")
+	}
+	conn.SetDeadline(time.Time{})
+	return &wsConn{conn: conn, r: r}, nil
+}
+
+// wsAccept returns Sec-WebSocket-Accept of the key.
+func wsAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ReadMessage returns the next text or binary message, answering pings.
+// Every frame received extends the read deadline by readTimeout.
+func (c *wsConn) ReadMessage() (opcode byte, message []byte, err error) {
+	for {
+		var fin bool
+		var op byte
+		var payload []byte
+		if c.readTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+		}
+		fin, op, payload, err = readWSFrame(c.r)
+		if err != nil {
+			return
+		}
+		switch op {
+		case wsClose:
+			c.WriteMessage(wsClose, nil)
+			err = errWSClosed
+			return
+		case wsPing:
+			if err = c.WriteMessage(wsPong, payload); err != nil {
+				return
+			}
+			continue
+		case wsPong:
+			continue
+		case wsContinuation:
+			if opcode == 0 {
+				err = errors.New("websocket: unexpected continuation frame")
+				return
+			}
+		default:
+			opcode = op
+		}
+		message = append(message, payload...)
+		if len(message) > wsMaxMessageSize {
+			err = errors.New("websocket: message too large")
+			return
+		}
+		if fin {
+			return
+		}
+	}
+}
+
+// WriteMessage writes a message in a single masked frame.
+func (c *wsConn) WriteMessage(opcode byte, payload []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return writeWSFrame(c.conn, opcode, payload, true)
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
+
+func readWSFrame(r io.Reader) (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > wsMaxMessageSize {
+		err = errors.New("websocket: frame too large")
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(r, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// writeWSFrame writes a final frame, clients must mask frames.
+func writeWSFrame(w io.Writer, opcode byte, payload []byte, masked bool) error {
+	frame := []byte{0x80 | opcode, 0}
+	var maskBit byte
+	if masked {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame[1] = maskBit | byte(n)
+	case n <= 0xffff:
+		frame[1] = maskBit | 126
+		frame = append(frame, byte(n>>8), byte(n))
+	default:
+		frame[1] = maskBit | 127
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(frame, ext[:]...)
+	}
+	data := payload
+	if masked {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		data = make([]byte, len(payload))
+		for i := range payload {
+			data[i] = payload[i] ^ mask[i%4]
+		}
+	}
+	_, err := w.Write(append(frame, data...))
+	return err
+}
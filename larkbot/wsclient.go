@@ -0,0 +1,308 @@
+package larkbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// WSClient receives events over the long connection (WebSocket) of the
+	// open platform instead of HTTP callbacks, so bots behind NAT don't
+	// need a public URL. Enable "receive events through persistent
+	// connection" in the developer console. Events are handled by Server
+	// the same way as HTTP callbacks (transcript, filter, sink, workers and
+	// handlers), verification token and encryption are not used.
+	WSClient struct {
+		AppId     string
+		AppSecret string
+
+		// defaults to "https://open.feishu.cn"
+		Domain string
+
+		Server *Server
+
+		// the connection is closed and reconnected if nothing (including
+		// pongs to pings) is received for this duration; defaults to
+		// three times the ping interval
+		ReadTimeout time.Duration
+
+		// used to request the connection URL, defaults to a client with
+		// 10 seconds timeout
+		HTTPClient *http.Client
+
+		Logger interface {
+			Info(args ...interface{})
+			Error(args ...interface{})
+		}
+
+		// used to expire pieces of incomplete events, defaults to
+		// larkslim.SystemClock
+		Clock larkslim.Clock
+
+		// pieces of events split into multiple frames, by message id
+		mutex  sync.Mutex
+		pieces map[string]*eventPieces
+	}
+
+	eventPieces struct {
+		payloads  [][]byte
+		expiresAt time.Time
+	}
+
+	wsEndpointResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			URL          string `json:"URL"`
+			ClientConfig struct {
+				ReconnectCount    int `json:"ReconnectCount"`
+				ReconnectInterval int `json:"ReconnectInterval"`
+				ReconnectNonce    int `json:"ReconnectNonce"`
+				PingInterval      int `json:"PingInterval"`
+			} `json:"ClientConfig"`
+		} `json:"data"`
+	}
+)
+
+// Run connects to the long connection and reconnects after errors until stop
+// is closed.
+func (c *WSClient) Run(stop <-chan struct{}) {
+	for {
+		reconnectInterval, err := c.connect(stop)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if err != nil {
+			c.error(err)
+		}
+		if reconnectInterval <= 0 {
+			reconnectInterval = 5 * time.Second
+		}
+		c.info("reconnect in", reconnectInterval)
+		select {
+		case <-stop:
+			return
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+// connect serves one connection until it is closed.
+func (c *WSClient) connect(stop <-chan struct{}) (reconnectInterval time.Duration, err error) {
+	endpoint, err := c.endpoint()
+	if err != nil {
+		return
+	}
+	config := endpoint.Data.ClientConfig
+	reconnectInterval = time.Duration(config.ReconnectInterval) * time.Second
+	u, err := url.Parse(endpoint.Data.URL)
+	if err != nil {
+		return
+	}
+	serviceId, _ := strconv.ParseInt(u.Query().Get("service_id"), 10, 32)
+	conn, err := dialWS(endpoint.Data.URL, 10*time.Second)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	c.info("connected to long connection")
+	// unacknowledged events are sent again in full after reconnecting
+	c.mutex.Lock()
+	c.pieces = nil
+	c.mutex.Unlock()
+
+	pingInterval := time.Duration(config.PingInterval) * time.Second
+	if pingInterval <= 0 {
+		pingInterval = 2 * time.Minute
+	}
+	conn.readTimeout = c.ReadTimeout
+	if conn.readTimeout <= 0 {
+		conn.readTimeout = 3 * pingInterval
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			ping := frame{
+				Method:  frameControl,
+				Service: int32(serviceId),
+				Headers: []frameHeader{{"type", "ping"}},
+			}
+			if err := conn.WriteMessage(wsBinary, ping.marshal()); err != nil {
+				return
+			}
+			select {
+			case <-stop:
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	for {
+		var opcode byte
+		var message []byte
+		opcode, message, err = conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if opcode != wsBinary {
+			continue
+		}
+		var f frame
+		if err = f.unmarshal(message); err != nil {
+			return
+		}
+		if f.Method != frameData || f.header("type") != "event" {
+			continue
+		}
+		body := c.assemble(&f)
+		if body == nil {
+			continue
+		}
+		start := time.Now()
+		code := c.handle(body)
+		f.setHeader("biz_rt", strconv.FormatInt(int64(time.Since(start)/time.Millisecond), 10))
+		f.Payload = []byte(fmt.Sprintf(`{"code":%d}`, code))
+		if err = conn.WriteMessage(wsBinary, f.marshal()); err != nil {
+			return
+		}
+	}
+}
+
+// endpoint requests the URL of the long connection.
+func (c *WSClient) endpoint() (resp wsEndpointResponse, err error) {
+	domain := c.Domain
+	if domain == "" {
+		domain = "https://open.feishu.cn"
+	}
+	body, _ := json.Marshal(map[string]string{
+		"AppID":     c.AppId,
+		"AppSecret": c.AppSecret,
+	})
+	req, err := http.NewRequest("POST", domain+"/callback/ws/endpoint", bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("locale", "zh")
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if err = json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return
+	}
+	if resp.Code != 0 {
+		err = fmt.Errorf("long connection endpoint: %s (code %d)", resp.Msg, resp.Code)
+	} else if resp.Data.URL == "" {
+		err = errors.New("long connection endpoint: empty URL")
+	}
+	return
+}
+
+// piecesTTL is how long pieces of an incomplete event are kept.
+const piecesTTL = 5 * time.Second
+
+// assemble returns the event of the frame, or nil if the event is split into
+// multiple frames and some of them have not been received. Pieces of events
+// not completed within piecesTTL are dropped.
+func (c *WSClient) assemble(f *frame) []byte {
+	sum, _ := strconv.Atoi(f.header("sum"))
+	if sum <= 1 {
+		return f.Payload
+	}
+	seq, _ := strconv.Atoi(f.header("seq"))
+	if seq < 0 || seq >= sum {
+		return nil
+	}
+	id := f.header("message_id")
+	now := c.clock().Now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.pieces == nil {
+		c.pieces = map[string]*eventPieces{}
+	}
+	for key, p := range c.pieces {
+		if !now.Before(p.expiresAt) {
+			delete(c.pieces, key)
+		}
+	}
+	p := c.pieces[id]
+	if p == nil || len(p.payloads) != sum {
+		p = &eventPieces{payloads: make([][]byte, sum), expiresAt: now.Add(piecesTTL)}
+		c.pieces[id] = p
+	}
+	p.payloads[seq] = f.Payload
+	for _, payload := range p.payloads {
+		if payload == nil {
+			return nil
+		}
+	}
+	delete(c.pieces, id)
+	return bytes.Join(p.payloads, nil)
+}
+
+// handle passes the event to Server and returns the code of the response.
+func (c *WSClient) handle(body []byte) int {
+	var botOpenId string
+	if c.Server != nil {
+		botOpenId = c.Server.BotOpenId
+	}
+	event, err := larkslim.ParseEventForBot(body, false, botOpenId)
+	if err != nil {
+		c.error(err)
+		return http.StatusInternalServerError
+	}
+	if c.Server == nil {
+		return http.StatusOK
+	}
+	switch c.Server.processEvent(event, body) {
+	case http.StatusNoContent:
+		return http.StatusOK
+	case http.StatusServiceUnavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func (c *WSClient) clock() larkslim.Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+	return larkslim.SystemClock
+}
+
+func (c *WSClient) info(args ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Info(args...)
+	}
+}
+
+func (c *WSClient) error(args ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Error(args...)
+	}
+}
@@ -0,0 +1,160 @@
+package larkbot
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func TestWSClient(t *testing.T) {
+	responses := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/callback/ws/endpoint" {
+			io.WriteString(w, `{"code":0,"data":{"URL":"ws://`+r.Host+`/ws?service_id=7","ClientConfig":{"PingInterval":120}}}`)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + wsAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+		event := `{"schema":"2.0","header":{"event_type":"im.message.receive_v1"},` +
+			`"event":{"message":{"chat_id":"oc_1","message_type":"text","content":"{\"text\":\"hi\"}"}}}`
+		// the event is split into two frames
+		for i, piece := range []string{event[:20], event[20:]} {
+			f := frame{
+				Method:  frameData,
+				Service: 7,
+				Headers: []frameHeader{
+					{"type", "event"},
+					{"message_id", "1"},
+					{"sum", "2"},
+					{"seq", string(rune('0' + i))},
+				},
+				Payload: []byte(piece),
+			}
+			writeWSFrame(conn, wsBinary, f.marshal(), false)
+		}
+		br := rw.Reader
+		for {
+			_, _, payload, err := readWSFrame(br)
+			if err != nil {
+				return
+			}
+			var f frame
+			f.unmarshal(payload)
+			if f.Method == frameData {
+				responses <- string(f.Payload)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	received := make(chan larkslim.EventResponse, 1)
+	client := &WSClient{
+		Domain: ts.URL,
+		Server: &Server{
+			EventCallbackHandler: func(event larkslim.EventResponse) {
+				received <- event
+			},
+		},
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go client.Run(stop)
+
+	select {
+	case event := <-received:
+		if event.Event.ChatId != "oc_1" || event.Event.Text != "hi" {
+			t.Errorf("wrong event: %+v", event.Event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not received")
+	}
+	select {
+	case resp := <-responses:
+		var v struct{ Code int }
+		if json.Unmarshal([]byte(resp), &v); v.Code != 200 {
+			t.Errorf("wrong response: %s", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("response not received")
+	}
+}
+
+func TestWSClientAssemble(t *testing.T) {
+	clock := larktest.NewFakeClock(time.Now())
+	client := &WSClient{Clock: clock}
+	piece := func(id, seq, payload string) *frame {
+		return &frame{
+			Headers: []frameHeader{{"message_id", id}, {"sum", "2"}, {"seq", seq}},
+			Payload: []byte(payload),
+		}
+	}
+	if body := client.assemble(piece("1", "0", "a")); body != nil {
+		t.Errorf("incomplete event should not be returned, got %q", body)
+	}
+	if body := client.assemble(piece("1", "1", "b")); string(body) != "ab" {
+		t.Errorf("event should be assembled, got %q", body)
+	}
+	client.assemble(piece("2", "0", "c"))
+	clock.Advance(piecesTTL)
+	client.assemble(piece("3", "0", "e"))
+	if body := client.assemble(piece("2", "1", "d")); body != nil {
+		t.Errorf("expired pieces should be dropped, got %q", body)
+	}
+	if n := len(client.pieces); n != 2 {
+		t.Errorf("should keep pieces of 2 events, got %d", n)
+	}
+}
+
+func TestWSClientReadTimeout(t *testing.T) {
+	connected := make(chan struct{}, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/callback/ws/endpoint" {
+			io.WriteString(w, `{"code":0,"data":{"URL":"ws://`+r.Host+`/ws?service_id=7","ClientConfig":{"ReconnectInterval":1}}}`)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + wsAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+		select {
+		case connected <- struct{}{}:
+		default:
+		}
+		// the server stops responding, but keeps the connection open
+		io.Copy(ioutil.Discard, rw)
+	}))
+	defer ts.Close()
+
+	client := &WSClient{
+		Domain:      ts.URL,
+		ReadTimeout: 100 * time.Millisecond,
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go client.Run(stop)
+	<-connected
+	select {
+	case <-connected:
+	case <-time.After(10 * time.Second):
+		t.Fatal("client should reconnect after the read timeout")
+	}
+}
@@ -0,0 +1,183 @@
+// Package larkbridge mirrors messages between a Lark chat and channels of
+// other chat platforms.
+//
+// Each platform implements Bridge, which normalizes inbound and outbound
+// messages to Message. Relay connects two bridges:
+//
+//	lark := &larkbridge.Lark{API: api, ChatId: "oc_..."}
+//	slack := &larkbridge.Slack{Token: "xoxb-...", Channel: "C..."}
+//	relay := &larkbridge.Relay{A: lark, B: slack}
+//	relay.Start()
+//	server.EventCallbackHandler = lark.HandleEvent
+//	http.Handle("/slack/events", slack)
+//
+// Set Translator and Languages of Relay to translate messages for
+// multilingual teams.
+package larkbridge
+
+import (
+	"container/list"
+	"io"
+	"sync"
+)
+
+type (
+	// Bridge is one side of a relay.
+	Bridge interface {
+		// Name returns the name of the platform, used in log messages and
+		// to tell the sides of a relay apart.
+		Name() string
+
+		// Send posts the message to the bridged channel and returns the id
+		// of the posted message. ThreadId of the message, if not empty, is
+		// an id previously returned by Send or passed to the receive func.
+		Send(msg Message) (id string, err error)
+
+		// Receive registers the func to be called for every message posted
+		// to the bridged channel by users (not by bridges).
+		Receive(func(Message))
+	}
+
+	// Message is a chat message normalized across platforms.
+	Message struct {
+		// id of the message on the platform it was received from
+		Id string
+
+		// id of the root message of the thread, empty if the message is
+		// not in a thread
+		ThreadId string
+
+		// display name of the sender
+		Sender string
+
+		Text   string
+		Images []Image
+	}
+
+	Image struct {
+		Name string
+		Open func() (io.ReadCloser, error)
+	}
+
+	// Translator translates texts, for example *larkslim.API.
+	Translator interface {
+		TranslateText(srcLang, dstLang, text string) (string, error)
+	}
+
+	// Relay mirrors messages between two bridges. Threads are mirrored on a
+	// best-effort basis as long as the parent message was mirrored by the
+	// same relay.
+	Relay struct {
+		A, B Bridge
+
+		// if set, texts are translated to the language of the receiving
+		// side when the languages of the sides differ
+		Translator Translator
+
+		// names of bridges to their languages, for example
+		// {"lark": "zh", "slack": "en"}
+		Languages map[string]string
+
+		// max number of mirrored messages remembered to mirror replies in
+		// threads, least recently used ones are forgotten first, defaults
+		// to 10000
+		MaxThreads int
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+
+		mutex   sync.Mutex
+		threads map[string]*list.Element // "name:id" -> threadLink
+		order   *list.List               // most recently used first
+	}
+
+	threadLink struct {
+		key string // "name:id"
+		id  string // id of the other side
+	}
+)
+
+// Start registers receive funcs on both bridges.
+func (r *Relay) Start() {
+	r.A.Receive(func(msg Message) { r.relay(r.A, r.B, msg) })
+	r.B.Receive(func(msg Message) { r.relay(r.B, r.A, msg) })
+}
+
+func (r *Relay) relay(from, to Bridge, msg Message) {
+	orig := msg
+	if msg.ThreadId != "" {
+		msg.ThreadId = r.thread(from, msg.ThreadId)
+	}
+	msg.Text = r.translate(from, to, msg.Text)
+	id, err := to.Send(msg)
+	if err != nil {
+		if r.Logger != nil {
+			r.Logger.Error(from.Name(), "->", to.Name(), err)
+		}
+		return
+	}
+	r.link(from, orig.Id, to, id)
+}
+
+func (r *Relay) link(from Bridge, fromId string, to Bridge, toId string) {
+	if fromId == "" || toId == "" {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.threads == nil {
+		r.threads = map[string]*list.Element{}
+		r.order = list.New()
+	}
+	r.put(from.Name()+":"+fromId, toId)
+	r.put(to.Name()+":"+toId, fromId)
+	max := r.MaxThreads
+	if max <= 0 {
+		max = 10000
+	}
+	// each mirrored message is linked from both sides
+	for r.order.Len() > 2*max {
+		last := r.order.Back()
+		r.order.Remove(last)
+		delete(r.threads, last.Value.(threadLink).key)
+	}
+}
+
+// put adds or updates the link, with mutex held.
+func (r *Relay) put(key, id string) {
+	if elem, ok := r.threads[key]; ok {
+		elem.Value = threadLink{key, id}
+		r.order.MoveToFront(elem)
+		return
+	}
+	r.threads[key] = r.order.PushFront(threadLink{key, id})
+}
+
+func (r *Relay) thread(from Bridge, id string) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	elem, ok := r.threads[from.Name()+":"+id]
+	if !ok {
+		return ""
+	}
+	r.order.MoveToFront(elem)
+	return elem.Value.(threadLink).id
+}
+
+// translate returns text in the language of the receiving side, or the
+// original text if it can't be translated.
+func (r *Relay) translate(from, to Bridge, text string) string {
+	src, dst := r.Languages[from.Name()], r.Languages[to.Name()]
+	if r.Translator == nil || text == "" || src == "" || dst == "" || src == dst {
+		return text
+	}
+	translated, err := r.Translator.TranslateText(src, dst, text)
+	if err != nil {
+		if r.Logger != nil {
+			r.Logger.Error(from.Name(), "->", to.Name(), err)
+		}
+		return text
+	}
+	return translated
+}
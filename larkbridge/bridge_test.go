@@ -0,0 +1,79 @@
+package larkbridge
+
+import (
+	"fmt"
+	"testing"
+)
+
+type fakeBridge struct {
+	name    string
+	sent    []Message
+	receive func(Message)
+}
+
+func (f *fakeBridge) Name() string { return f.name }
+
+func (f *fakeBridge) Receive(fn func(Message)) { f.receive = fn }
+
+func (f *fakeBridge) Send(msg Message) (string, error) {
+	f.sent = append(f.sent, msg)
+	return fmt.Sprintf("%s-%d", f.name, len(f.sent)), nil
+}
+
+func TestRelay(t *testing.T) {
+	a := &fakeBridge{name: "a"}
+	b := &fakeBridge{name: "b"}
+	relay := &Relay{A: a, B: b}
+	relay.Start()
+
+	a.receive(Message{Id: "x", Sender: "Tom", Text: "hello"})
+	if len(b.sent) != 1 || b.sent[0].Text != "hello" {
+		t.Fatalf("message not relayed: %+v", b.sent)
+	}
+
+	// reply in b to the relayed message should be threaded under x in a
+	b.receive(Message{Id: "y", ThreadId: "b-1", Text: "hi"})
+	if len(a.sent) != 1 || a.sent[0].ThreadId != "x" {
+		t.Fatalf("thread not mapped: %+v", a.sent)
+	}
+}
+
+func TestRelayMaxThreads(t *testing.T) {
+	a := &fakeBridge{name: "a"}
+	b := &fakeBridge{name: "b"}
+	relay := &Relay{A: a, B: b, MaxThreads: 1}
+	relay.Start()
+	a.receive(Message{Id: "x1", Text: "1"})
+	a.receive(Message{Id: "x2", Text: "2"})
+	b.receive(Message{Id: "y2", ThreadId: "b-2", Text: "re 2"})
+	b.receive(Message{Id: "y1", ThreadId: "b-1", Text: "re 1"})
+	if len(a.sent) != 2 || a.sent[0].ThreadId != "x2" || a.sent[1].ThreadId != "" {
+		t.Errorf("least recently used messages should be forgotten: %+v", a.sent)
+	}
+	if len(relay.threads) != 2 {
+		t.Errorf("wrong number of links remembered: %d", len(relay.threads))
+	}
+}
+
+type fakeTranslator map[string]string
+
+func (f fakeTranslator) TranslateText(srcLang, dstLang, text string) (string, error) {
+	return f[srcLang+">"+dstLang+":"+text], nil
+}
+
+func TestRelayTranslate(t *testing.T) {
+	a := &fakeBridge{name: "a"}
+	b := &fakeBridge{name: "b"}
+	relay := &Relay{
+		A:          a,
+		B:          b,
+		Translator: fakeTranslator{"zh>en:你好": "hello", "en>zh:hi": "嗨"},
+		Languages:  map[string]string{"a": "zh", "b": "en"},
+	}
+	relay.Start()
+	a.receive(Message{Id: "x", Text: "你好"})
+	b.receive(Message{Id: "y", Text: "hi"})
+	if len(b.sent) != 1 || b.sent[0].Text != "hello" || len(a.sent) != 1 || a.sent[0].Text != "嗨" {
+		t.Errorf("messages not translated: %+v %+v", a.sent, b.sent)
+	}
+}
@@ -0,0 +1,87 @@
+package larkbridge
+
+import (
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Lark is the Lark side of a bridge. Call HandleEvent for every event
+	// received by larkbot.Server.
+	Lark struct {
+		API    *larkslim.API
+		ChatId string
+
+		receive func(Message)
+	}
+)
+
+func (l *Lark) Name() string {
+	return "lark"
+}
+
+func (l *Lark) Receive(f func(Message)) {
+	l.receive = f
+}
+
+// HandleEvent normalizes message events of the chat and passes them to the
+// receive func. It has the signature of larkbot.Server.EventCallbackHandler.
+func (l *Lark) HandleEvent(event larkslim.EventResponse) {
+	e := event.Event
+	if e.Type != "message" || e.ChatId != l.ChatId || l.receive == nil {
+		return
+	}
+	msg := Message{
+		Id:       e.MessageId,
+		ThreadId: e.RootId,
+		Sender:   e.OpenId,
+	}
+	if user, err := l.API.GetUserInfo(e.OpenId); err == nil {
+		msg.Sender = user.Name
+	}
+	switch e.MsgType {
+	case "text":
+		msg.Text = e.Text
+	default:
+		msg.Text = "[" + e.MsgType + "]"
+	}
+	l.receive(msg)
+}
+
+// Send posts text and images of the message to the chat. The id of the
+// first posted message is returned.
+func (l *Lark) Send(msg Message) (id string, err error) {
+	if msg.Text != "" {
+		id, err = l.API.SendMessage(l.ChatId, msg.Sender+": "+msg.Text, l.options(msg.ThreadId)...)
+		if err != nil {
+			return
+		}
+	}
+	for _, image := range msg.Images {
+		rc, err := image.Open()
+		if err != nil {
+			return id, err
+		}
+		key, err := l.API.UploadMessageImage(rc)
+		rc.Close()
+		if err != nil {
+			return id, err
+		}
+		imageId, err := l.API.SendImageMessage(l.ChatId, key, l.options(msg.ThreadId)...)
+		if err != nil {
+			return id, err
+		}
+		if id == "" {
+			id = imageId
+		}
+	}
+	return
+}
+
+// options returns options to send a message as a reply to rootId if it is
+// not empty.
+func (l *Lark) options(rootId string) (opts []larkslim.SendOption) {
+	if rootId != "" {
+		opts = append(opts, larkslim.ReplyTo(rootId))
+	}
+	return
+}
@@ -0,0 +1,271 @@
+package larkbridge
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SlackPrefix = "https://slack.com/api"
+)
+
+type (
+	// Slack is the Slack side of a bridge. Serve it as the request URL of
+	// the Slack Events API subscription (message.channels event).
+	Slack struct {
+		// Slack bot token (xoxb-...), needs chat:write, files:read and
+		// users:read scopes
+		Token string
+
+		// used to verify requests from Slack
+		SigningSecret string
+
+		Channel string
+
+		Timeout time.Duration
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+
+		receive   func(Message)
+		mutex     sync.Mutex
+		userNames map[string]string
+	}
+
+	slackEvent struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Event     struct {
+			Type     string `json:"type"`
+			Subtype  string `json:"subtype"`
+			Channel  string `json:"channel"`
+			User     string `json:"user"`
+			BotId    string `json:"bot_id"`
+			Text     string `json:"text"`
+			Ts       string `json:"ts"`
+			ThreadTs string `json:"thread_ts"`
+			Files    []struct {
+				Mimetype   string `json:"mimetype"`
+				Name       string `json:"name"`
+				UrlPrivate string `json:"url_private"`
+			} `json:"files"`
+		} `json:"event"`
+	}
+
+	slackResponse struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+		Ts    string `json:"ts"`
+		User  struct {
+			Name    string `json:"name"`
+			Profile struct {
+				DisplayName string `json:"display_name"`
+				RealName    string `json:"real_name"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+)
+
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+func (s *Slack) Receive(f func(Message)) {
+	s.receive = f
+}
+
+// Send posts the message to the channel. Images are not uploaded, their
+// names are appended to the text instead.
+func (s *Slack) Send(msg Message) (id string, err error) {
+	text := msg.Text
+	for _, image := range msg.Images {
+		text += " [" + image.Name + "]"
+	}
+	params := url.Values{
+		"channel": {s.Channel},
+		"text":    {fmt.Sprintf("*%s*: %s", msg.Sender, text)},
+	}
+	if msg.ThreadId != "" {
+		params.Set("thread_ts", msg.ThreadId)
+	}
+	var resp slackResponse
+	err = s.call("chat.postMessage", params, &resp)
+	id = resp.Ts
+	return
+}
+
+// ServeHTTP handles requests from the Slack Events API and passes messages
+// of the channel to the receive func.
+func (s *Slack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.error(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := s.verify(r.Header, body); err != nil {
+		s.error(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var event slackEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		s.error(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if event.Type == "url_verification" {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, event.Challenge)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	e := event.Event
+	if event.Type != "event_callback" || e.Type != "message" || e.Channel != s.Channel {
+		return
+	}
+	if e.BotId != "" || (e.Subtype != "" && e.Subtype != "file_share" && e.Subtype != "thread_broadcast") {
+		// ignore messages from bots (including bridges), edits and
+		// deletions
+		return
+	}
+	if s.receive == nil {
+		return
+	}
+	msg := Message{
+		Id:   e.Ts,
+		Text: e.Text,
+	}
+	if e.ThreadTs != e.Ts {
+		msg.ThreadId = e.ThreadTs
+	}
+	for _, file := range e.Files {
+		if !strings.HasPrefix(file.Mimetype, "image/") {
+			continue
+		}
+		fileUrl := file.UrlPrivate
+		msg.Images = append(msg.Images, Image{
+			Name: file.Name,
+			Open: func() (io.ReadCloser, error) {
+				return s.download(fileUrl)
+			},
+		})
+	}
+	go func() {
+		msg.Sender = s.userName(e.User)
+		s.receive(msg)
+	}()
+}
+
+func (s *Slack) download(fileUrl string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", fileUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", fileUrl, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (s *Slack) verify(header http.Header, body []byte) error {
+	if s.SigningSecret == "" {
+		return nil
+	}
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("bad slack request timestamp")
+	}
+	if d := time.Since(time.Unix(ts, 0)); d > 5*time.Minute || d < -5*time.Minute {
+		return errors.New("slack request timestamp too old")
+	}
+	mac := hmac.New(sha256.New, []byte(s.SigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	sig := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(sig), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("wrong slack signature")
+	}
+	return nil
+}
+
+func (s *Slack) userName(user string) string {
+	s.mutex.Lock()
+	name, ok := s.userNames[user]
+	s.mutex.Unlock()
+	if ok {
+		return name
+	}
+	var resp slackResponse
+	if err := s.call("users.info", url.Values{"user": {user}}, &resp); err != nil {
+		s.error(err)
+		return user
+	}
+	name = resp.User.Profile.DisplayName
+	if name == "" {
+		name = resp.User.Profile.RealName
+	}
+	if name == "" {
+		name = resp.User.Name
+	}
+	s.mutex.Lock()
+	if s.userNames == nil {
+		s.userNames = map[string]string{}
+	}
+	s.userNames[user] = name
+	s.mutex.Unlock()
+	return name
+}
+
+func (s *Slack) call(method string, params url.Values, resp *slackResponse) error {
+	req, err := http.NewRequest("POST", SlackPrefix+"/"+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	res, err := s.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return err
+	}
+	if !resp.Ok {
+		return fmt.Errorf("slack %s: %s", method, resp.Error)
+	}
+	return nil
+}
+
+func (s *Slack) client() *http.Client {
+	return &http.Client{
+		Timeout: s.Timeout,
+	}
+}
+
+func (s *Slack) error(err error) {
+	if s.Logger != nil {
+		s.Logger.Error(err)
+	}
+}
@@ -0,0 +1,226 @@
+// Package larkchart renders simple line and bar charts as PNG images and
+// sends them to Lark, without any dependencies outside the standard library.
+// Charts have no text, describe them in a message or post instead.
+package larkchart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+const (
+	Line Kind = iota
+	Bar
+)
+
+var (
+	// colors of series without Color
+	Palette = []color.Color{
+		color.RGBA{0x33, 0x70, 0xff, 0xff},
+		color.RGBA{0xf5, 0x4a, 0x45, 0xff},
+		color.RGBA{0x34, 0xc7, 0x24, 0xff},
+		color.RGBA{0xff, 0x88, 0x00, 0xff},
+		color.RGBA{0x7f, 0x3b, 0xf5, 0xff},
+	}
+
+	background = color.White
+	axisColor  = color.RGBA{0x8f, 0x95, 0x9e, 0xff}
+	gridColor  = color.RGBA{0xe4, 0xe5, 0xe7, 0xff}
+)
+
+type (
+	Kind int
+
+	Series struct {
+		Name   string
+		Values []float64
+		Color  color.Color
+	}
+
+	Chart struct {
+		Kind   Kind
+		Series []Series
+
+		// defaults to 800x400
+		Width  int
+		Height int
+
+		// number of horizontal grid lines, defaults to 4
+		GridLines int
+	}
+)
+
+// Image renders the chart. The value axis always includes zero.
+func (c Chart) Image() image.Image {
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = 800
+	}
+	if height <= 0 {
+		height = 400
+	}
+	gridLines := c.GridLines
+	if gridLines <= 0 {
+		gridLines = 4
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	pad := 20
+	plot := image.Rect(pad, pad, width-pad, height-pad)
+	if plot.Empty() {
+		return img
+	}
+
+	min, max, n := 0.0, 0.0, 0
+	for _, s := range c.Series {
+		for _, v := range s.Values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+		if len(s.Values) > n {
+			n = len(s.Values)
+		}
+	}
+	if max == min {
+		max = min + 1
+	}
+	y := func(v float64) int {
+		return plot.Max.Y - int(math.Round((v-min)/(max-min)*float64(plot.Dy())))
+	}
+
+	for i := 0; i <= gridLines; i++ {
+		gy := plot.Min.Y + i*plot.Dy()/gridLines
+		drawLine(img, plot.Min.X, gy, plot.Max.X, gy, gridColor, 1)
+	}
+	zero := y(0)
+	drawLine(img, plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y, axisColor, 1)
+	drawLine(img, plot.Min.X, zero, plot.Max.X, zero, axisColor, 1)
+
+	if n == 0 {
+		return img
+	}
+	switch c.Kind {
+	case Bar:
+		group := float64(plot.Dx()) / float64(n)
+		bar := group / float64(len(c.Series)+1)
+		for si, s := range c.Series {
+			col := c.color(si)
+			for i, v := range s.Values {
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					continue
+				}
+				x0 := plot.Min.X + int(float64(i)*group+bar/2+float64(si)*bar)
+				x1 := x0 + int(math.Max(bar-1, 1))
+				y0, y1 := y(v), zero
+				if y0 > y1 {
+					y0, y1 = y1, y0
+				}
+				draw.Draw(img, image.Rect(x0, y0, x1, y1+1), image.NewUniform(col), image.Point{}, draw.Src)
+			}
+		}
+	default:
+		step := 0.0
+		if n > 1 {
+			step = float64(plot.Dx()) / float64(n-1)
+		}
+		for si, s := range c.Series {
+			col := c.color(si)
+			px, py, ok := 0, 0, false
+			for i, v := range s.Values {
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					ok = false
+					continue
+				}
+				x, y := plot.Min.X+int(math.Round(float64(i)*step)), y(v)
+				if ok {
+					drawLine(img, px, py, x, y, col, 2)
+				} else {
+					drawLine(img, x, y, x, y, col, 2)
+				}
+				px, py, ok = x, y, true
+			}
+		}
+	}
+	return img
+}
+
+// PNG writes the chart as PNG image to w.
+func (c Chart) PNG(w io.Writer) error {
+	return png.Encode(w, c.Image())
+}
+
+// Upload uploads the chart as a message image and returns its image key.
+func Upload(api *larkslim.API, c Chart) (key string, err error) {
+	var buf bytes.Buffer
+	if err = c.PNG(&buf); err != nil {
+		return
+	}
+	return api.UploadMessageImage(&buf)
+}
+
+// Send uploads the chart and sends it as an image message to the target.
+func Send(api *larkslim.API, target string, c Chart, opts ...larkslim.SendOption) error {
+	key, err := Upload(api, c)
+	if err != nil {
+		return err
+	}
+	_, err = api.SendImageMessage(target, key, opts...)
+	return err
+}
+
+func (c Chart) color(i int) color.Color {
+	if c.Series[i].Color != nil {
+		return c.Series[i].Color
+	}
+	return Palette[i%len(Palette)]
+}
+
+// drawLine draws a line of the thickness using Bresenham's algorithm.
+func drawLine(img draw.Image, x0, y0, x1, y1 int, c color.Color, thickness int) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		for tx := 0; tx < thickness; tx++ {
+			for ty := 0; ty < thickness; ty++ {
+				img.Set(x0+tx, y0+ty, c)
+			}
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
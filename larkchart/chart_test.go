@@ -0,0 +1,33 @@
+package larkchart
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestImage(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	for _, kind := range []Kind{Line, Bar} {
+		img := Chart{
+			Kind:   kind,
+			Width:  200,
+			Height: 100,
+			Series: []Series{{Values: []float64{1, 3, 2, 4}, Color: red}},
+		}.Image()
+		if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+			t.Fatalf("%d: wrong size: %v", kind, b)
+		}
+		found := false
+		for x := 0; x < 200 && !found; x++ {
+			for y := 0; y < 100; y++ {
+				if r, g, _, _ := img.At(x, y).RGBA(); r == 0xffff && g == 0 {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%d: series is not drawn", kind)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+// Package larkfeed polls RSS and Atom feeds and posts new entries to Lark.
+package larkfeed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+const maxSeen = 500
+
+type (
+	// Poster polls feeds every Interval and posts new entries to the
+	// targets of each feed. Ids of posted entries are saved to StateFile so
+	// entries are not posted again after restart. Entries already present
+	// when a feed is polled for the first time are not posted.
+	Poster struct {
+		API   *larkslim.API
+		Feeds []Feed
+
+		// defaults to 10 minutes
+		Interval time.Duration
+
+		// path to JSON file to persist state, empty to keep state in memory
+		StateFile string
+
+		Timeout time.Duration
+
+		Logger interface {
+			Info(args ...interface{})
+			Error(args ...interface{})
+		}
+
+		mutex sync.Mutex
+		state map[string][]string
+	}
+
+	Feed struct {
+		URL string
+
+		// open_id, user_id, email or chat_id
+		Targets []string
+
+		// "post" (default) or "card"
+		Format string
+
+		// header color of cards, for example "blue"
+		Template string
+	}
+
+	Entry struct {
+		Id      string
+		Title   string
+		Link    string
+		Summary string
+	}
+
+	rss struct {
+		Channel struct {
+			Items []struct {
+				Guid        string `xml:"guid"`
+				Title       string `xml:"title"`
+				Link        string `xml:"link"`
+				Description string `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	atom struct {
+		Entries []struct {
+			Id      string `xml:"id"`
+			Title   string `xml:"title"`
+			Summary string `xml:"summary"`
+			Content string `xml:"content"`
+			Links   []struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+)
+
+var htmlTags = regexp.MustCompile(`<[^>]*>`)
+
+// Run polls all feeds every Interval until stop is closed.
+func (p *Poster) Run(stop <-chan struct{}) error {
+	if err := p.load(); err != nil {
+		return err
+	}
+	interval := p.Interval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.Poll()
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches all feeds once and posts new entries.
+func (p *Poster) Poll() {
+	for _, feed := range p.Feeds {
+		if err := p.poll(feed); err != nil {
+			p.error(feed.URL, err)
+		}
+	}
+	if err := p.save(); err != nil {
+		p.error(err)
+	}
+}
+
+func (p *Poster) poll(feed Feed) error {
+	entries, err := p.Fetch(feed.URL)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	seen, known := p.state[feed.URL]
+	p.mutex.Unlock()
+	seenSet := map[string]bool{}
+	for _, id := range seen {
+		seenSet[id] = true
+	}
+	// feeds list newest entries first, post oldest first
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if seenSet[entry.Id] {
+			continue
+		}
+		if known {
+			if !p.post(feed, entry) {
+				// retried in the next poll
+				continue
+			}
+			if p.Logger != nil {
+				p.Logger.Info("posted", entry.Link)
+			}
+		}
+		seenSet[entry.Id] = true
+		seen = append(seen, entry.Id)
+	}
+	if len(seen) > maxSeen {
+		seen = seen[len(seen)-maxSeen:]
+	}
+	p.mutex.Lock()
+	if p.state == nil {
+		p.state = map[string][]string{}
+	}
+	p.state[feed.URL] = append([]string{}, seen...)
+	p.mutex.Unlock()
+	return nil
+}
+
+// post sends the entry to all targets of the feed and logs the failures. It
+// reports whether any target has received the entry, in which case the
+// entry is not posted again, so that other targets don't get duplicates.
+func (p *Poster) post(feed Feed, entry Entry) (delivered bool) {
+	for _, target := range feed.Targets {
+		var err error
+		if feed.Format == "card" {
+			_, err = p.API.SendCard(target, entry.Card(feed.Template))
+		} else {
+			_, err = p.API.SendPost(target, entry.Post())
+		}
+		if err != nil {
+			p.error(feed.URL, target, err)
+			continue
+		}
+		delivered = true
+	}
+	return
+}
+
+// Fetch downloads and parses a RSS or Atom feed.
+func (p *Poster) Fetch(url string) ([]Entry, error) {
+	client := http.Client{
+		Timeout: p.Timeout,
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(body)
+}
+
+// Parse parses a RSS or Atom feed.
+func Parse(body []byte) (entries []Entry, err error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err = xml.Unmarshal(body, &root); err != nil {
+		return
+	}
+	switch root.XMLName.Local {
+	case "rss":
+		var feed rss
+		if err = xml.Unmarshal(body, &feed); err != nil {
+			return
+		}
+		for _, item := range feed.Channel.Items {
+			id := item.Guid
+			if id == "" {
+				id = item.Link
+			}
+			entries = append(entries, Entry{
+				Id:      id,
+				Title:   strings.TrimSpace(item.Title),
+				Link:    strings.TrimSpace(item.Link),
+				Summary: plainText(item.Description),
+			})
+		}
+	case "feed":
+		var feed atom
+		if err = xml.Unmarshal(body, &feed); err != nil {
+			return
+		}
+		for _, e := range feed.Entries {
+			entry := Entry{
+				Id:      e.Id,
+				Title:   strings.TrimSpace(e.Title),
+				Summary: plainText(e.Summary),
+			}
+			if entry.Summary == "" {
+				entry.Summary = plainText(e.Content)
+			}
+			for _, link := range e.Links {
+				if link.Rel == "" || link.Rel == "alternate" {
+					entry.Link = link.Href
+					break
+				}
+			}
+			if entry.Id == "" {
+				entry.Id = entry.Link
+			}
+			entries = append(entries, entry)
+		}
+	default:
+		err = fmt.Errorf("unknown feed format: %s", root.XMLName.Local)
+	}
+	return
+}
+
+// Post returns the entry as a post message.
+func (e Entry) Post() larkslim.Post {
+	var lines larkslim.PostLines
+	if e.Summary != "" {
+		lines = append(lines, larkslim.PostLine{
+			{Tag: "text", Text: e.Summary},
+		})
+	}
+	lines = append(lines, larkslim.PostLine{
+		{Tag: "a", Text: e.Link, Href: e.Link},
+	})
+	return larkslim.Post{
+		"zh_cn": larkslim.PostOfLocale{
+			Title:   e.Title,
+			Content: lines,
+		},
+	}
+}
+
+// Card returns the entry as a card message.
+func (e Entry) Card(template string) larkslim.Card {
+	content := e.Summary
+	if content != "" {
+		content += "\n"
+	}
+	content += "[" + e.Link + "](" + e.Link + ")"
+	return larkslim.Card{
+		Config: larkslim.CardConfig{
+			WideScreenMode: true,
+			EnableForward:  true,
+		},
+		Header: larkslim.CardHeader{
+			Title: larkslim.CardHeaderTitle{
+				Tag:     "plain_text",
+				Content: e.Title,
+			},
+			Template: template,
+		},
+		Elements: []interface{}{
+			map[string]interface{}{
+				"tag":     "markdown",
+				"content": content,
+			},
+		},
+	}
+}
+
+func (p *Poster) load() error {
+	if p.StateFile == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(p.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return json.Unmarshal(data, &p.state)
+}
+
+func (p *Poster) save() error {
+	if p.StateFile == "" {
+		return nil
+	}
+	p.mutex.Lock()
+	data, err := json.Marshal(p.state)
+	p.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := p.StateFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.StateFile)
+}
+
+func (p *Poster) error(args ...interface{}) {
+	if p.Logger != nil {
+		p.Logger.Error(args...)
+	}
+}
+
+func plainText(s string) string {
+	s = html.UnescapeString(htmlTags.ReplaceAllString(s, " "))
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > 300 {
+		s = string(r[:300]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,79 @@
+package larkfeed
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+func TestParse(t *testing.T) {
+	entries, err := Parse([]byte(`<?xml version="1.0"?>
+<rss version="2.0"><channel><title>t</title>
+<item><title>Hello</title><link>https://example.com/1</link>
+<description>&lt;p&gt;first &amp;amp; only&lt;/p&gt;</description></item>
+</channel></rss>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Id != "https://example.com/1" || entries[0].Summary != "first & only" {
+		t.Errorf("bad rss entries: %+v", entries)
+	}
+
+	entries, err = Parse([]byte(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<entry><id>urn:1</id><title>Hi</title>
+<link rel="alternate" href="https://example.com/a"/><summary>sum</summary></entry>
+</feed>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Id != "urn:1" || entries[0].Link != "https://example.com/a" {
+		t.Errorf("bad atom entries: %+v", entries)
+	}
+}
+
+func TestPollPartialFailure(t *testing.T) {
+	var mutex sync.Mutex
+	var sent []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/v3/tenant_access_token/internal" {
+			io.WriteString(w, `{"code":0,"msg":"ok","tenant_access_token":"t-test","expire":7200}`)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), "oc_bad") {
+			io.WriteString(w, `{"code":230002,"msg":"bot not in chat"}`)
+			return
+		}
+		mutex.Lock()
+		sent = append(sent, string(body))
+		mutex.Unlock()
+		io.WriteString(w, `{"code":0,"msg":"ok","data":{"message_id":"om_1"}}`)
+	}))
+	defer api.Close()
+	items := `<item><title>First</title><link>https://example.com/1</link></item>`
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<?xml version="1.0"?><rss version="2.0"><channel>`+items+`</channel></rss>`)
+	}))
+	defer feed.Close()
+	p := &Poster{
+		API:   larkslim.NewAPI("cli_test", "secret"),
+		Feeds: []Feed{{URL: feed.URL, Targets: []string{"oc_good", "oc_bad"}}},
+	}
+	p.API.BaseURL = api.URL
+	p.Poll()
+	items = `<item><title>Second</title><link>https://example.com/2</link></item>` + items
+	p.Poll()
+	p.Poll()
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(sent) != 1 || !strings.Contains(sent[0], "Second") {
+		t.Errorf("entry should be posted to the good target once: %q", sent)
+	}
+}
@@ -0,0 +1,160 @@
+// Package larkoncall sends messages to whoever is currently on call, as
+// reported by an on-call schedule such as PagerDuty:
+//
+//	pager := &larkoncall.Pager{
+//		API: api,
+//		Schedule: &larkoncall.PagerDuty{
+//			Token:              token,
+//			EscalationPolicies: map[string]string{"db": "PABC123"},
+//		},
+//	}
+//	pager.Escalate("db", "Primary database is down", "phone")
+package larkoncall
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Schedule reports who is on call for a team.
+	Schedule interface {
+		// CurrentOnCall returns open ids (ou_...) or emails of users
+		// currently on call for the team.
+		CurrentOnCall(team string) ([]string, error)
+	}
+
+	// ScheduleFunc adapts a func to Schedule.
+	ScheduleFunc func(team string) ([]string, error)
+
+	// Static is a fixed schedule of teams to open ids or emails.
+	Static map[string][]string
+
+	// Pager sends messages to users on call.
+	Pager struct {
+		API      *larkslim.API
+		Schedule Schedule
+
+		// if set, used when Schedule returns no one or fails, for
+		// example a chat id of the whole team
+		Fallback string
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+	}
+)
+
+// ErrNoOneOnCall is returned when no one is on call and there is no
+// Fallback.
+var ErrNoOneOnCall = errors.New("no one is on call")
+
+func (f ScheduleFunc) CurrentOnCall(team string) ([]string, error) {
+	return f(team)
+}
+
+func (s Static) CurrentOnCall(team string) ([]string, error) {
+	return s[team], nil
+}
+
+// Notify sends text to everyone on call for the team, and returns the ids
+// of the messages sent.
+func (p *Pager) Notify(team, text string) (messageIds map[string]string, err error) {
+	targets, err := p.targets(team)
+	if err != nil {
+		return
+	}
+	messageIds = map[string]string{}
+	for _, target := range targets {
+		var messageId string
+		messageId, err = p.API.SendMessage(target, text)
+		if err != nil {
+			p.error("on-call", team, target, err)
+			continue
+		}
+		messageIds[target] = messageId
+	}
+	if len(messageIds) > 0 {
+		err = nil
+	}
+	return
+}
+
+// Escalate sends text to everyone on call for the team like Notify, then
+// buzzes users of open ids with the urgent kind ("app", "sms" or "phone",
+// see larkslim.API.Urgent). Emails are resolved to open ids, users of emails
+// that can't be resolved are only notified.
+func (p *Pager) Escalate(team, text, kind string) error {
+	messageIds, err := p.Notify(team, text)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for target, messageId := range messageIds {
+		if !strings.HasPrefix(target, "ou_") || messageId == "" {
+			continue
+		}
+		invalid, err := p.API.Urgent(messageId, kind, []string{target})
+		if err == nil && len(invalid) > 0 {
+			err = errors.New("can't buzz " + strings.Join(invalid, ", "))
+		}
+		if err != nil {
+			p.error("on-call", team, target, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// targets returns users on call for the team, or Fallback.
+func (p *Pager) targets(team string) ([]string, error) {
+	targets, err := p.Schedule.CurrentOnCall(team)
+	if err != nil {
+		p.error("on-call", team, err)
+	}
+	if len(targets) > 0 {
+		return p.resolveEmails(team, targets), nil
+	}
+	if p.Fallback != "" {
+		return []string{p.Fallback}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrNoOneOnCall
+}
+
+// resolveEmails replaces emails in targets with open ids of the users, so
+// they can be buzzed. Emails that can't be resolved are kept.
+func (p *Pager) resolveEmails(team string, targets []string) []string {
+	var emails []string
+	for _, target := range targets {
+		if strings.Contains(target, "@") {
+			emails = append(emails, target)
+		}
+	}
+	if len(emails) == 0 {
+		return targets
+	}
+	openIds, err := p.API.GetUserIdsByEmails(emails)
+	if err != nil {
+		p.error("on-call", team, err)
+		return targets
+	}
+	resolved := make([]string, len(targets))
+	for i, target := range targets {
+		if openId, ok := openIds[target]; ok {
+			target = openId
+		}
+		resolved[i] = target
+	}
+	return resolved
+}
+
+func (p *Pager) error(args ...interface{}) {
+	if p.Logger != nil {
+		p.Logger.Error(args...)
+	}
+}
@@ -0,0 +1,83 @@
+package larkoncall
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func TestPagerDuty(t *testing.T) {
+	pd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token token=secret" || r.URL.Query().Get("escalation_policy_ids[]") != "P1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"oncalls":[` +
+			`{"escalation_level":1,"user":{"email":"a@example.com"}},` +
+			`{"escalation_level":1,"user":{"email":"b@example.com"}},` +
+			`{"escalation_level":2,"user":{"email":"boss@example.com"}}]}`))
+	}))
+	defer pd.Close()
+	schedule := &PagerDuty{
+		Token:              "secret",
+		EscalationPolicies: map[string]string{"db": "P1"},
+		OpenIds:            map[string]string{"a@example.com": "ou_a"},
+		BaseURL:            pd.URL,
+	}
+	targets, err := schedule.CurrentOnCall("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(targets, ",") != "ou_a,b@example.com" {
+		t.Errorf("wrong targets: %v", targets)
+	}
+	if _, err := schedule.CurrentOnCall("web"); err == nil {
+		t.Error("unknown team should fail")
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	server.Handle("/im/v1/messages/om_1/urgent_phone", map[string]interface{}{"code": 0, "msg": "success"})
+	server.Handle("/im/v1/messages/om_2/urgent_phone", map[string]interface{}{"code": 0, "msg": "success"})
+	// b is not mapped by the schedule, c is not in the contact
+	server.Handle("/contact/v3/users/batch_get_id", map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"user_list": []map[string]string{
+				{"email": "b@example.com", "user_id": "ou_b"},
+				{"email": "c@example.com"},
+			},
+		},
+	})
+	pager := &Pager{
+		API:      server.API(),
+		Schedule: Static{"db": {"ou_a", "b@example.com", "c@example.com"}},
+		Fallback: "oc_team",
+	}
+	if err := pager.Escalate("db", "database is down", "phone"); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, req := range server.Requests()[1:] {
+		got = append(got, req.Method+" "+req.Path)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "PATCH /im/v1/messages/om_1/urgent_phone,PATCH /im/v1/messages/om_2/urgent_phone,"+
+		"POST /contact/v3/users/batch_get_id,POST /message/v4/send/,POST /message/v4/send/,POST /message/v4/send/" {
+		t.Errorf("wrong requests: %v", got)
+	}
+	ids, err := pager.Notify("web", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ids["oc_team"]; !ok || len(ids) != 1 {
+		t.Errorf("should notify fallback: %v", ids)
+	}
+}
@@ -0,0 +1,93 @@
+package larkoncall
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type (
+	// PagerDuty is a Schedule of the first level of PagerDuty escalation
+	// policies.
+	PagerDuty struct {
+		// REST API key
+		Token string
+
+		// teams to escalation policy ids
+		EscalationPolicies map[string]string
+
+		// emails of PagerDuty users to open ids; users not in the map
+		// are returned as emails, which Pager looks up in the contact
+		OpenIds map[string]string
+
+		// defaults to "https://api.pagerduty.com"
+		BaseURL string
+
+		// defaults to a client with 10-second timeout
+		HTTPClient *http.Client
+	}
+
+	pagerDutyOnCalls struct {
+		OnCalls []struct {
+			EscalationLevel int `json:"escalation_level"`
+			User            struct {
+				Email string `json:"email"`
+			} `json:"user"`
+		} `json:"oncalls"`
+	}
+)
+
+func (pd *PagerDuty) CurrentOnCall(team string) (targets []string, err error) {
+	policy, ok := pd.EscalationPolicies[team]
+	if !ok {
+		err = fmt.Errorf("no escalation policy for team %q", team)
+		return
+	}
+	baseURL := pd.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.pagerduty.com"
+	}
+	query := url.Values{}
+	query.Set("escalation_policy_ids[]", policy)
+	query.Set("include[]", "users")
+	query.Set("earliest", "true")
+	req, err := http.NewRequest("GET", baseURL+"/oncalls?"+query.Encode(), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Authorization", "Token token="+pd.Token)
+	client := pd.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("pagerduty: %s", res.Status)
+		return
+	}
+	var data pagerDutyOnCalls
+	if err = json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return
+	}
+	seen := map[string]bool{}
+	for _, oncall := range data.OnCalls {
+		email := oncall.User.Email
+		if oncall.EscalationLevel != 1 || email == "" || seen[email] {
+			continue
+		}
+		seen[email] = true
+		if openId, ok := pd.OpenIds[email]; ok {
+			targets = append(targets, openId)
+		} else {
+			targets = append(targets, email)
+		}
+	}
+	return
+}
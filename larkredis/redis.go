@@ -0,0 +1,195 @@
+// Package larkredis implements larkslim.Cache with Redis, so that the access
+// token, image keys and dedup windows are shared by multiple processes:
+//
+//	api.Cache = &larkredis.Cache{Addr: "localhost:6379"}
+package larkredis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// Cache is a larkslim.Cache using a single connection to Redis, which
+	// is reconnected after errors.
+	Cache struct {
+		// defaults to "localhost:6379"
+		Addr string
+
+		Password string
+		DB       int
+
+		// defaults to 5 seconds
+		Timeout time.Duration
+
+		mutex sync.Mutex
+		conn  net.Conn
+		r     *bufio.Reader
+	}
+
+	// Error is an error reply of Redis.
+	Error string
+)
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+func (c *Cache) Get(key string) (value []byte, ok bool, err error) {
+	var reply interface{}
+	reply, err = c.do("GET", key)
+	if err != nil || reply == nil {
+		return
+	}
+	value, ok = reply.([]byte)
+	return
+}
+
+func (c *Cache) Set(key string, value []byte, ttl time.Duration) (err error) {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		ms := ttl.Milliseconds()
+		if ms < 1 {
+			ms = 1
+		}
+		args = append(args, "PX", strconv.FormatInt(ms, 10))
+	}
+	_, err = c.do(args...)
+	return
+}
+
+func (c *Cache) Delete(key string) (err error) {
+	_, err = c.do("DEL", key)
+	return
+}
+
+// Close closes the connection.
+func (c *Cache) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.close()
+}
+
+func (c *Cache) do(args ...string) (reply interface{}, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.conn == nil {
+		if err = c.connect(); err != nil {
+			return
+		}
+	}
+	reply, err = c.command(args...)
+	if err != nil {
+		if _, ok := err.(Error); !ok {
+			c.close()
+		}
+	}
+	return
+}
+
+func (c *Cache) connect() (err error) {
+	addr := c.Addr
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	c.conn, err = net.DialTimeout("tcp", addr, c.timeout())
+	if err != nil {
+		c.conn = nil
+		return
+	}
+	c.r = bufio.NewReader(c.conn)
+	if c.Password != "" {
+		if _, err = c.command("AUTH", c.Password); err != nil {
+			c.close()
+			return
+		}
+	}
+	if c.DB != 0 {
+		if _, err = c.command("SELECT", strconv.Itoa(c.DB)); err != nil {
+			c.close()
+			return
+		}
+	}
+	return
+}
+
+func (c *Cache) close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.r = nil, nil
+	return err
+}
+
+func (c *Cache) command(args ...string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(c.timeout()))
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
+
+func (c *Cache) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return 5 * time.Second
+}
+
+// readReply reads a RESP reply. Bulk strings are []byte, simple strings are
+// string, integers are int64 and arrays are []interface{}; nil bulk strings
+// and arrays are nil.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: bad reply: " + strconv.Quote(line))
+	}
+	kind, line := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, Error(line)
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
@@ -0,0 +1,76 @@
+package larkredis
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+var _ larkslim.Cache = &Cache{}
+
+// fakeRedis handles GET, SET and DEL of one connection.
+func fakeRedis(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		conn, err := ln.Accept()
+		ln.Close()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		data := map[string][]byte{}
+		for {
+			reply, err := readReply(r)
+			if err != nil {
+				return
+			}
+			var args []string
+			for _, arg := range reply.([]interface{}) {
+				args = append(args, string(arg.([]byte)))
+			}
+			switch strings.ToUpper(args[0]) {
+			case "GET":
+				if v, ok := data[args[1]]; ok {
+					conn.Write([]byte("$" + string(rune('0'+len(v))) + "\r\n" + string(v) + "\r\n"))
+				} else {
+					conn.Write([]byte("$-1\r\n"))
+				}
+			case "SET":
+				data[args[1]] = []byte(args[2])
+				conn.Write([]byte("+OK\r\n"))
+			case "DEL":
+				delete(data, args[1])
+				conn.Write([]byte(":1\r\n"))
+			default:
+				conn.Write([]byte("-ERR unknown command\r\n"))
+			}
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestCache(t *testing.T) {
+	c := &Cache{Addr: fakeRedis(t)}
+	defer c.Close()
+	if err := c.Set("key", []byte("value"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	value, ok, err := c.Get("key")
+	if err != nil || !ok || string(value) != "value" {
+		t.Errorf("wrong value: %q %t %v", value, ok, err)
+	}
+	if err := c.Delete("key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := c.Get("key"); err != nil || ok {
+		t.Errorf("key should be deleted: %t %v", ok, err)
+	}
+}
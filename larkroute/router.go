@@ -0,0 +1,263 @@
+// Package larkroute sends alerts to targets chosen by rules in a JSON
+// configuration file, so notification routing lives in config rather than
+// code.
+//
+// The configuration is JSON rather than YAML because larkslim depends only on
+// the standard library, which has no YAML parser. YAML files can be converted
+// with tools like yq (yq -o=json routes.yaml), or parsed by the caller and
+// passed to Router.SetConfig.
+//
+// Example configuration:
+//
+//	{
+//	  "routes": [
+//	    {"severities": ["critical"], "targets": ["oc_oncall"], "style": "card", "color": "red"},
+//	    {"teams": ["db"], "tags": ["backup"], "targets": ["oc_db"], "continue": true},
+//	    {"targets": ["oc_misc"]}
+//	  ]
+//	}
+package larkroute
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	Config struct {
+		Routes []Route `json:"routes"`
+	}
+
+	// Route matches alerts whose severity is one of Severities, team is one
+	// of Teams and which have all of Tags. Empty lists match anything.
+	Route struct {
+		Severities []string `json:"severities"`
+		Teams      []string `json:"teams"`
+		Tags       []string `json:"tags"`
+
+		// open_ids, user_ids, emails or chat_ids
+		Targets []string `json:"targets"`
+
+		// "text" (default) or "card"
+		Style string `json:"style"`
+
+		// header color of cards, for example "red"
+		Color string `json:"color"`
+
+		// if true, following routes are also checked after this route
+		// matched
+		Continue bool `json:"continue"`
+	}
+
+	Alert struct {
+		Severity string
+		Team     string
+		Tags     []string
+		Title    string
+		Text     string
+	}
+
+	Router struct {
+		API *larkslim.API
+
+		// path to the JSON configuration file
+		File string
+
+		Logger interface {
+			Info(args ...interface{})
+			Error(args ...interface{})
+		}
+
+		mutex   sync.RWMutex
+		config  Config
+		modTime time.Time
+	}
+)
+
+var ErrNoRoute = errors.New("no route matches the alert")
+
+// NewRouter creates a router and loads the configuration file.
+func NewRouter(api *larkslim.API, file string) (*Router, error) {
+	r := &Router{
+		API:  api,
+		File: file,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the configuration file again.
+func (r *Router) Reload() error {
+	fi, err := os.Stat(r.File)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(r.File)
+	if err != nil {
+		return err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	r.SetConfig(config)
+	r.mutex.Lock()
+	r.modTime = fi.ModTime()
+	r.mutex.Unlock()
+	return nil
+}
+
+// SetConfig replaces the configuration.
+func (r *Router) SetConfig(config Config) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.config = config
+}
+
+// Watch reloads the configuration file every interval if it has been
+// modified, until stop is closed. Invalid configuration is logged and the
+// previous configuration is kept.
+func (r *Router) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		fi, err := os.Stat(r.File)
+		if err != nil {
+			r.error(err)
+			continue
+		}
+		r.mutex.RLock()
+		modified := !fi.ModTime().Equal(r.modTime)
+		r.mutex.RUnlock()
+		if !modified {
+			continue
+		}
+		if err := r.Reload(); err != nil {
+			r.error(err)
+			continue
+		}
+		if r.Logger != nil {
+			r.Logger.Info("reloaded", r.File)
+		}
+	}
+}
+
+// Match returns routes matching the alert.
+func (r *Router) Match(alert Alert) (routes []Route) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, route := range r.config.Routes {
+		if !route.Match(alert) {
+			continue
+		}
+		routes = append(routes, route)
+		if !route.Continue {
+			break
+		}
+	}
+	return
+}
+
+// Send sends the alert to targets of all matching routes.
+func (r *Router) Send(alert Alert) (err error) {
+	routes := r.Match(alert)
+	if len(routes) == 0 {
+		return ErrNoRoute
+	}
+	for _, route := range routes {
+		for _, target := range route.Targets {
+			var e error
+			if route.Style == "card" {
+				_, e = r.API.SendCard(target, alert.Card(route.Color))
+			} else {
+				_, e = r.API.SendMessage(target, alert.String())
+			}
+			if e != nil {
+				r.error(e)
+				err = e
+			}
+		}
+	}
+	return
+}
+
+func (route Route) Match(alert Alert) bool {
+	if len(route.Severities) > 0 && !contains(route.Severities, alert.Severity) {
+		return false
+	}
+	if len(route.Teams) > 0 && !contains(route.Teams, alert.Team) {
+		return false
+	}
+	for _, tag := range route.Tags {
+		if !contains(alert.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func (alert Alert) String() string {
+	s := alert.Title
+	if alert.Severity != "" {
+		s = "[" + alert.Severity + "] " + s
+	}
+	if alert.Text != "" {
+		s += "\n" + alert.Text
+	}
+	return s
+}
+
+func (alert Alert) Card(color string) larkslim.Card {
+	title := alert.Title
+	if alert.Severity != "" {
+		title = "[" + alert.Severity + "] " + title
+	}
+	card := larkslim.Card{
+		Config: larkslim.CardConfig{
+			WideScreenMode: true,
+			EnableForward:  true,
+		},
+		Header: larkslim.CardHeader{
+			Title: larkslim.CardHeaderTitle{
+				Tag:     "plain_text",
+				Content: title,
+			},
+			Template: color,
+		},
+	}
+	if alert.Text != "" {
+		card.Elements = append(card.Elements, map[string]interface{}{
+			"tag":     "markdown",
+			"content": alert.Text,
+		})
+	}
+	return card
+}
+
+func (r *Router) error(err error) {
+	if r.Logger != nil {
+		r.Logger.Error(err)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package larkroute
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	var config Config
+	err := json.Unmarshal([]byte(`{"routes": [
+		{"severities": ["critical"], "targets": ["oc_oncall"], "style": "card", "color": "red"},
+		{"teams": ["db"], "tags": ["backup"], "targets": ["oc_db"], "continue": true},
+		{"targets": ["oc_misc"]}
+	]}`), &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Router{}
+	r.SetConfig(config)
+	for _, test := range []struct {
+		alert   Alert
+		targets []string
+	}{
+		{Alert{Severity: "critical", Team: "db"}, []string{"oc_oncall"}},
+		{Alert{Team: "db", Tags: []string{"backup", "nightly"}}, []string{"oc_db", "oc_misc"}},
+		{Alert{Team: "db"}, []string{"oc_misc"}},
+	} {
+		var targets []string
+		for _, route := range r.Match(test.alert) {
+			targets = append(targets, route.Targets...)
+		}
+		if len(targets) != len(test.targets) {
+			t.Errorf("%+v: targets should be %v, got %v", test.alert, test.targets, targets)
+			continue
+		}
+		for i := range targets {
+			if targets[i] != test.targets[i] {
+				t.Errorf("%+v: targets should be %v, got %v", test.alert, test.targets, targets)
+			}
+		}
+	}
+}
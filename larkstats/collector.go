@@ -0,0 +1,174 @@
+// Package larkstats periodically samples statistics of chats, for example
+// to power community health dashboards.
+package larkstats
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Sample is the statistics of a chat at a time.
+	Sample struct {
+		Time   time.Time
+		ChatId string
+		Name   string
+
+		// number of users and bots in the chat
+		Users int
+		Bots  int
+
+		// number of messages and their distinct senders in the Interval
+		// before Time, only collected if Messages of Collector is true
+		Messages int
+		Senders  int
+	}
+
+	// Sink stores samples, for example in a time series database.
+	Sink interface {
+		WriteSamples(samples []Sample) error
+	}
+
+	// SinkFunc adapts a func to Sink.
+	SinkFunc func(samples []Sample) error
+
+	// Collector samples chats every Interval and writes the samples to
+	// Sink.
+	Collector struct {
+		API  *larkslim.API
+		Sink Sink
+
+		// chats to sample, empty to sample all chats the bot is in
+		ChatIds []string
+
+		// defaults to 1 hour
+		Interval time.Duration
+
+		// count messages of the Interval with ListMessages, which needs the
+		// im:message.group_msg scope to read messages of groups
+		Messages bool
+
+		// defaults to larkslim.SystemClock
+		Clock larkslim.Clock
+
+		Logger interface {
+			Error(args ...interface{})
+		}
+	}
+)
+
+func (f SinkFunc) WriteSamples(samples []Sample) error {
+	return f(samples)
+}
+
+// Run collects and writes samples every Interval until stop is closed.
+func (c *Collector) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.interval())
+	defer ticker.Stop()
+	for {
+		samples, err := c.Collect()
+		if err != nil {
+			c.error(err)
+		}
+		if len(samples) > 0 {
+			if err := c.Sink.WriteSamples(samples); err != nil {
+				c.error(err)
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect samples the chats once. Chats that fail to be sampled are logged
+// and skipped.
+func (c *Collector) Collect() (samples []Sample, err error) {
+	chatIds := c.ChatIds
+	if len(chatIds) == 0 {
+		var groups larkslim.Groups
+		groups, err = c.API.ListAllChats()
+		if err != nil {
+			return
+		}
+		for _, group := range groups {
+			chatIds = append(chatIds, group.ChatId)
+		}
+	}
+	for _, chatId := range chatIds {
+		chat, err := c.API.GetChat(chatId)
+		if err != nil {
+			c.error(chatId, err)
+			continue
+		}
+		sample := Sample{
+			Time:   c.clock().Now(),
+			ChatId: chatId,
+			Name:   chat.Name,
+			Users:  chat.UserCount,
+			Bots:   chat.BotCount,
+		}
+		if c.Messages {
+			sample.Messages, sample.Senders, err = c.countMessages(chatId, sample.Time.Add(-c.interval()))
+			if err != nil {
+				c.error(chatId, err)
+				continue
+			}
+		}
+		samples = append(samples, sample)
+	}
+	return
+}
+
+// countMessages counts messages of the chat sent after since and their
+// distinct senders.
+func (c *Collector) countMessages(chatId string, since time.Time) (messages, senders int, err error) {
+	sinceMs := since.UnixNano() / int64(time.Millisecond)
+	seen := map[string]bool{}
+	var pageToken string
+	for {
+		var page []larkslim.Message
+		page, pageToken, err = c.API.ListMessages(chatId, pageToken)
+		if err != nil {
+			return
+		}
+		for _, message := range page {
+			// messages are listed newest first
+			if ms, _ := strconv.ParseInt(message.CreateTime, 10, 64); ms < sinceMs {
+				return
+			}
+			messages++
+			if !seen[message.Sender.Id] {
+				seen[message.Sender.Id] = true
+				senders++
+			}
+		}
+		if pageToken == "" {
+			return
+		}
+	}
+}
+
+func (c *Collector) interval() time.Duration {
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return time.Hour
+}
+
+func (c *Collector) clock() larkslim.Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+	return larkslim.SystemClock
+}
+
+func (c *Collector) error(args ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Error(args...)
+	}
+}
@@ -0,0 +1,75 @@
+package larkstats
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func TestCollect(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	server.Handle("/im/v1/chats/oc_test", map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"name":       "test",
+			"user_count": "42",
+			"bot_count":  "2",
+		},
+	})
+	c := &Collector{
+		API:     server.API(),
+		ChatIds: []string{"oc_test", "oc_missing"},
+	}
+	samples, err := c.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 || samples[0].Name != "test" || samples[0].Users != 42 || samples[0].Bots != 2 {
+		t.Errorf("wrong samples: %+v", samples)
+	}
+}
+
+func TestCollectMessages(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	ms := func(d time.Duration) string {
+		return strconv.FormatInt(now.Add(-d).UnixNano()/int64(time.Millisecond), 10)
+	}
+	server.Handle("/im/v1/chats/oc_test", map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{"name": "test"},
+	})
+	server.Handle("/im/v1/messages", map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"items": []map[string]interface{}{
+				{"message_id": "om_3", "create_time": ms(time.Minute), "sender": map[string]string{"id": "ou_a"}},
+				{"message_id": "om_2", "create_time": ms(30 * time.Minute), "sender": map[string]string{"id": "ou_b"}},
+				{"message_id": "om_1", "create_time": ms(40 * time.Minute), "sender": map[string]string{"id": "ou_a"}},
+				{"message_id": "om_0", "create_time": ms(2 * time.Hour), "sender": map[string]string{"id": "ou_c"}},
+			},
+			"has_more":   true,
+			"page_token": "p2",
+		},
+	})
+	c := &Collector{
+		API:      server.API(),
+		ChatIds:  []string{"oc_test"},
+		Messages: true,
+		Clock:    larktest.NewFakeClock(now),
+	}
+	samples, err := c.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 || samples[0].Messages != 3 || samples[0].Senders != 2 {
+		t.Errorf("wrong samples: %+v", samples)
+	}
+}
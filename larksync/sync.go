@@ -0,0 +1,300 @@
+// Package larksync reconciles Lark chats with groups of an external
+// directory such as LDAP or SCIM.
+//
+// Groups are provided by a Source. Each group is mapped to a chat, which is
+// created if the group has not been synced before, and members of the chat
+// are synced with members of the group:
+//
+//	syncer := &larksync.Syncer{API: api, Source: source, StateFile: "sync.json"}
+//	go syncer.Run(stop)
+package larksync
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Source provides group definitions, for example by querying LDAP or a
+	// SCIM endpoint.
+	Source interface {
+		Groups() ([]Group, error)
+	}
+
+	// SourceFunc adapts a func to Source.
+	SourceFunc func() ([]Group, error)
+
+	Group struct {
+		// unique id of the group in the directory
+		Id string
+
+		// name of the chat created for the group
+		Name string
+
+		// emails or open ids (ou_...) of members
+		Members []string
+	}
+
+	// Drift is the difference between a group and its chat found by Sync.
+	Drift struct {
+		GroupId string
+		ChatId  string
+
+		// chat has been created (or would be created in dry-run mode)
+		Created bool
+
+		// open ids of users to add to or remove from the chat
+		larkslim.MemberDiff
+
+		// members of the group not found in the directory of Lark
+		Unknown []string
+	}
+
+	// Syncer syncs groups of Source to chats every Interval. Chats of
+	// groups are saved to StateFile, so they are not created again after
+	// restart.
+	Syncer struct {
+		API    *larkslim.API
+		Source Source
+
+		// defaults to 1 hour
+		Interval time.Duration
+
+		// path to JSON file to persist group id to chat id mapping, empty
+		// to keep state in memory
+		StateFile string
+
+		// only report drifts, don't create chats or change members
+		DryRun bool
+
+		// caches email to open id lookups, defaults to a MemoryCache
+		Cache larkslim.Cache
+
+		// how long found open ids are cached, zero means forever; emails
+		// not found are not cached
+		CacheTTL time.Duration
+
+		Logger interface {
+			Info(args ...interface{})
+			Error(args ...interface{})
+		}
+
+		mutex  sync.Mutex
+		loaded bool
+		chats  map[string]string // group id -> chat id
+		cache  larkslim.Cache
+	}
+)
+
+func (f SourceFunc) Groups() ([]Group, error) {
+	return f()
+}
+
+// Run syncs all groups every Interval until stop is closed.
+func (s *Syncer) Run(stop <-chan struct{}) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	interval := s.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Sync(); err != nil {
+			s.error(err)
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync syncs all groups once and returns drifts of groups that are not in
+// sync. Errors of individual groups are logged and don't stop other groups
+// from being synced.
+func (s *Syncer) Sync() (drifts []Drift, err error) {
+	if err = s.load(); err != nil {
+		return
+	}
+	var groups []Group
+	groups, err = s.Source.Groups()
+	if err != nil {
+		return
+	}
+	for _, group := range groups {
+		drift, err := s.sync(group)
+		if err != nil {
+			s.error(group.Id, err)
+			continue
+		}
+		if drift.Created || len(drift.Add) > 0 || len(drift.Remove) > 0 || len(drift.Unknown) > 0 {
+			s.info("drift", group.Id, drift.ChatId, "created:", drift.Created,
+				"add:", drift.Add, "remove:", drift.Remove, "unknown:", drift.Unknown)
+			drifts = append(drifts, drift)
+		}
+	}
+	err = s.save()
+	return
+}
+
+func (s *Syncer) sync(group Group) (drift Drift, err error) {
+	drift.GroupId = group.Id
+	var members []string
+	members, drift.Unknown, err = s.resolve(group.Members)
+	if err != nil {
+		return
+	}
+	s.mutex.Lock()
+	chatId := s.chats[group.Id]
+	s.mutex.Unlock()
+	if chatId == "" {
+		drift.Created = true
+		drift.Add = members
+		if s.DryRun || len(members) == 0 {
+			return
+		}
+		chatId, err = s.API.CreateChat(group.Name, members[0])
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		if s.chats == nil {
+			s.chats = map[string]string{}
+		}
+		s.chats[group.Id] = chatId
+		s.mutex.Unlock()
+	}
+	drift.ChatId = chatId
+	var diff larkslim.MemberDiff
+	diff, err = s.API.SyncChatMembers(chatId, members, s.DryRun)
+	if !drift.Created {
+		drift.MemberDiff = diff
+	}
+	return
+}
+
+// resolve converts emails to open ids, using cached results of previous
+// lookups. Emails not found are looked up again next time, since the users
+// may join later.
+func (s *Syncer) resolve(members []string) (openIds, unknown []string, err error) {
+	cache := s.getCache()
+	openId := map[string]string{} // email -> open id
+	var lookup []string
+	for _, member := range members {
+		if !strings.Contains(member, "@") {
+			continue
+		}
+		var value []byte
+		var ok bool
+		value, ok, err = cache.Get(cacheKey(member))
+		if err != nil {
+			return
+		}
+		if ok {
+			openId[member] = string(value)
+		} else {
+			lookup = append(lookup, member)
+		}
+	}
+	if len(lookup) > 0 {
+		var found map[string]string
+		found, err = s.API.GetUserIdsByEmails(lookup)
+		if err != nil {
+			return
+		}
+		for _, email := range lookup {
+			// empty open id means the email is not found
+			openId[email] = found[email]
+			if found[email] == "" {
+				continue
+			}
+			if err = cache.Set(cacheKey(email), []byte(found[email]), s.CacheTTL); err != nil {
+				return
+			}
+		}
+	}
+	for _, member := range members {
+		if !strings.Contains(member, "@") {
+			openIds = append(openIds, member)
+		} else if id := openId[member]; id != "" {
+			openIds = append(openIds, id)
+		} else {
+			unknown = append(unknown, member)
+		}
+	}
+	return
+}
+
+func (s *Syncer) getCache() larkslim.Cache {
+	if s.Cache != nil {
+		return s.Cache
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cache == nil {
+		s.cache = &larkslim.MemoryCache{}
+	}
+	return s.cache
+}
+
+func cacheKey(email string) string {
+	return "larksync:open_id:" + email
+}
+
+// load reads StateFile once, so that Sync called without Run doesn't create
+// chats of synced groups again.
+func (s *Syncer) load() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.loaded || s.StateFile == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(s.StateFile)
+	if err == nil {
+		err = json.Unmarshal(data, &s.chats)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.loaded = true
+	return nil
+}
+
+func (s *Syncer) save() error {
+	if s.StateFile == "" {
+		return nil
+	}
+	s.mutex.Lock()
+	data, err := json.Marshal(s.chats)
+	s.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := s.StateFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.StateFile)
+}
+
+func (s *Syncer) info(args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Info(args...)
+	}
+}
+
+func (s *Syncer) error(args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Error(args...)
+	}
+}
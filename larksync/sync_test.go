@@ -0,0 +1,108 @@
+package larksync
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/caiguanhao/larkslim/larktest"
+)
+
+func TestSync(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	server.Handle("/contact/v3/users/batch_get_id", map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"user_list": []map[string]string{
+				{"email": "a@example.com", "user_id": "ou_a"},
+				{"email": "x@example.com"},
+			},
+		},
+	})
+	server.Handle("/chat/v4/info/", map[string]interface{}{
+		"code": 0,
+		"msg":  "ok",
+		"data": map[string]interface{}{
+			"chat_id":       "oc_dev",
+			"owner_open_id": "ou_owner",
+			"members": []map[string]string{
+				{"open_id": "ou_owner"},
+				{"open_id": "ou_a"},
+				{"open_id": "ou_old"},
+			},
+		},
+	})
+	server.Handle("/chat/v4/chatter/add/", map[string]interface{}{"code": 0, "msg": "ok"})
+	server.Handle("/chat/v4/chatter/delete/", map[string]interface{}{"code": 0, "msg": "ok"})
+	s := &Syncer{
+		API: server.API(),
+		Source: SourceFunc(func() ([]Group, error) {
+			return []Group{{
+				Id:      "cn=dev",
+				Name:    "dev",
+				Members: []string{"a@example.com", "ou_b", "x@example.com"},
+			}}, nil
+		}),
+		chats: map[string]string{"cn=dev": "oc_dev"},
+	}
+	drifts, err := s.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifts) != 1 {
+		t.Fatalf("should have 1 drift, got %d", len(drifts))
+	}
+	d := drifts[0]
+	if d.Created || len(d.Add) != 1 || d.Add[0] != "ou_b" || len(d.Remove) != 1 || d.Remove[0] != "ou_old" ||
+		len(d.Unknown) != 1 || d.Unknown[0] != "x@example.com" {
+		t.Errorf("wrong drift: %+v", d)
+	}
+	var added struct {
+		OpenIds []string `json:"open_ids"`
+	}
+	for _, req := range server.Requests() {
+		if req.Path == "/chat/v4/chatter/add/" {
+			json.Unmarshal(req.Body, &added)
+		}
+	}
+	if len(added.OpenIds) != 1 || added.OpenIds[0] != "ou_b" {
+		t.Errorf("wrong users added: %v", added.OpenIds)
+	}
+	if _, ok, _ := s.getCache().Get(cacheKey("x@example.com")); ok {
+		t.Error("emails not found should not be cached")
+	}
+}
+
+func TestSyncLoadsState(t *testing.T) {
+	server := larktest.NewServer()
+	defer server.Close()
+	server.Handle("/chat/v4/info/", map[string]interface{}{
+		"code": 0,
+		"msg":  "ok",
+		"data": map[string]interface{}{
+			"chat_id": "oc_dev",
+			"members": []map[string]string{{"open_id": "ou_a"}},
+		},
+	})
+	file := filepath.Join(t.TempDir(), "sync.json")
+	if err := ioutil.WriteFile(file, []byte(`{"cn=dev":"oc_dev"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := &Syncer{
+		API: server.API(),
+		Source: SourceFunc(func() ([]Group, error) {
+			return []Group{{Id: "cn=dev", Name: "dev", Members: []string{"ou_a"}}}, nil
+		}),
+		StateFile: file,
+	}
+	drifts, err := s.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifts) != 0 {
+		t.Errorf("chat in state file should not be created again: %+v", drifts)
+	}
+}
@@ -0,0 +1,100 @@
+package larktest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// FakeClock is a larkslim.Clock whose time only changes when Sleep or
+	// Advance is called, so tests of expiry and backoff run instantly.
+	// Funcs of AfterFunc are called by Sleep and Advance when their time
+	// has come.
+	FakeClock struct {
+		mutex  sync.Mutex
+		now    time.Time
+		slept  []time.Duration
+		timers []*fakeTimer
+	}
+
+	fakeTimer struct {
+		clock *FakeClock
+		at    time.Time
+		f     func()
+	}
+)
+
+// NewFakeClock returns a FakeClock starting at t.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// Sleep advances the clock by d without sleeping and records d.
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.mutex.Lock()
+	c.slept = append(c.slept, d)
+	c.mutex.Unlock()
+	c.Advance(d)
+}
+
+// Advance advances the clock by d and calls funcs of AfterFunc that are
+// due, in the order of their times.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	var due, pending []*fakeTimer
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	c.timers = pending
+	c.mutex.Unlock()
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].at.Before(due[j].at)
+	})
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// AfterFunc calls f when the clock is advanced by d or more. Unlike
+// time.AfterFunc, f is called in the goroutine calling Sleep or Advance.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) larkslim.Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Slept returns durations passed to Sleep so far.
+func (c *FakeClock) Slept() []time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]time.Duration(nil), c.slept...)
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package larktest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	var fired []string
+	clock.AfterFunc(2*time.Second, func() { fired = append(fired, "b") })
+	clock.AfterFunc(time.Second, func() { fired = append(fired, "a") })
+	stopped := clock.AfterFunc(time.Second, func() { fired = append(fired, "stopped") })
+	if !stopped.Stop() {
+		t.Error("Stop should return true for a pending timer")
+	}
+	clock.Advance(999 * time.Millisecond)
+	if len(fired) != 0 {
+		t.Errorf("timers should not fire early, got %q", fired)
+	}
+	clock.Sleep(2 * time.Second)
+	if got := strings.Join(fired, ","); got != "a,b" {
+		t.Errorf("timers should fire in order of their times, got %q", got)
+	}
+	if stopped.Stop() {
+		t.Error("Stop should return false for a stopped timer")
+	}
+	if now := clock.Now(); !now.Equal(start.Add(2999 * time.Millisecond)) {
+		t.Errorf("clock should be advanced, got %s", now)
+	}
+	if slept := clock.Slept(); len(slept) != 1 || slept[0] != 2*time.Second {
+		t.Errorf("Sleep should be recorded, got %v", slept)
+	}
+}
@@ -0,0 +1,23 @@
+package larktest
+
+import (
+	"testing"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+func TestAssertGolden(t *testing.T) {
+	post := larkslim.Post{
+		"en_us": larkslim.PostOfLocale{Title: "b"},
+		"zh_cn": larkslim.PostOfLocale{Title: "a"},
+	}
+	AssertGolden(t, "post", post)
+}
+
+func TestDiff(t *testing.T) {
+	got := Diff("a\nb\nc\n", "a\nx\nc\n")
+	want := " a\n-b\n+x\n c\n"
+	if got != want {
+		t.Errorf("diff should be %q, got %q", want, got)
+	}
+}
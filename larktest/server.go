@@ -0,0 +1,130 @@
+package larktest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/caiguanhao/larkslim"
+)
+
+type (
+	// Server is a fake open platform for tests and benchmarks. It issues
+	// access tokens, accepts messages and responds to other paths with
+	// responses set by Handle.
+	Server struct {
+		*httptest.Server
+
+		mutex     sync.Mutex
+		requests  []Request
+		responses map[string]interface{}
+		messageId int
+	}
+
+	Request struct {
+		Method string
+		Path   string
+		Query  string
+		Header http.Header
+		Body   []byte
+	}
+)
+
+// NewServer starts a fake server, call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		responses: map[string]interface{}{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// NewTLSServer starts a fake server with TLS and HTTP/2.
+func NewTLSServer() *Server {
+	s := &Server{
+		responses: map[string]interface{}{},
+	}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serve))
+	s.Server.EnableHTTP2 = true
+	s.Server.StartTLS()
+	return s
+}
+
+// API returns an API using the server.
+func (s *Server) API() *larkslim.API {
+	api := larkslim.NewAPI("cli_test", "secret")
+	api.BaseURL = s.URL + "/open-apis"
+	api.HTTPClient = s.Client()
+	return api
+}
+
+// Handle sets the JSON response of path (without /open-apis prefix), for
+// example "/chat/v4/list/".
+func (s *Server) Handle(path string, response interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.responses[path] = response
+}
+
+// Requests returns requests received so far.
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	path := strings.TrimPrefix(r.URL.Path, "/open-apis")
+	s.mutex.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	response, ok := s.responses[path]
+	if !ok {
+		switch path {
+		case "/auth/v3/tenant_access_token/internal":
+			ok, response = true, map[string]interface{}{
+				"code":                0,
+				"msg":                 "ok",
+				"expire":              7200,
+				"tenant_access_token": "t-test",
+			}
+		case "/message/v4/send/", "/im/v1/messages", messageReplyPath(path):
+			s.messageId++
+			ok, response = true, map[string]interface{}{
+				"code": 0,
+				"msg":  "success",
+				"data": map[string]string{
+					"message_id": "om_" + strconv.Itoa(s.messageId),
+				},
+			}
+		}
+	}
+	s.mutex.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		response = map[string]interface{}{
+			"code": 404,
+			"msg":  "not found",
+		}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// messageReplyPath returns path if it is the im/v1 reply path of a message.
+func messageReplyPath(path string) string {
+	if strings.HasPrefix(path, "/im/v1/messages/") && strings.HasSuffix(path, "/reply") {
+		return path
+	}
+	return ""
+}
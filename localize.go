@@ -0,0 +1,95 @@
+package larkslim
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// how long the locale of a user looked up by SendLocalized is cached
+const localeCacheTTL = 24 * time.Hour
+
+type (
+	// Catalog maps locales (zh_cn, en_us, ja_jp) to message keys and their
+	// fmt formats. Optional titles use the message key with ".title" suffix.
+	Catalog map[string]map[string]string
+)
+
+var ErrNoTranslations = errors.New("no translations found for the key")
+
+// Post returns a post message containing the message of key in every locale
+// of the catalog that has it. Lark clients display the locale matching the
+// language setting of the recipient.
+func (c Catalog) Post(key string, args ...interface{}) (post Post, err error) {
+	for locale, messages := range c {
+		format, ok := messages[key]
+		if !ok {
+			continue
+		}
+		if post == nil {
+			post = Post{}
+		}
+		var lines PostLines
+		for _, line := range strings.Split(fmt.Sprintf(format, args...), "\n") {
+			lines = append(lines, PostLine{{Tag: "text", Text: line}})
+		}
+		post[locale] = PostOfLocale{
+			Title:   messages[key+".title"],
+			Content: lines,
+		}
+	}
+	if post == nil {
+		err = ErrNoTranslations
+	}
+	return
+}
+
+// SendLocalized sends the message of key in the catalog of the API as a post
+// message. For users, only the locale of the user (see UserLocale) is sent,
+// or DefaultLocale if UserLocale is not set or the catalog doesn't have the
+// locale. Chats, and users without either locale in the catalog, get all
+// locales of the catalog, and clients display the one matching their
+// language setting.
+func (api *API) SendLocalized(target, key string, args ...interface{}) (messageId string, err error) {
+	var post Post
+	post, err = api.Catalog.Post(key, args...)
+	if err != nil {
+		return
+	}
+	if !strings.HasPrefix(target, "oc_") {
+		for _, locale := range []string{api.userLocale(target), api.DefaultLocale} {
+			if content, ok := post[locale]; ok {
+				post = Post{locale: content}
+				break
+			}
+		}
+	}
+	return api.SendPost(target, post)
+}
+
+// userLocale returns the locale of the user from UserLocale, cached for
+// localeCacheTTL, or an empty string if UserLocale is not set or the user
+// can't be looked up.
+func (api *API) userLocale(userId string) (locale string) {
+	if api.UserLocale == nil {
+		return
+	}
+	cache := api.cache()
+	key := api.cacheKey("locale", userId)
+	if value, ok, err := cache.Get(key); err == nil && ok {
+		return string(value)
+	}
+	user, err := api.GetUserInfo(userId)
+	if err != nil {
+		if api.Debugger != nil {
+			api.Debugger("failed to get locale of", userId+":", err)
+		}
+		return
+	}
+	locale = api.UserLocale(user)
+	if err := cache.Set(key, []byte(locale), localeCacheTTL); err != nil && api.Debugger != nil {
+		api.Debugger("locale cache error:", err)
+	}
+	return
+}
@@ -0,0 +1,89 @@
+package larkslim
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// MaxMessageLength is the maximum number of characters of each reply
+	// sent by SendLong.
+	MaxMessageLength = 4000
+
+	// MaxLongReplies is the maximum number of replies sent by SendLong,
+	// longer content is uploaded as a text file instead.
+	MaxLongReplies = 5
+)
+
+// ErrLongContentNotSent is returned by SendLong when the summary is held
+// during QuietHours or skipped by DedupWindow, so there is no thread to send
+// the content to.
+var ErrLongContentNotSent = errors.New("content not sent because the summary was held or skipped")
+
+// SendLong sends summary as a text message to the target and content as
+// replies in its thread, split at line boundaries into messages of at most
+// MaxMessageLength characters. If content needs more than MaxLongReplies
+// messages, it is uploaded as fileName (for example "build.log") and sent as
+// a file reply instead, so that long logs don't flood the chat. Pass
+// Critical() to send the content during quiet hours.
+func (api *API) SendLong(target, summary, content, fileName string, opts ...SendOption) (err error) {
+	var rootId string
+	rootId, err = api.send(target, "text", TextContent{summary}, opts...)
+	if err != nil || content == "" {
+		return
+	}
+	if rootId == "" {
+		err = ErrLongContentNotSent
+		return
+	}
+	opts = append(opts, InThread(rootId))
+	chunks := splitLines(content, MaxMessageLength)
+	if len(chunks) > MaxLongReplies {
+		var key string
+		key, err = api.UploadFile(strings.NewReader(content), "stream", fileName)
+		if err != nil {
+			return
+		}
+		_, err = api.send(target, "file", FileContent{key}, opts...)
+		return
+	}
+	for _, chunk := range chunks {
+		_, err = api.send(target, "text", TextContent{chunk}, opts...)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// splitLines splits text into chunks of at most max characters, at line
+// boundaries unless a line itself is longer than max. Chunks of only blank
+// lines are skipped, as empty messages can't be sent.
+func splitLines(text string, max int) (chunks []string) {
+	var b strings.Builder
+	n := 0
+	flush := func() {
+		if chunk := strings.TrimRight(b.String(), "\n"); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		b.Reset()
+		n = 0
+	}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for utf8.RuneCountInString(line) > max {
+			flush()
+			runes := []rune(line)
+			chunks = append(chunks, string(runes[:max]))
+			line = string(runes[max:])
+		}
+		l := utf8.RuneCountInString(line)
+		if n+l > max {
+			flush()
+		}
+		b.WriteString(line)
+		n += l
+	}
+	flush()
+	return
+}
@@ -0,0 +1,91 @@
+package larkslim
+
+// maximum number of users of each add or remove request
+const maxChatUsersPerRequest = 200
+
+type (
+	// MemberDiff is the changes made (or to be made in dry-run mode) by
+	// SyncChatMembers.
+	MemberDiff struct {
+		Add    []string
+		Remove []string
+	}
+)
+
+// SyncChatMembers adds users in desired (open ids) that are not members of
+// the chat and removes members not in desired, except the owner. If dryRun
+// is true, the changes are only computed and returned. Members are always
+// read from the API, not from the cache of ReadCacheTTL.
+func (api *API) SyncChatMembers(chatId string, desired []string, dryRun bool) (diff MemberDiff, err error) {
+	var group Group
+	group, err = api.getChatInfo(chatId)
+	if err != nil {
+		return
+	}
+	var current []string
+	for _, member := range group.Members {
+		if member.OpenId != group.OwnerOpenId {
+			current = append(current, member.OpenId)
+		}
+	}
+	diff = DiffMembers(current, desired)
+	if group.OwnerOpenId != "" {
+		var add []string
+		for _, openId := range diff.Add {
+			if openId != group.OwnerOpenId {
+				add = append(add, openId)
+			}
+		}
+		diff.Add = add
+	}
+	if dryRun {
+		return
+	}
+	for _, batch := range batches(diff.Add, maxChatUsersPerRequest) {
+		err = api.AddUsersToChat(chatId, batch)
+		if err != nil {
+			return
+		}
+	}
+	for _, batch := range batches(diff.Remove, maxChatUsersPerRequest) {
+		err = api.RemoveUsersFromChat(chatId, batch)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// DiffMembers returns ids in desired but not in current as Add and ids in
+// current but not in desired as Remove, in their original order.
+func DiffMembers(current, desired []string) (diff MemberDiff) {
+	inCurrent := map[string]bool{}
+	for _, id := range current {
+		inCurrent[id] = true
+	}
+	inDesired := map[string]bool{}
+	for _, id := range desired {
+		if !inCurrent[id] && !inDesired[id] {
+			diff.Add = append(diff.Add, id)
+		}
+		inDesired[id] = true
+	}
+	for _, id := range current {
+		if !inDesired[id] {
+			diff.Remove = append(diff.Remove, id)
+			inDesired[id] = true
+		}
+	}
+	return
+}
+
+func batches(ids []string, size int) (out [][]string) {
+	for len(ids) > size {
+		out = append(out, ids[:size])
+		ids = ids[size:]
+	}
+	if len(ids) > 0 {
+		out = append(out, ids)
+	}
+	return
+}
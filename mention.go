@@ -0,0 +1,118 @@
+package larkslim
+
+import (
+	"sort"
+	"strings"
+)
+
+type (
+	// Mention is an element of the mentions list of a message. The text of
+	// the message contains Key (like "@_user_1") in place of the mentioned
+	// user.
+	Mention struct {
+		Key       string    `json:"key"`
+		Id        MentionId `json:"id"`
+		Name      string    `json:"name"`
+		TenantKey string    `json:"tenant_key"`
+	}
+
+	MentionId struct {
+		UnionId string `json:"union_id"`
+		UserId  string `json:"user_id"`
+		OpenId  string `json:"open_id"`
+	}
+
+	BotInfoResponse struct {
+		APIResponse
+		Bot BotInfo `json:"bot"`
+	}
+
+	BotInfo struct {
+		ActivateStatus int      `json:"activate_status"`
+		AppName        string   `json:"app_name"`
+		AvatarUrl      string   `json:"avatar_url"`
+		IpWhiteList    []string `json:"ip_white_list"`
+		OpenId         string   `json:"open_id"`
+	}
+)
+
+// GetBotInfo returns information of the bot, including its open_id which
+// can be used with IsMentioned and StripMention.
+func (api *API) GetBotInfo() (bot BotInfo, err error) {
+	var data BotInfoResponse
+	err = api.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/bot/v3/info",
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	bot = data.Bot
+	return
+}
+
+// IsMentioned reports whether the user (or bot) with the open_id is in the
+// mentions list of a message.
+func IsMentioned(mentions []Mention, openId string) bool {
+	for _, m := range mentions {
+		if m.Id.OpenId == openId {
+			return true
+		}
+	}
+	return false
+}
+
+// StripMention removes the placeholders of the user (or bot) with the
+// open_id from text, together with the spaces left where they were.
+// Placeholders of other mentioned users are replaced with "@" and their
+// names. Other spaces and line breaks are kept.
+func StripMention(text string, mentions []Mention, openId string) string {
+	for _, m := range byKeyLength(mentions) {
+		if m.Key == "" {
+			continue
+		}
+		if m.Id.OpenId == openId {
+			text = removeMention(text, m.Key)
+		} else {
+			text = strings.Replace(text, m.Key, "@"+m.Name, -1)
+		}
+	}
+	return text
+}
+
+// byKeyLength returns a copy of mentions sorted by length of keys, longest
+// first, so that "@_user_1" is not replaced in "@_user_10".
+func byKeyLength(mentions []Mention) []Mention {
+	sorted := append([]Mention(nil), mentions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Key) > len(sorted[j].Key)
+	})
+	return sorted
+}
+
+// removeMention removes key from text, and the spaces after it, or before
+// it if it is at the end of a line.
+func removeMention(text, key string) string {
+	for {
+		i := strings.Index(text, key)
+		if i < 0 {
+			return text
+		}
+		j := i + len(key)
+		if end := j + len(text[j:]) - len(strings.TrimLeft(text[j:], " \t")); end > j {
+			j = end
+		} else {
+			i = len(strings.TrimRight(text[:i], " \t"))
+		}
+		text = text[:i] + text[j:]
+	}
+}
@@ -0,0 +1,170 @@
+package larkslim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+)
+
+type (
+	// Message is a message returned by GetMessage or ListMessages.
+	Message struct {
+		MessageId  string `json:"message_id"`
+		RootId     string `json:"root_id"`
+		ParentId   string `json:"parent_id"`
+		ThreadId   string `json:"thread_id"`
+		MsgType    string `json:"msg_type"`
+		ChatId     string `json:"chat_id"`
+		Deleted    bool   `json:"deleted"`
+		Updated    bool   `json:"updated"`
+		CreateTime string `json:"create_time"`
+		UpdateTime string `json:"update_time"`
+		Sender     struct {
+			Id string `json:"id"`
+			// "open_id" or "app_id"
+			IdType string `json:"id_type"`
+			// "user" or "app"
+			SenderType string `json:"sender_type"`
+		} `json:"sender"`
+		Body struct {
+			// JSON content of the message
+			Content string `json:"content"`
+		} `json:"body"`
+	}
+
+	MessagesResponse struct {
+		APIResponse
+		Data struct {
+			Items     []Message `json:"items"`
+			PageToken string    `json:"page_token"`
+			HasMore   bool      `json:"has_more"`
+		} `json:"data"`
+	}
+)
+
+// GetMessage returns the message, for example to find the chat of a message
+// in an event that has only the message id.
+func (api *API) GetMessage(messageId string) (message Message, err error) {
+	var data MessagesResponse
+	err = api.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/im/v1/messages/"+messageId,
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	if len(data.Data.Items) == 0 {
+		err = errors.New("message not found: " + messageId)
+		return
+	}
+	message = data.Data.Items[0]
+	return
+}
+
+// UpdateMessage changes the content of a sent message with the im/v1 API.
+// Content is TextContent or PostContent to edit a text or post message
+// (editing is allowed within 24 hours and up to 20 times), or Card (or any
+// other value marshaled as a card) to update a card message, which must have
+// been sent with CardConfig.UpdateMulti if shared by all members.
+func (api *API) UpdateMessage(messageId string, content interface{}) (err error) {
+	method, msgType := "PUT", ""
+	switch content.(type) {
+	case TextContent:
+		msgType = "text"
+	case PostContent:
+		msgType = "post"
+	default:
+		method = "PATCH"
+	}
+	// edits are filtered like new messages, the target is not known
+	filterType := msgType
+	if filterType == "" {
+		filterType = "interactive"
+	}
+	content, err = api.filter("", filterType, content)
+	if err != nil {
+		return
+	}
+	if v, ok := content.(PostContent); ok {
+		content = v.Post
+	}
+	var contentJSON []byte
+	contentJSON, err = json.Marshal(content)
+	if err != nil {
+		return
+	}
+	err = api.NewRequest(
+		// method
+		method,
+
+		// path
+		"/im/v1/messages/"+messageId,
+
+		// request body
+		struct {
+			MsgType string `json:"msg_type,omitempty"`
+			Content string `json:"content"`
+		}{msgType, string(contentJSON)},
+
+		// response
+		nil,
+	)
+	if api.OnMessageSent != nil {
+		if err != nil {
+			messageId = ""
+		}
+		api.OnMessageSent("", filterType, messageId, err)
+	}
+	return
+}
+
+// RecallMessage recalls a message sent by the bot. Messages older than the
+// recall time limit of the tenant (24 hours by default) can't be recalled.
+func (api *API) RecallMessage(messageId string) (err error) {
+	err = api.NewRequest(
+		// method
+		"DELETE",
+
+		// path
+		"/im/v1/messages/"+messageId,
+
+		// request body
+		nil,
+
+		// response
+		nil,
+	)
+	return
+}
+
+// ForwardMessage forwards a message to the target (open id, chat id, union
+// id, user id or email) and returns the id of the new message.
+func (api *API) ForwardMessage(messageId, target string) (newMessageId string, err error) {
+	var data MessageResponse
+	err = api.NewRequest(
+		// method
+		"POST",
+
+		// path
+		"/im/v1/messages/"+messageId+"/forward?receive_id_type="+url.QueryEscape(receiveIdType(target)),
+
+		// request body
+		struct {
+			ReceiveId string `json:"receive_id"`
+		}{target},
+
+		// response
+		&data,
+	)
+	newMessageId = data.Data.MessageId
+	return
+}
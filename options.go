@@ -0,0 +1,63 @@
+package larkslim
+
+type (
+	// SendOption changes how a message is sent by the Send* methods.
+	SendOption func(*sendOptions)
+
+	sendOptions struct {
+		critical bool
+		rootId   string
+		fallback string
+
+		// reply in a thread (im/v1 reply_in_thread) rather than quote
+		inThread bool
+
+		// forces the version of the send endpoint
+		version string
+
+		// if set, the thread id of the sent message is stored in it,
+		// im/v1 only
+		threadId *string
+	}
+)
+
+// Critical sends the message immediately even during quiet hours of the
+// target.
+func Critical() SendOption {
+	return func(o *sendOptions) {
+		o.critical = true
+	}
+}
+
+// InThread sends the message as a reply in the thread of the message rootId.
+func InThread(rootId string) SendOption {
+	return func(o *sendOptions) {
+		o.rootId = rootId
+		o.inThread = true
+	}
+}
+
+// ReplyTo sends the message as a reply quoting the message parentId in the
+// chat. With the legacy v4 API it is the same as InThread.
+func ReplyTo(parentId string) SendOption {
+	return func(o *sendOptions) {
+		o.rootId = parentId
+		o.inThread = false
+	}
+}
+
+// FileFallback uploads the content as a file named fileName (for example
+// "report.md") and sends it with a short pointer message instead, if the
+// message is too large to be sent, see oversized.
+func FileFallback(fileName string) SendOption {
+	return func(o *sendOptions) {
+		o.fallback = fileName
+	}
+}
+
+func newSendOptions(opts []SendOption) (o sendOptions) {
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return
+}
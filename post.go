@@ -0,0 +1,115 @@
+package larkslim
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+type (
+	// PostBuilder builds post messages line by line:
+	//
+	//	post := larkslim.NewPostBuilder("title").
+	//		Text("Hello ").Link("world", "https://example.com").
+	//		NewLine().
+	//		Tag(imageTag).
+	//		Post("zh_cn")
+	PostBuilder struct {
+		title string
+		lines PostLines
+		line  PostLine
+	}
+)
+
+func NewPostBuilder(title string) *PostBuilder {
+	return &PostBuilder{title: title}
+}
+
+// Tag appends a tag to the current line. Image tags are put on their own
+// line.
+func (b *PostBuilder) Tag(tag PostTag) *PostBuilder {
+	if tag.Tag == "img" {
+		b.NewLine()
+		b.lines = append(b.lines, PostLine{tag})
+		return b
+	}
+	b.line = append(b.line, tag)
+	return b
+}
+
+func (b *PostBuilder) Text(text string) *PostBuilder {
+	return b.Tag(PostTag{Tag: "text", Text: text})
+}
+
+func (b *PostBuilder) Link(text, href string) *PostBuilder {
+	return b.Tag(PostTag{Tag: "a", Text: text, Href: href})
+}
+
+// At mentions the user, use "all" to mention everyone.
+func (b *PostBuilder) At(userId string) *PostBuilder {
+	return b.Tag(PostTag{Tag: "at", UserId: userId})
+}
+
+// Image adds an image. Width and height can be zero if unknown, but images
+// are displayed with correct aspect ratio only if they are set, see
+// UploadPostImage and ImageTag.
+func (b *PostBuilder) Image(imageKey string, width, height int) *PostBuilder {
+	return b.Tag(PostTag{Tag: "img", ImageKey: imageKey, Width: width, Height: height})
+}
+
+// NewLine ends the current line.
+func (b *PostBuilder) NewLine() *PostBuilder {
+	if len(b.line) > 0 {
+		b.lines = append(b.lines, b.line)
+		b.line = nil
+	}
+	return b
+}
+
+// Post returns the post message of the locale, for example "zh_cn".
+func (b *PostBuilder) Post(locale string) Post {
+	b.NewLine()
+	return Post{
+		locale: PostOfLocale{
+			Title:   b.title,
+			Content: append(PostLines(nil), b.lines...),
+		},
+	}
+}
+
+// ImageTag returns an img tag of the image key with width and height
+// decoded from the PNG, JPEG or GIF image r.
+func ImageTag(imageKey string, r io.Reader) (tag PostTag, err error) {
+	var config image.Config
+	config, _, err = image.DecodeConfig(r)
+	if err != nil {
+		return
+	}
+	tag = PostTag{
+		Tag:      "img",
+		ImageKey: imageKey,
+		Width:    config.Width,
+		Height:   config.Height,
+	}
+	return
+}
+
+// UploadPostImage uploads the image as a message image and returns an img
+// tag with its key, width and height.
+func (api *API) UploadPostImage(file io.Reader) (tag PostTag, err error) {
+	var buf bytes.Buffer
+	var key string
+	key, err = api.UploadMessageImage(io.TeeReader(file, &buf))
+	if err != nil {
+		return
+	}
+	tag, err = ImageTag(key, &buf)
+	if err != nil {
+		// unknown format, let the client decide the size
+		tag, err = PostTag{Tag: "img", ImageKey: key}, nil
+	}
+	return
+}
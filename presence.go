@@ -0,0 +1,85 @@
+package larkslim
+
+type (
+	contactUserStatusResponse struct {
+		APIResponse
+		Data struct {
+			User struct {
+				Status UserStatus `json:"status"`
+			} `json:"user"`
+		} `json:"data"`
+	}
+
+	// UserStatus is the account status of a user. The open platform does
+	// not expose online or typing status, so this is the best available
+	// signal of whether a user can receive messages.
+	UserStatus struct {
+		IsFrozen    bool `json:"is_frozen"`
+		IsResigned  bool `json:"is_resigned"`
+		IsActivated bool `json:"is_activated"`
+		IsExited    bool `json:"is_exited"`
+		IsUnjoin    bool `json:"is_unjoin"`
+	}
+)
+
+// GetUserStatus returns the account status of the user with the open_id.
+// Requires the contact:user.base:readonly scope.
+func (api *API) GetUserStatus(openId string) (status UserStatus, err error) {
+	var data contactUserStatusResponse
+	err = api.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/contact/v3/users/"+openId+"?user_id_type=open_id",
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	status = data.Data.User.Status
+	return
+}
+
+// Active reports whether the user has activated the account and has not been
+// frozen, resigned or exited.
+func (status UserStatus) Active() bool {
+	return status.IsActivated && !status.IsFrozen && !status.IsResigned && !status.IsExited
+}
+
+// PreferDM returns openId as the target if the user is active, otherwise
+// chatId, so that messages for inactive users reach the group instead.
+func (api *API) PreferDM(openId, chatId string) (target string, err error) {
+	var status UserStatus
+	status, err = api.GetUserStatus(openId)
+	if err != nil {
+		return
+	}
+	if status.Active() {
+		target = openId
+	} else {
+		target = chatId
+	}
+	return
+}
+
+// PreferGroup returns chatId as the target if the user is a member of the
+// chat, otherwise openId, so that the user sees the message either way.
+func (api *API) PreferGroup(chatId, openId string) (target string, err error) {
+	var group Group
+	group, err = api.GetChatInfo(chatId)
+	if err != nil {
+		return
+	}
+	if group.HasMember(openId) {
+		target = chatId
+	} else {
+		target = openId
+	}
+	return
+}
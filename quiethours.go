@@ -0,0 +1,106 @@
+package larkslim
+
+import (
+	"time"
+)
+
+type (
+	// QuietHours is a daily time window during which messages are held.
+	// Start and End are durations since midnight, End may be less than
+	// Start for windows spanning midnight, for example Start 22h and End 8h.
+	QuietHours struct {
+		Start time.Duration
+		End   time.Duration
+
+		// defaults to time.Local
+		Location *time.Location
+	}
+
+	heldMessage struct {
+		msgType string
+		content interface{}
+		options sendOptions
+	}
+)
+
+// Remaining returns how long until the quiet hours end, or zero if t is not
+// in the quiet hours.
+func (q QuietHours) Remaining(t time.Time) time.Duration {
+	if q.Start == q.End {
+		return 0
+	}
+	loc := q.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	sinceMidnight := t.Sub(midnight)
+	if q.Start < q.End {
+		if sinceMidnight >= q.Start && sinceMidnight < q.End {
+			return q.End - sinceMidnight
+		}
+		return 0
+	}
+	if sinceMidnight >= q.Start {
+		return midnight.AddDate(0, 0, 1).Add(q.End).Sub(t)
+	}
+	if sinceMidnight < q.End {
+		return q.End - sinceMidnight
+	}
+	return 0
+}
+
+// hold queues the message if the target is in its quiet hours and returns
+// true. Held messages of a target are sent in order when the quiet hours
+// end.
+func (api *API) hold(target, msgType string, content interface{}, options sendOptions) bool {
+	quietHours, ok := api.QuietHours[target]
+	if !ok {
+		quietHours, ok = api.QuietHours[""]
+	}
+	if !ok {
+		return false
+	}
+	wait := quietHours.Remaining(api.clock().Now())
+	if wait <= 0 {
+		return false
+	}
+	state := api.state()
+	state.heldMutex.Lock()
+	defer state.heldMutex.Unlock()
+	if state.held == nil {
+		state.held = map[string][]heldMessage{}
+	}
+	if len(state.held[target]) == 0 {
+		// sent after the caller has returned, so not with its context
+		detached := api.detached()
+		api.clock().AfterFunc(wait, func() {
+			detached.sendHeld(target)
+		})
+	}
+	// the caller has got an empty id already
+	options.threadId = nil
+	state.held[target] = append(state.held[target], heldMessage{msgType, content, options})
+	return true
+}
+
+func (api *API) sendHeld(target string) {
+	state := api.state()
+	state.heldMutex.Lock()
+	messages := state.held[target]
+	delete(state.held, target)
+	state.heldMutex.Unlock()
+	for _, msg := range messages {
+		options := msg.options
+		// sent with the same options, for example replies to the same
+		// message, but not held again
+		_, err := api.send(target, msg.msgType, msg.content, func(o *sendOptions) {
+			*o = options
+			o.critical = true
+		})
+		if err != nil && api.Debugger != nil {
+			api.Debugger("failed to send held message to", target+":", err)
+		}
+	}
+}
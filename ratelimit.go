@@ -0,0 +1,74 @@
+package larkslim
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// RateLimiter is a token bucket limiting the rate of requests. It is
+	// safe for concurrent use, requests wait for their turn instead of
+	// failing.
+	RateLimiter struct {
+		rate  float64 // tokens per second
+		burst float64
+
+		mutex  sync.Mutex
+		tokens float64
+		last   time.Time
+	}
+)
+
+// NewRateLimiter returns a RateLimiter allowing rate requests per second on
+// average and bursts of at most burst requests.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve takes a token and returns how long to wait before it can be
+// used.
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait blocks until the request is allowed by RateLimiter and the limiter
+// of its endpoint in EndpointRateLimiters.
+func (api *API) wait(req *http.Request) {
+	clock := api.clock()
+	var wait time.Duration
+	if api.RateLimiter != nil {
+		wait = api.RateLimiter.reserve(clock.Now())
+	}
+	if l := api.EndpointRateLimiters[req.URL.Path]; l != nil {
+		if w := l.reserve(clock.Now()); w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		if api.Debugger != nil {
+			api.Debugger("rate limited, waiting", wait)
+		}
+		clock.Sleep(wait)
+	}
+}
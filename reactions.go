@@ -0,0 +1,119 @@
+package larkslim
+
+import (
+	"net/url"
+)
+
+type (
+	// Reaction is an emoji reaction to a message.
+	Reaction struct {
+		ReactionId string `json:"reaction_id"`
+		Operator   struct {
+			// open id of the user or app id of the bot
+			OperatorId string `json:"operator_id"`
+			// "user" or "app"
+			OperatorType string `json:"operator_type"`
+		} `json:"operator"`
+		// milliseconds since epoch
+		ActionTime   string       `json:"action_time"`
+		ReactionType ReactionType `json:"reaction_type"`
+	}
+
+	ReactionType struct {
+		EmojiType string `json:"emoji_type"`
+	}
+
+	ReactionResponse struct {
+		APIResponse
+		Data Reaction `json:"data"`
+	}
+
+	ReactionsResponse struct {
+		APIResponse
+		Data struct {
+			Items     []Reaction `json:"items"`
+			HasMore   bool       `json:"has_more"`
+			PageToken string     `json:"page_token"`
+		} `json:"data"`
+	}
+)
+
+// CreateReaction adds an emoji reaction, for example "THUMBSUP", "OK" or
+// "DONE", to a message and returns the id of the reaction. Bots commonly
+// acknowledge a command with a reaction instead of a reply.
+func (api *API) CreateReaction(messageId, emojiType string) (reactionId string, err error) {
+	var data ReactionResponse
+	err = api.NewRequest(
+		// method
+		"POST",
+
+		// path
+		"/im/v1/messages/"+messageId+"/reactions",
+
+		// request body
+		struct {
+			ReactionType ReactionType `json:"reaction_type"`
+		}{ReactionType{emojiType}},
+
+		// response
+		&data,
+	)
+	reactionId = data.Data.ReactionId
+	return
+}
+
+// DeleteReaction removes a reaction added by the bot from a message.
+func (api *API) DeleteReaction(messageId, reactionId string) (err error) {
+	err = api.NewRequest(
+		// method
+		"DELETE",
+
+		// path
+		"/im/v1/messages/"+messageId+"/reactions/"+reactionId,
+
+		// request body
+		nil,
+
+		// response
+		nil,
+	)
+	return
+}
+
+// ListReactions returns all reactions to a message, or only reactions of
+// emojiType if it is not empty.
+func (api *API) ListReactions(messageId, emojiType string) (reactions []Reaction, err error) {
+	var pageToken string
+	for {
+		var data ReactionsResponse
+		query := url.Values{}
+		query.Set("page_size", "50")
+		if emojiType != "" {
+			query.Set("reaction_type", emojiType)
+		}
+		if pageToken != "" {
+			query.Set("page_token", pageToken)
+		}
+		err = api.NewRequest(
+			// method
+			"GET",
+
+			// path
+			"/im/v1/messages/"+messageId+"/reactions?"+query.Encode(),
+
+			// request body
+			nil,
+
+			// response
+			&data,
+		)
+		if err != nil {
+			return
+		}
+		reactions = append(reactions, data.Data.Items...)
+		if !data.Data.HasMore || data.Data.PageToken == "" {
+			return
+		}
+		pageToken = data.Data.PageToken
+	}
+}
@@ -0,0 +1,71 @@
+package larkslim
+
+import (
+	"encoding/json"
+)
+
+// cachedRead unmarshals the result cached under key to v, or calls read,
+// which sets v, and caches v for ReadCacheTTL.
+func (api *API) cachedRead(key string, v interface{}, read func() error) (err error) {
+	if api.ReadCacheTTL <= 0 {
+		return read()
+	}
+	cache := api.cache()
+	key = api.cacheKey("read", key)
+	value, ok, err := cache.Get(key)
+	if err == nil && ok && json.Unmarshal(value, v) == nil {
+		return
+	}
+	if err != nil && api.Debugger != nil {
+		api.Debugger("read cache error:", err)
+	}
+	if err = read(); err != nil {
+		return
+	}
+	if value, err := json.Marshal(v); err == nil {
+		if err := cache.Set(key, value, api.ReadCacheTTL); err != nil && api.Debugger != nil {
+			api.Debugger("read cache error:", err)
+		}
+	}
+	return
+}
+
+// InvalidateUser removes the cached result of GetUserInfo of the user.
+func (api *API) InvalidateUser(userId string) {
+	api.invalidate("user:" + userId)
+}
+
+// InvalidateChat removes the cached results of GetChatInfo of the chat and
+// ListAllChats.
+func (api *API) InvalidateChat(chatId string) {
+	api.invalidate("chat:" + chatId)
+	api.invalidate("chats")
+}
+
+// InvalidateOnEvent removes cached results that may have been changed by
+// the contact or chat event, for example user_update or remove_bot. Call it
+// in the event handler if ReadCacheTTL is set.
+func (api *API) InvalidateOnEvent(event EventResponse) {
+	e := event.Event
+	switch e.Type {
+	case "user_update", "user_leave", "user_status_change":
+		for _, userId := range []string{e.OpenId, e.EmployeeId, e.UnionId} {
+			if userId != "" {
+				api.InvalidateUser(userId)
+			}
+		}
+	case "add_bot", "remove_bot", "chat_disband", "group_setting_update",
+		"add_user_to_chat", "remove_user_from_chat", "revoke_add_user_from_chat",
+		"p2p_chat_create":
+		api.InvalidateChat(e.ChatId)
+	}
+}
+
+func (api *API) invalidate(key string) {
+	if api.ReadCacheTTL <= 0 {
+		return
+	}
+	if err := api.cache().Delete(api.cacheKey("read", key)); err != nil && api.Debugger != nil {
+		api.Debugger("read cache error:", err)
+	}
+}
@@ -0,0 +1,152 @@
+package larkslim
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+type idempotentKey struct{}
+
+// DefaultRetryPolicy retries rate limited requests, server errors and network
+// timeouts with exponential backoff starting from 500ms up to 30s, with
+// jitter. Retry-After of rate limited responses is respected.
+func DefaultRetryPolicy(attempt int, err error) (wait time.Duration, retry bool) {
+	var e *Error
+	if errors.As(err, &e) && e.RetryAfter > 0 {
+		return e.RetryAfter, true
+	}
+	switch Classify(err) {
+	case CategoryRateLimit, CategoryServer:
+		retry = true
+	default:
+		var netErr net.Error
+		retry = errors.As(err, &netErr) && netErr.Timeout()
+	}
+	if !retry {
+		return
+	}
+	wait = 500 * time.Millisecond << uint(attempt)
+	if wait > 30*time.Second || wait <= 0 {
+		wait = 30 * time.Second
+	}
+	// full jitter within the upper half
+	wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+	return
+}
+
+// do sends the request, retrying it according to RetryPolicy at most
+// MaxRetries times. Requests that are not idempotent are not retried after
+// errors without a response (like timeouts) once they have been written,
+// because the server may have processed them.
+func (api *API) do(req *http.Request, respData interface{}) (err error) {
+	policy := api.RetryPolicy
+	if policy == nil {
+		policy = DefaultRetryPolicy
+	}
+	idempotent := isIdempotent(req)
+	for attempt := 0; ; attempt++ {
+		var wrote int32
+		attemptReq := req
+		if !idempotent {
+			attemptReq = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+				WroteRequest: func(info httptrace.WroteRequestInfo) {
+					if info.Err == nil {
+						atomic.StoreInt32(&wrote, 1)
+					}
+				},
+			}))
+		}
+		if api.hedgeable(attemptReq) {
+			err = api.doHedged(attemptReq, respData)
+		} else {
+			err = api.doOnce(attemptReq, respData)
+		}
+		if err == nil || attempt >= api.MaxRetries {
+			return
+		}
+		var e *Error
+		if !idempotent && atomic.LoadInt32(&wrote) == 1 && !errors.As(err, &e) {
+			if api.Debugger != nil {
+				api.Debugger("not retrying", req.URL.String(), "which may have been processed, after error:", err)
+			}
+			return
+		}
+		wait, retry := policy(attempt, err)
+		if !retry {
+			return
+		}
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return
+			}
+		}
+		if api.Debugger != nil {
+			api.Debugger("retrying", req.URL.String(), "in", wait, "after error:", err)
+		}
+		if err = api.sleep(req.Context(), wait); err != nil {
+			return
+		}
+	}
+}
+
+// sleep waits for d, or returns the error of ctx if it is done first.
+func (api *API) sleep(ctx context.Context, d time.Duration) error {
+	if clock := api.clock(); clock != SystemClock {
+		clock.Sleep(d)
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// idempotent returns a copy of the API whose requests are retried even if
+// they may have been processed, for requests the server deduplicates, for
+// example messages sent with a uuid.
+func (api *API) idempotent() *API {
+	api.state()
+	copied := *api
+	copied.ctx = context.WithValue(api.context(), idempotentKey{}, true)
+	return &copied
+}
+
+// newUuid returns a random id for deduplication of requests.
+func newUuid() string {
+	b := make([]byte, 16)
+	cryptorand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isIdempotent reports whether req can be sent more than once safely.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+		return true
+	}
+	idempotent, _ := req.Context().Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+// retryAfter parses the Retry-After header in seconds.
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
@@ -0,0 +1,40 @@
+package larkslim
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+type (
+	// Scanner scans files for viruses or other disallowed content, for
+	// example with a ClamAV or ICAP client. Scan returns an error if the file
+	// must not be uploaded or used.
+	Scanner interface {
+		Scan(name string, file io.Reader) error
+	}
+
+	// ScannerFunc adapts a func to Scanner.
+	ScannerFunc func(name string, file io.Reader) error
+)
+
+func (f ScannerFunc) Scan(name string, file io.Reader) error {
+	return f(name, file)
+}
+
+// scan reads the file and scans it with Scanner, and returns a reader of the
+// same content to be uploaded. The file is returned unchanged if Scanner is
+// not set.
+func (api *API) scan(name string, file io.Reader) (io.Reader, error) {
+	if api.Scanner == nil {
+		return file, nil
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.Scanner.Scan(name, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
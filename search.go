@@ -0,0 +1,129 @@
+package larkslim
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+type (
+	SearchMessagesResponse struct {
+		APIResponse
+		Data struct {
+			Items     []string `json:"items"`
+			PageToken string   `json:"page_token"`
+			HasMore   bool     `json:"has_more"`
+		} `json:"data"`
+	}
+)
+
+// ListMessages returns a page of messages of the chat, newest first. The bot
+// must be in the chat and have the im:message.group_msg scope to read
+// messages of groups. See ListDepartments for pageToken.
+func (api *API) ListMessages(chatId, pageToken string) (messages []Message, nextPageToken string, err error) {
+	var data MessagesResponse
+	err = api.NewRequest(
+		// method
+		"GET",
+
+		// path
+		"/im/v1/messages?container_id_type=chat&sort_type=ByCreateTimeDesc&page_size=50&container_id="+url.QueryEscape(chatId)+"&page_token="+url.QueryEscape(pageToken),
+
+		// request body
+		nil,
+
+		// response
+		&data,
+	)
+	messages = data.Data.Items
+	if data.Data.HasMore {
+		nextPageToken = data.Data.PageToken
+	}
+	return
+}
+
+// FindMessages returns up to limit messages of the chat whose text contains
+// the query (case-insensitive), newest first, so FindMessages(chatId, "x", 1)
+// finds the last message about x. The history is scanned page by page until
+// enough messages are found, use a small limit for long chats. Limit <= 0
+// means no limit and the whole history is scanned.
+func (api *API) FindMessages(chatId, query string, limit int) (messages []Message, err error) {
+	query = strings.ToLower(query)
+	var pageToken string
+	for {
+		var page []Message
+		page, pageToken, err = api.ListMessages(chatId, pageToken)
+		if err != nil {
+			return
+		}
+		for _, message := range page {
+			if message.Deleted || !strings.Contains(strings.ToLower(message.Text()), query) {
+				continue
+			}
+			messages = append(messages, message)
+			if limit > 0 && len(messages) >= limit {
+				return
+			}
+		}
+		if pageToken == "" {
+			return
+		}
+	}
+}
+
+// SearchMessages searches messages visible to the user with the search
+// engine of Lark, optionally only in the chats, and returns a page of message
+// ids, which can be read with GetMessage. It requires a user access token,
+// call it on the API returned by WithUserAccessToken. See ListDepartments for
+// pageToken.
+func (api *API) SearchMessages(query string, chatIds []string, pageToken string) (messageIds []string, nextPageToken string, err error) {
+	var data SearchMessagesResponse
+	err = api.NewRequest(
+		// method
+		"POST",
+
+		// path
+		"/search/v2/message?user_id_type=open_id&page_size=50&page_token="+url.QueryEscape(pageToken),
+
+		// request body
+		struct {
+			Query   string   `json:"query"`
+			ChatIds []string `json:"chat_ids,omitempty"`
+		}{query, chatIds},
+
+		// response
+		&data,
+	)
+	messageIds = data.Data.Items
+	if data.Data.HasMore {
+		nextPageToken = data.Data.PageToken
+	}
+	return
+}
+
+// Text returns the plain text of a text or post message, or an empty string
+// for other types of messages.
+func (m Message) Text() string {
+	switch m.MsgType {
+	case "text":
+		var content TextContent
+		json.Unmarshal([]byte(m.Body.Content), &content)
+		return content.Text
+	case "post":
+		var content PostOfLocale
+		json.Unmarshal([]byte(m.Body.Content), &content)
+		lines := []string{}
+		if content.Title != "" {
+			lines = append(lines, content.Title)
+		}
+		for _, line := range content.Content {
+			var texts []string
+			for _, element := range line {
+				texts = append(texts, element.Text)
+			}
+			lines = append(lines, strings.Join(texts, ""))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
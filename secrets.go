@@ -0,0 +1,91 @@
+package larkslim
+
+import (
+	"errors"
+	"regexp"
+)
+
+// ErrSecretDetected is returned by SecretsFilter.Filter in block mode.
+var ErrSecretDetected = errors.New("message blocked because it contains a secret")
+
+// DefaultSecretPatterns detect obvious secrets: AWS access keys, private
+// keys, bearer tokens and GitHub and Slack tokens.
+var DefaultSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----(?s:.*?)(?:-----END [A-Z ]*PRIVATE KEY-----|$)`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{20,}=*`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+}
+
+type (
+	// SecretsFilter detects secrets in text and post messages and masks
+	// them or blocks the message. Use its Filter method as a SendFilter:
+	//
+	//	api.SendFilters = append(api.SendFilters, (&larkslim.SecretsFilter{}).Filter)
+	SecretsFilter struct {
+		// defaults to DefaultSecretPatterns
+		Patterns []*regexp.Regexp
+
+		// if true, messages containing secrets are not sent and
+		// ErrSecretDetected is returned, otherwise secrets are masked
+		Block bool
+
+		// replacement of secrets, defaults to "[REDACTED]"
+		Mask string
+	}
+)
+
+// Filter masks secrets in text and post content, or returns
+// ErrSecretDetected in block mode. Other content is returned unchanged.
+func (f *SecretsFilter) Filter(target, msgType string, content interface{}) (interface{}, error) {
+	found := false
+	mask := func(text string) string {
+		for _, pattern := range f.patterns() {
+			if !pattern.MatchString(text) {
+				continue
+			}
+			found = true
+			m := f.Mask
+			if m == "" {
+				m = "[REDACTED]"
+			}
+			text = pattern.ReplaceAllLiteralString(text, m)
+		}
+		return text
+	}
+	switch v := content.(type) {
+	case TextContent:
+		v.Text = mask(v.Text)
+		content = v
+	case PostContent:
+		post := Post{}
+		for locale, p := range v.Post {
+			p.Title = mask(p.Title)
+			var lines PostLines
+			for _, line := range p.Content {
+				var tags PostLine
+				for _, tag := range line {
+					tag.Text = mask(tag.Text)
+					tag.Href = mask(tag.Href)
+					tags = append(tags, tag)
+				}
+				lines = append(lines, tags)
+			}
+			p.Content = lines
+			post[locale] = p
+		}
+		content = PostContent{post}
+	}
+	if found && f.Block {
+		return nil, ErrSecretDetected
+	}
+	return content, nil
+}
+
+func (f *SecretsFilter) patterns() []*regexp.Regexp {
+	if f.Patterns != nil {
+		return f.Patterns
+	}
+	return DefaultSecretPatterns
+}
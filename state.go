@@ -0,0 +1,51 @@
+package larkslim
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// apiState holds the mutable state of an API behind a pointer, so that
+	// an API can be copied by value: NewAPI and the first method call set
+	// up the state, and all copies made afterwards share the cached access
+	// token and other state instead of copying locks.
+	apiState struct {
+		mutex                sync.Mutex
+		accessToken          string
+		accessTokenExpiredAt time.Time
+		refresh              *tokenRefresh
+
+		appAccessToken          string
+		appAccessTokenExpiredAt time.Time
+		appRefresh              *tokenRefresh
+
+		// used if API.Cache is not set
+		cache *MemoryCache
+
+		heldMutex sync.Mutex
+		held      map[string][]heldMessage
+
+		warnedMutex sync.Mutex
+		warned      map[string]bool
+	}
+
+	// tokenRefresh is an in-flight access token request.
+	tokenRefresh struct {
+		done      chan struct{}
+		token     string
+		expiresAt time.Time
+		err       error
+	}
+)
+
+var stateMutex sync.Mutex
+
+func (api *API) state() *apiState {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	if api.shared == nil {
+		api.shared = &apiState{}
+	}
+	return api.shared
+}
@@ -0,0 +1,31 @@
+package larkslim
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// UnmarshalStrict is like json.Unmarshal but returns an error if data
+// contains object keys which do not match any field of v, or if data is a
+// response of the open platform without code.
+func UnmarshalStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("strict: %s", err)
+	}
+	if _, ok := v.(interface{ apiResponse() }); ok {
+		var required struct {
+			Code *int `json:"code"`
+		}
+		json.Unmarshal(data, &required)
+		if required.Code == nil {
+			return errors.New("strict: response has no code")
+		}
+	}
+	return nil
+}
+
+func (APIResponse) apiResponse() {}
@@ -0,0 +1,193 @@
+package larkslim
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// ErrNotTable is returned by NewTable if the value is not a [][]string or a
+// slice of structs.
+var ErrNotTable = errors.New("value is not a [][]string or a slice of structs")
+
+type (
+	// Table is rows of cells, the first row is the header.
+	Table [][]string
+)
+
+// NewTable converts a [][]string or a slice of structs (or pointers to
+// structs) to a Table. Exported fields of structs become columns, named by
+// the "table" struct tag or the field name. Fields tagged `table:"-"` are
+// skipped.
+func NewTable(v interface{}) (table Table, err error) {
+	if rows, ok := v.([][]string); ok {
+		table = rows
+		return
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice {
+		err = ErrNotTable
+		return
+	}
+	typ := value.Type().Elem()
+	isPtr := typ.Kind() == reflect.Ptr
+	if isPtr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		err = ErrNotTable
+		return
+	}
+	var header []string
+	var fields []int
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get("table")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		header = append(header, name)
+		fields = append(fields, i)
+	}
+	table = Table{header}
+	for i := 0; i < value.Len(); i++ {
+		elem := value.Index(i)
+		row := make([]string, len(fields))
+		if isPtr {
+			if elem.IsNil() {
+				table = append(table, row)
+				continue
+			}
+			elem = elem.Elem()
+		}
+		for j, f := range fields {
+			row[j] = fmt.Sprint(elem.Field(f).Interface())
+		}
+		table = append(table, row)
+	}
+	return
+}
+
+// String renders the table as aligned plain text, to be displayed in a
+// monospace font.
+func (table Table) String() string {
+	var widths []int
+	for _, row := range table {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := textWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	var b strings.Builder
+	for n, row := range table {
+		var line strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cell)
+			line.WriteString(strings.Repeat(" ", widths[i]-textWidth(cell)))
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteString("\n")
+		if n == 0 && len(table) > 1 {
+			var sep []string
+			for _, w := range widths {
+				sep = append(sep, strings.Repeat("-", w))
+			}
+			b.WriteString(strings.Join(sep, "  "))
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Post renders the table as a post message of the locale (for example
+// "zh_cn"), one line for each row with cells separated by " | ".
+func (table Table) Post(locale, title string) Post {
+	var lines PostLines
+	for _, row := range table {
+		lines = append(lines, PostLine{{Tag: "text", Text: strings.Join(row, " | ")}})
+	}
+	return Post{
+		locale: PostOfLocale{
+			Title:   title,
+			Content: lines,
+		},
+	}
+}
+
+// Card renders the table as a card, one block of short fields for each row,
+// each field is the column name in bold followed by the cell.
+func (table Table) Card(title string) Card {
+	card := Card{
+		Config: CardConfig{
+			WideScreenMode: true,
+			EnableForward:  true,
+		},
+		Header: CardHeader{
+			Title: CardHeaderTitle{
+				Tag:     "plain_text",
+				Content: title,
+			},
+		},
+	}
+	if len(table) == 0 {
+		return card
+	}
+	header := table[0]
+	for n, row := range table[1:] {
+		if n > 0 {
+			card.Elements = append(card.Elements, map[string]interface{}{
+				"tag": "hr",
+			})
+		}
+		var fields []interface{}
+		for i, cell := range row {
+			content := cell
+			if i < len(header) {
+				content = "**" + header[i] + "**\n" + cell
+			}
+			fields = append(fields, map[string]interface{}{
+				"is_short": true,
+				"text": map[string]interface{}{
+					"tag":     "lark_md",
+					"content": content,
+				},
+			})
+		}
+		card.Elements = append(card.Elements, map[string]interface{}{
+			"tag":    "div",
+			"fields": fields,
+		})
+	}
+	return card
+}
+
+// textWidth returns the number of columns the text takes in a monospace
+// font, wide (CJK) characters take two columns.
+func textWidth(text string) (width int) {
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hangul, r),
+			unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r),
+			r >= 0xff01 && r <= 0xff60, r >= 0x3000 && r <= 0x303f:
+			width += 2
+		default:
+			width++
+		}
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package larkslim
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type (
+	// TokenStore stores access tokens, so that multiple processes or
+	// replicas of an app share a token instead of each fetching its own and
+	// hitting the rate limit of token issuance. Implementations must be
+	// safe for concurrent use.
+	TokenStore interface {
+		// Get returns the token of the key and when it expires, ok is
+		// false if there is no token.
+		Get(key string) (token string, expiresAt time.Time, ok bool, err error)
+
+		Set(key, token string, expiresAt time.Time) error
+	}
+
+	// CacheTokenStore is a TokenStore of a Cache, for example a Redis
+	// cache.
+	CacheTokenStore struct {
+		Cache Cache
+
+		// defaults to SystemClock
+		Clock Clock
+	}
+
+	// FileTokenStore is a TokenStore of a JSON file, to share tokens
+	// between processes on the same host.
+	FileTokenStore struct {
+		Path string
+
+		mutex sync.Mutex
+	}
+)
+
+func (s CacheTokenStore) Get(key string) (token string, expiresAt time.Time, ok bool, err error) {
+	value, ok, err := s.Cache.Get(key)
+	if err != nil || !ok {
+		return
+	}
+	var cached cachedToken
+	if json.Unmarshal(value, &cached) != nil {
+		ok = false
+		return
+	}
+	return cached.Token, cached.ExpiresAt, true, nil
+}
+
+func (s CacheTokenStore) Set(key, token string, expiresAt time.Time) error {
+	value, err := json.Marshal(cachedToken{token, expiresAt})
+	if err != nil {
+		return err
+	}
+	clock := s.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	return s.Cache.Set(key, value, expiresAt.Sub(clock.Now()))
+}
+
+func (s *FileTokenStore) Get(key string) (token string, expiresAt time.Time, ok bool, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	tokens, err := s.read()
+	if err != nil {
+		return
+	}
+	cached, ok := tokens[key]
+	return cached.Token, cached.ExpiresAt, ok, nil
+}
+
+func (s *FileTokenStore) Set(key, token string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	tokens[key] = cachedToken{token, expiresAt}
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first, so other processes never read a
+	// partially written file
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), ".tokens")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+// read returns tokens in the file, with mutex held. A file that does not
+// exist has no tokens.
+func (s *FileTokenStore) read() (tokens map[string]cachedToken, err error) {
+	tokens = map[string]cachedToken{}
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil || len(data) == 0 {
+		return
+	}
+	err = json.Unmarshal(data, &tokens)
+	return
+}
+
+// tokenStore returns TokenStore, or Cache as a TokenStore if only Cache is
+// set, or nil if neither is set.
+func (api *API) tokenStore() TokenStore {
+	if api.TokenStore != nil {
+		return api.TokenStore
+	}
+	if api.Cache != nil {
+		return CacheTokenStore{api.Cache, api.clock()}
+	}
+	return nil
+}
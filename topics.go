@@ -0,0 +1,55 @@
+package larkslim
+
+// IsTopicGroup reports whether the chat is a topic group, where every
+// message sent to the chat starts a new topic.
+func (chat ChatInfo) IsTopicGroup() bool {
+	return chat.ChatMode == "topic" || chat.GroupMessageType == "thread"
+}
+
+// CreateTopicGroup creates a topic group with the users of the open ids and
+// the bot, and returns the chat id.
+func (api *API) CreateTopicGroup(name string, openIds []string) (chatId string, err error) {
+	var data struct {
+		APIResponse
+		Data struct {
+			ChatId string `json:"chat_id"`
+		} `json:"data"`
+	}
+	err = api.NewRequest(
+		// method
+		"POST",
+
+		// path
+		"/im/v1/chats?user_id_type=open_id",
+
+		// request body
+		struct {
+			Name             string   `json:"name"`
+			UserIdList       []string `json:"user_id_list,omitempty"`
+			ChatMode         string   `json:"chat_mode"`
+			ChatType         string   `json:"chat_type"`
+			GroupMessageType string   `json:"group_message_type"`
+		}{name, openIds, "group", "private", "thread"},
+
+		// response
+		&data,
+	)
+	chatId = data.Data.ChatId
+	if err == nil {
+		api.invalidate("chats")
+	}
+	return
+}
+
+// CreateTopic sends a message to the topic group to start a new topic, and
+// returns the id of the message and of the topic, which are empty if the
+// message is held during QuietHours or skipped by DedupWindow. To post in
+// the topic, reply to the message with ReplyMessage and InThread(messageId);
+// received messages in the topic have the topic id in Event.ThreadId.
+func (api *API) CreateTopic(chatId, msgType string, content interface{}) (messageId, threadId string, err error) {
+	messageId, err = api.send(chatId, msgType, content, func(o *sendOptions) {
+		o.version = V1
+		o.threadId = &threadId
+	})
+	return
+}
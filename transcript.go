@@ -0,0 +1,97 @@
+package larkslim
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	Inbound  = "inbound"
+	Outbound = "outbound"
+)
+
+type (
+	// TranscriptStore stores the timeline of conversations, used by bot
+	// operators as an audit trail. Implementations must be safe for
+	// concurrent use.
+	TranscriptStore interface {
+		Append(entry TranscriptEntry) error
+	}
+
+	TranscriptEntry struct {
+		Time      time.Time `json:"time"`
+		Direction string    `json:"direction"`
+
+		// chat_id of inbound events and im/v1 outbound messages, target
+		// (open_id, user_id, email or chat_id) of other outbound messages
+		Chat string `json:"chat"`
+
+		// sender open_id of inbound events
+		OpenId    string          `json:"open_id,omitempty"`
+		MsgType   string          `json:"msg_type,omitempty"`
+		MessageId string          `json:"message_id,omitempty"`
+		Content   json.RawMessage `json:"content,omitempty"`
+		Error     string          `json:"error,omitempty"`
+	}
+
+	// MemoryTranscript is a TranscriptStore that keeps the latest entries of
+	// each chat in memory.
+	MemoryTranscript struct {
+		// max number of entries to keep for each chat, 0 means no limit
+		Limit int
+
+		mutex   sync.Mutex
+		entries map[string][]TranscriptEntry
+	}
+)
+
+func (t *MemoryTranscript) Append(entry TranscriptEntry) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.entries == nil {
+		t.entries = map[string][]TranscriptEntry{}
+	}
+	entries := append(t.entries[entry.Chat], entry)
+	if t.Limit > 0 && len(entries) > t.Limit {
+		entries = entries[len(entries)-t.Limit:]
+	}
+	t.entries[entry.Chat] = entries
+	return nil
+}
+
+// Entries returns a copy of entries of the chat, oldest first.
+func (t *MemoryTranscript) Entries(chat string) []TranscriptEntry {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]TranscriptEntry(nil), t.entries[chat]...)
+}
+
+// NewInboundTranscriptEntry creates a transcript entry from a received
+// event. Body is the raw JSON of the event.
+func NewInboundTranscriptEntry(event EventResponse, body []byte) TranscriptEntry {
+	return TranscriptEntry{
+		Time:      time.Now(),
+		Direction: Inbound,
+		Chat:      event.Event.ChatId,
+		OpenId:    event.Event.OpenId,
+		MsgType:   event.Event.MsgType,
+		MessageId: event.Event.MessageId,
+		Content:   json.RawMessage(body),
+	}
+}
+
+func newOutboundTranscriptEntry(now time.Time, target, msgType, messageId string, content interface{}, err error) TranscriptEntry {
+	entry := TranscriptEntry{
+		Time:      now,
+		Direction: Outbound,
+		Chat:      target,
+		MsgType:   msgType,
+		MessageId: messageId,
+	}
+	entry.Content, _ = json.Marshal(content)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
@@ -0,0 +1,59 @@
+package larkslim
+
+// TranslateText translates text from the source language to the target
+// language with the translation API of the open platform. Languages are
+// codes like "zh", "en" or "ja".
+func (api *API) TranslateText(srcLang, dstLang, text string) (translated string, err error) {
+	var data struct {
+		APIResponse
+		Data struct {
+			Text string `json:"text"`
+		} `json:"data"`
+	}
+	err = api.NewRequest(
+		// method
+		"POST",
+
+		// path
+		"/translation/v1/text/translate",
+
+		// request body
+		struct {
+			SourceLanguage string `json:"source_language"`
+			Text           string `json:"text"`
+			TargetLanguage string `json:"target_language"`
+		}{srcLang, text, dstLang},
+
+		// response
+		&data,
+	)
+	translated = data.Data.Text
+	return
+}
+
+// DetectLanguage returns the language code of the text, for example "zh".
+func (api *API) DetectLanguage(text string) (language string, err error) {
+	var data struct {
+		APIResponse
+		Data struct {
+			Language string `json:"language"`
+		} `json:"data"`
+	}
+	err = api.NewRequest(
+		// method
+		"POST",
+
+		// path
+		"/translation/v1/text/detect",
+
+		// request body
+		struct {
+			Text string `json:"text"`
+		}{text},
+
+		// response
+		&data,
+	)
+	language = data.Data.Language
+	return
+}
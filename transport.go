@@ -0,0 +1,48 @@
+package larkslim
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultTransport is the transport of API clients without HTTPClient. It
+// is shared by all APIs so that connections to the open platform are kept
+// alive and reused, and keeps more idle connections per host than
+// http.DefaultTransport to suit chatty bots sending concurrently.
+var DefaultTransport http.RoundTripper = NewTransport(32, 90*time.Second)
+
+// NewTransport returns a transport with HTTP/2 enabled and the given number
+// of idle (keep-alive) connections per host and idle timeout.
+func NewTransport(maxIdleConnsPerHost int, idleConnTimeout time.Duration) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func (api *API) client() *http.Client {
+	if api.HTTPClient != nil {
+		return api.HTTPClient
+	}
+	return &http.Client{
+		Transport: DefaultTransport,
+		Timeout:   api.Timeout,
+	}
+}
+
+func (api *API) baseURL() string {
+	if api.BaseURL != "" {
+		return api.BaseURL
+	}
+	return Prefix
+}
@@ -0,0 +1,150 @@
+package larkslim
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+type (
+	NamedReader struct {
+		Name string
+		io.Reader
+	}
+
+	fileUploadResponse struct {
+		APIResponse
+		Data struct {
+			FileKey string `json:"file_key"`
+		} `json:"data"`
+	}
+
+	// UploadErrors is returned by UploadMessageImages if any upload failed.
+	// It has the same length as the input, with nil for successful uploads.
+	UploadErrors []error
+)
+
+// UploadMessageImages uploads images using at most concurrency concurrent
+// requests and returns image keys in the order of files. Keys of failed
+// uploads are empty and their errors are returned in UploadErrors.
+func (api *API) UploadMessageImages(files []NamedReader, concurrency int) (keys []string, err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	keys = make([]string, len(files))
+	errs := make(UploadErrors, len(files))
+	var failed bool
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			key, err := api.UploadMessageImage(files[i])
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %s", files[i].Name, err)
+				failed = true
+				return
+			}
+			keys[i] = key
+		}(i)
+	}
+	wg.Wait()
+	if failed {
+		err = errs
+	}
+	return
+}
+
+// FileType returns the file type of UploadFile for the file name.
+func FileType(fileName string) string {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".opus":
+		return "opus"
+	case ".mp4":
+		return "mp4"
+	case ".pdf":
+		return "pdf"
+	case ".doc", ".docx":
+		return "doc"
+	case ".xls", ".xlsx":
+		return "xls"
+	case ".ppt", ".pptx":
+		return "ppt"
+	}
+	return "stream"
+}
+
+func (errs UploadErrors) Error() string {
+	var messages []string
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	return fmt.Sprintf("%d of %d uploads failed: %s", len(messages), len(errs), strings.Join(messages, "; "))
+}
+
+// UploadFile uploads the file (up to 30 MB) with im/v1 API and returns its
+// file key, which can be sent with SendFileMessage. fileType is one of opus,
+// mp4, pdf, doc, xls, ppt or stream (other types); empty fileType is
+// detected from the extension of fileName by FileType.
+func (api *API) UploadFile(file io.Reader, fileType, fileName string) (key string, err error) {
+	if fileType == "" {
+		fileType = FileType(fileName)
+	}
+	file, err = api.scan(fileName, file)
+	if err != nil {
+		return
+	}
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("file_type", fileType)
+	writer.WriteField("file_name", fileName)
+	var part io.Writer
+	part, err = writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return
+	}
+	_, err = io.Copy(part, file)
+	if err != nil {
+		return
+	}
+	err = writer.Close()
+	if err != nil {
+		return
+	}
+	var req *http.Request
+	req, err = api.newRequest(
+		// method
+		"POST",
+
+		// path
+		"/im/v1/files",
+
+		// request body
+		body,
+	)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	var data fileUploadResponse
+	err = api.do(req, &data)
+	if err == nil {
+		key = data.Data.FileKey
+	}
+	return
+}
@@ -0,0 +1,41 @@
+package larkslim
+
+import (
+	"errors"
+)
+
+// Urgent buzzes users about a message sent by the bot, which must be in a
+// chat with the users. Kind is "app" for in-app notifications, "sms" or
+// "phone", the latter two cost the tenant's urgent quota. It returns the
+// open ids that can't be buzzed, for example users not in the chat.
+func (api *API) Urgent(messageId, kind string, openIds []string) (invalidOpenIds []string, err error) {
+	switch kind {
+	case "app", "sms", "phone":
+	default:
+		err = errors.New("unknown urgent kind: " + kind)
+		return
+	}
+	var data struct {
+		APIResponse
+		Data struct {
+			InvalidUserIdList []string `json:"invalid_user_id_list"`
+		} `json:"data"`
+	}
+	err = api.NewRequest(
+		// method
+		"PATCH",
+
+		// path
+		"/im/v1/messages/"+messageId+"/urgent_"+kind+"?user_id_type=open_id",
+
+		// request body
+		struct {
+			UserIdList []string `json:"user_id_list"`
+		}{openIds},
+
+		// response
+		&data,
+	)
+	invalidOpenIds = data.Data.InvalidUserIdList
+	return
+}
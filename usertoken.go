@@ -0,0 +1,182 @@
+package larkslim
+
+import (
+	"net/url"
+	"time"
+)
+
+type (
+	AppAccessTokenResponse struct {
+		APIResponse
+		Expire int    `json:"expire"`
+		Token  string `json:"app_access_token"`
+	}
+
+	// UserAccessToken is an access token of a user obtained with OAuth,
+	// used to call user-scoped APIs like Drive and Calendar with
+	// WithUserAccessToken.
+	UserAccessToken struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+
+		// seconds until the access token and the refresh token expire
+		ExpiresIn        int `json:"expires_in"`
+		RefreshExpiresIn int `json:"refresh_expires_in"`
+
+		OpenId  string `json:"open_id"`
+		UnionId string `json:"union_id"`
+		UserId  string `json:"user_id"`
+		Name    string `json:"name"`
+		EnName  string `json:"en_name"`
+		Email   string `json:"email"`
+	}
+
+	UserAccessTokenResponse struct {
+		APIResponse
+		Data UserAccessToken `json:"data"`
+	}
+)
+
+// AppAccessToken returns the app access token and its expiry time, fetching
+// a new token if the cached one has expired. Like the tenant access token,
+// concurrent callers wait for a single in-flight request.
+func (api *API) AppAccessToken() (token string, expiresAt time.Time, err error) {
+	state := api.state()
+	state.mutex.Lock()
+	if state.appAccessToken != "" && !state.appAccessTokenExpiredAt.Before(api.clock().Now()) {
+		token, expiresAt = state.appAccessToken, state.appAccessTokenExpiredAt
+		state.mutex.Unlock()
+		return
+	}
+	if f := state.appRefresh; f != nil {
+		state.mutex.Unlock()
+		<-f.done
+		return f.token, f.expiresAt, f.err
+	}
+	f := &tokenRefresh{done: make(chan struct{})}
+	state.appRefresh = f
+	state.mutex.Unlock()
+
+	f.token, f.expiresAt, f.err = api.detached().fetchAppAccessToken()
+
+	state.mutex.Lock()
+	if f.err == nil {
+		state.appAccessToken = f.token
+		state.appAccessTokenExpiredAt = f.expiresAt
+	}
+	state.appRefresh = nil
+	state.mutex.Unlock()
+	close(f.done)
+	return f.token, f.expiresAt, f.err
+}
+
+func (api *API) fetchAppAccessToken() (token string, expiresAt time.Time, err error) {
+	start := api.clock().Now()
+	var data AppAccessTokenResponse
+	// fetching a token has no side effects, it is safe to retry
+	err = api.idempotent().NewRequest(
+		// method
+		"POST",
+
+		// path
+		getAppAccessToken,
+
+		// request body
+		Protected{
+			Original: map[string]string{
+				"app_id":     api.AppId,
+				"app_secret": api.AppSecret,
+			},
+			Filtered: map[string]string{
+				"app_id":     api.AppId,
+				"app_secret": "[filtered]",
+			},
+		},
+
+		// response
+		&data,
+	)
+	if err != nil {
+		return
+	}
+	token = data.Token
+	expiresAt = start.Add(api.tokenLifetime(data.Expire))
+	return
+}
+
+// AuthorizeURL returns the URL to redirect users to for authorization. After
+// the user agrees, the user is redirected to redirectURI (which must be in
+// the redirect URL list of the app) with the code and the state, the code
+// can then be exchanged with GetUserAccessToken.
+func (api *API) AuthorizeURL(redirectURI, state string) string {
+	query := url.Values{}
+	query.Set("app_id", api.AppId)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("state", state)
+	return api.baseURL() + "/authen/v1/index?" + query.Encode()
+}
+
+// GetUserAccessToken exchanges the code of the authorization redirect for an
+// access token of the user.
+func (api *API) GetUserAccessToken(code string) (token UserAccessToken, err error) {
+	return api.userAccessToken("/authen/v1/access_token", Protected{
+		Original: map[string]string{
+			"grant_type": "authorization_code",
+			"code":       code,
+		},
+		Filtered: map[string]string{
+			"grant_type": "authorization_code",
+			"code":       "[filtered]",
+		},
+	})
+}
+
+// RefreshUserAccessToken returns a new access token of the user with the
+// refresh token of an earlier one. Each refresh token can be used only once,
+// save the new refresh token for the next refresh.
+func (api *API) RefreshUserAccessToken(refreshToken string) (token UserAccessToken, err error) {
+	return api.userAccessToken("/authen/v1/refresh_access_token", Protected{
+		Original: map[string]string{
+			"grant_type":    "refresh_token",
+			"refresh_token": refreshToken,
+		},
+		Filtered: map[string]string{
+			"grant_type":    "refresh_token",
+			"refresh_token": "[filtered]",
+		},
+	})
+}
+
+func (api *API) userAccessToken(path string, reqBody Protected) (token UserAccessToken, err error) {
+	appToken, _, err := api.AppAccessToken()
+	if err != nil {
+		return
+	}
+	var data UserAccessTokenResponse
+	err = api.WithUserAccessToken(appToken).NewRequest(
+		// method
+		"POST",
+
+		// path
+		path,
+
+		// request body
+		reqBody,
+
+		// response
+		&data,
+	)
+	token = data.Data
+	return
+}
+
+// WithUserAccessToken returns a copy of the API that sends requests with the
+// access token instead of the tenant access token, to call APIs on behalf of
+// the user. The copy shares other state with the API.
+func (api *API) WithUserAccessToken(accessToken string) *API {
+	api.state()
+	copied := *api
+	copied.accessToken = accessToken
+	return &copied
+}